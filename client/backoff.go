@@ -0,0 +1,86 @@
+package client
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+const (
+	defaultDialTimeout      = 15 * time.Second
+	defaultHandshakeTimeout = 15 * time.Second
+	defaultRestoreTimeout   = 15 * time.Second
+)
+
+// Backoff controls the delay between connection attempts. The Client waits
+// after every round of failed attempts to all remotes, and the delay grows
+// by Factor after each round up to Max.
+type Backoff struct {
+	Initial time.Duration // delay after the first round of attempts
+	Max     time.Duration // upper bound of the delay
+	Factor  float64       // delay multiplier, values below 1 are treated as 1
+	Jitter  float64       // randomization of the delay in [0, 1], e.g. 0.2 means ±20%
+}
+
+var defaultBackoff = Backoff{
+	Initial: 1 * time.Second,
+	Max:     8 * time.Second,
+	Factor:  2,
+	Jitter:  0.2,
+}
+
+// next returns the delay of the round following the one with delay d.
+func (b Backoff) next(d time.Duration) time.Duration {
+	f := max(b.Factor, 1)
+	n := time.Duration(float64(d) * f)
+	if b.Max > 0 && n > b.Max {
+		n = b.Max
+	}
+	return n
+}
+
+// jittered returns d randomized according to b.Jitter.
+func (b Backoff) jittered(d time.Duration) time.Duration {
+	j := min(max(b.Jitter, 0), 1)
+	if j == 0 || d <= 0 {
+		return d
+	}
+	return time.Duration(float64(d) * (1 - j + 2*j*rand.Float64()))
+}
+
+// ConnectError is returned by Dial when the Client fails to connect to any of the remotes.
+// It unwraps to both the last connection error and the context error, if any,
+// so errors.Is(err, context.DeadlineExceeded) or errors.Is(err, syscall.ECONNREFUSED) work.
+type ConnectError struct {
+	Remotes  []Remote
+	Attempts int   // number of failed attempts
+	Err      error // last connection error, nil if no attempt was made
+	Cause    error // context error if the context ended, nil if attempts are exhausted
+}
+
+func (e *ConnectError) Error() string {
+	rs := make([]string, len(e.Remotes))
+	for i, r := range e.Remotes {
+		rs[i] = r.String()
+	}
+	msg := fmt.Sprintf("connect to %s: %d attempts failed", strings.Join(rs, ","), e.Attempts)
+	if e.Cause != nil {
+		msg += fmt.Sprintf(": %s", e.Cause)
+	}
+	if e.Err != nil {
+		msg += fmt.Sprintf(": last error: %s", e.Err)
+	}
+	return msg
+}
+
+func (e *ConnectError) Unwrap() []error {
+	var errs []error
+	if e.Cause != nil {
+		errs = append(errs, e.Cause)
+	}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
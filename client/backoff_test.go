@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"errors"
+	jrpc "github.com/kazmanavt/jsonrpc/v2/jrpc1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: 5 * time.Second, Factor: 2, Jitter: 0.5}
+	assert.Equal(t, 2*time.Second, b.next(time.Second))
+	assert.Equal(t, 5*time.Second, b.next(4*time.Second))
+	assert.Equal(t, time.Second, Backoff{Factor: 0.5}.next(time.Second))
+	for range 100 {
+		d := b.jittered(time.Second)
+		assert.GreaterOrEqual(t, d, 500*time.Millisecond)
+		assert.LessOrEqual(t, d, 1500*time.Millisecond)
+	}
+	assert.Equal(t, time.Second, Backoff{}.jittered(time.Second))
+}
+
+func TestDial_MaxAttempts(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "db.sock")
+	c, err := Dial(context.Background(), "unix", sock,
+		WithMaxAttempts(3),
+		WithBackoff(Backoff{Initial: time.Millisecond, Jitter: 0}))
+	require.Nil(t, c)
+	var cErr *ConnectError
+	require.ErrorAs(t, err, &cErr)
+	assert.Equal(t, 3, cErr.Attempts)
+	assert.NoError(t, cErr.Cause)
+	var opErr *net.OpError
+	assert.ErrorAs(t, err, &opErr)
+	assert.Contains(t, err.Error(), "unix:"+sock)
+}
+
+func TestDial_ContextDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var dials atomic.Int32
+	start := time.Now()
+	c, err := Dial(ctx, "pipe", "test",
+		WithBackoff(Backoff{Initial: 10 * time.Millisecond, Max: 20 * time.Millisecond}),
+		WithDialer(func(ctx context.Context) (net.Conn, error) {
+			dials.Add(1)
+			return nil, errors.New("no server")
+		}))
+	require.Nil(t, c)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "no server")
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.Greater(t, dials.Load(), int32(1))
+}
+
+func TestDial_DialTimeout(t *testing.T) {
+	// the listener never accepts, so the dial hangs until the dial timeout
+	l := newPipeListener()
+	defer l.Close()
+	_, err := Dial(context.Background(), "pipe", "test",
+		WithMaxAttempts(1),
+		WithDialTimeout(50*time.Millisecond),
+		WithDialer(l.Dial))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestDial(t *testing.T) {
+	l := newPipeListener()
+	s := jrpc.NewServerConnection(l, nil)
+	defer s.Close()
+	require.NoError(t, s.HandleCall("list_dbs", func() ([]string, error) {
+		return []string{}, nil
+	}))
+	require.NoError(t, s.HandleCall("set_db_change_aware", func(bool) (struct{}, error) {
+		return struct{}{}, nil
+	}))
+	require.NoError(t, s.HandleCall("echo", func(args ...string) ([]string, error) {
+		return args, nil
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := Dial(ctx, "pipe", "test", WithMaxAttempts(1), WithDialer(l.Dial))
+	require.NoError(t, err)
+	defer c.Close()
+	require.NoError(t, c.Echo(ctx))
+}
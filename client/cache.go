@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/db"
+	"github.com/kazmanavt/ovsdb/v2/monitor"
+	"log/slog"
+	"reflect"
+	"sync/atomic"
+)
+
+var cacheSeq atomic.Int64
+
+// Cache creates db.DB for the db dbName and keeps it in sync with the server by monitor_cond_since
+// monitor set with monReqs (all columns of all tables if monReqs is nil).
+// The initial content is applied before Cache returns, the updates are applied in order
+// as they come. After reconnect with the lost transaction history or the update failing
+// to apply, the cache is cleared and rebuilt from the fresh snapshot, for the new schema
+// if the db is converted. The cache stops following the server when the Client is closed.
+func (c *Client) Cache(ctx context.Context, dbName string, monReqs monitor.MonCondReqSet) (db.DB, error) {
+	sch, err := c.GetSchema(ctx, dbName)
+	if err != nil {
+		return nil, err
+	}
+	if monReqs == nil {
+		monReqs = monitor.NewMonCondReqSet(sch)
+		for tName := range sch.Tables {
+			monReqs.Add(tName, monitor.MonCondReq{})
+		}
+	}
+
+	monName := fmt.Sprintf("_cache.%s.%d", dbName, cacheSeq.Add(1))
+	initial, updates, err := c.SetMonitorCondSince(ctx, dbName, monName, monReqs,
+		WithMonitorDelivery(DeliveryQueue), WithResyncMarker())
+	if err != nil {
+		return nil, err
+	}
+	c.monMu.RLock()
+	item, ok := c.monitors[monName]
+	c.monMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("cache monitor %q not found", monName)
+	}
+
+	d := db.NewDB(sch)
+	if err := d.ApplyUpdate2(initial); err != nil {
+		_ = c.CancelMonitor(ctx, monName)
+		return nil, fmt.Errorf("apply initial content: %w", err)
+	}
+
+	go func() {
+		// broken is set if the update fails to apply, the updates are skipped then
+		// until the fresh snapshot of resync
+		broken := false
+		for {
+			select {
+			case upd := <-updates:
+				if upd.IsResync() {
+					c.resetCache(d, dbName)
+				} else if broken {
+					continue
+				}
+				broken = false
+				if err := d.ApplyUpdate2(upd); err != nil {
+					c.log.Warn("fail to apply update to cache, resyncing",
+						slog.String("db", dbName),
+						slog.String("monitor", monName),
+						slog.String("error", err.Error()))
+					broken = true
+					c.requestResync(item)
+				}
+			case <-item.done:
+				return
+			}
+		}
+	}()
+	return d, nil
+}
+
+// resetCache switches the cache d to the current schema of db if it is changed,
+// the content is cleared anyway by the resync snapshot.
+func (c *Client) resetCache(d db.DB, dbName string) {
+	c.schemasMu.RLock()
+	sch, ok := c.schemas[dbName]
+	c.schemasMu.RUnlock()
+	if !ok || reflect.DeepEqual(sch, d.Schema()) {
+		return
+	}
+	if err := db.Reset(d, sch); err != nil {
+		c.log.Warn("fail to reset cache for new schema", slog.String("db", dbName), slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/transact"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestClient_Cache(t *testing.T) {
+	s := &monServer{}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := Dial(ctx, "pipe", "test", WithDialer(s.dial))
+	require.NoError(t, err)
+	defer c.Close()
+
+	d, err := c.Cache(ctx, "Test", nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, d.TableLen("T"))
+	assert.EqualValues(t, 0, d.GetS("T", testRowUUID, "x"))
+
+	var monName string
+	c.monMu.RLock()
+	for name := range c.monitors {
+		monName = name
+	}
+	c.monMu.RUnlock()
+
+	// updates are applied in order, none is lost
+	for range 30 {
+		s.update(t, monName)
+	}
+	require.Eventually(t, func() bool {
+		return d.GetS("T", testRowUUID, "x") == 30
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the row is replaced while the client is disconnected, the server has no history
+	const newRow = "0a5e7c9e-2f3b-4c7a-9d1e-5b6f7a8c9d0e"
+	s.row.Store(newRow)
+	_ = c.conn().Close()
+	require.Eventually(t, func() bool {
+		return d.TableRowS("T", newRow) != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, d.TableLen("T"))
+	assert.Nil(t, d.TableRowS("T", testRowUUID))
+}
+
+func TestClient_CacheApplyError(t *testing.T) {
+	e := newE2E(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	u := e.insert(t, 1)
+	d, err := e.c.Cache(ctx, "Test", nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, d.TableLen("T"))
+
+	// the cache diverged, the modify of the lost row fails and the cache is resynced
+	d.Clear()
+	tr := transact.NewTransaction(e.sch)
+	tr.Update([]types.Condition{types.Equal("_uuid", u)}, e.sch.Tables["T"].NewRow("x", 2))
+	require.NoError(t, e.srv.Transact("Test", tr))
+	require.Eventually(t, func() bool {
+		return d.TableRow("T", u) != nil && d.Get("T", u, "x") == 2
+	}, 5*time.Second, time.Millisecond)
+
+	// the updates are applied after the resync
+	second := e.insert(t, 3)
+	require.Eventually(t, func() bool { return d.TableRow("T", second) != nil }, 5*time.Second, time.Millisecond)
+}
+
+func TestClient_CacheSchemaChange(t *testing.T) {
+	e := newE2E(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	u := e.insert(t, 1)
+	d, err := e.c.Cache(ctx, "Test", nil)
+	require.NoError(t, err)
+
+	var next schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &next))
+	next.Version = "1.1.0"
+	var y schema.ColumnSchema
+	require.NoError(t, json.Unmarshal([]byte(`{"type": "string"}`), &y))
+	next.Tables["T"].Columns["y"] = &y
+	require.NoError(t, e.c.Convert(ctx, "Test", &next))
+
+	require.Eventually(t, func() bool { return d.Schema().Version == "1.1.0" }, 5*time.Second, time.Millisecond)
+	require.Eventually(t, func() bool { return d.TableRow("T", u) != nil }, 5*time.Second, time.Millisecond)
+	assert.Equal(t, 1, d.Get("T", u, "x"))
+	assert.Equal(t, "", d.Get("T", u, "y"))
+}
@@ -7,7 +7,9 @@ import (
 	jrpc "github.com/kazmanavt/jsonrpc/v2/jrpc1"
 	"github.com/kazmanavt/ovsdb/v2/monitor"
 	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/types"
 	"log/slog"
+	"slices"
 	"sync"
 	"time"
 )
@@ -25,217 +27,450 @@ type monitorItem struct {
 	renewReqs   monitor.GenericMonReqSet
 	updChan2    chan<- monitor.TableSetUpdate2
 	updChan     chan<- monitor.TableSetUpdate
+
+	delivery DeliveryPolicy
+	queue2   *queue[monitor.TableSetUpdate2] // set for DeliveryQueue
+	queue    *queue[monitor.TableSetUpdate]  // set for DeliveryQueue
+	done     chan struct{}                   // closed when the monitor is forgotten
+	stopOnce sync.Once
+	// reestablishMu serializes re-establishing of the monitor, see reestablishMonitor
+	reestablishMu sync.Mutex
+	// resyncMarker marks the snapshots replacing the consumer state, see WithResyncMarker
+	resyncMarker bool
+	// the rest is guarded by deliverMu
+	deliverMu sync.Mutex
+	// resyncing is set while the monitor is being re-established by DeliveryResync policy
+	resyncing bool
+	// holding is set while the snapshot is requested, the updates are held until it is delivered
+	holding  bool
+	held     []any // snapshots and updates waiting for delivery in order, see flush
+	mark     int   // position of the requested snapshot in held
+	flushing bool  // flush is running
 }
 
 type Client struct {
-	log, jLog        *slog.Logger
-	network, address string
-	jConn            jrpc.Connection
-	//ctx              context.Context
-	//cancel           context.CancelFunc
+	log, jLog  *slog.Logger
+	remotes    []Remote
+	remoteIdx  int // remote to try first, guarded by lock
+	leaderOnly bool
+	tls        tlsOpts
+	dialer     Dialer
+	jConn      jrpc.Connection
+	oConn      *orderedConn // the stream of jConn, see orderUpdates
+	remote     Remote       // remote of jConn
+	// dropCause is the reason of jConn closing by the Client, nil if closed by the server
+	dropCause error
+	connected bool // the first connection was established
+	// ctx is canceled by Close to stop reconnection attempts
+	ctx    context.Context
+	cancel context.CancelFunc
 	lock   sync.RWMutex
 	closed bool
 
 	monitors map[string]*monitorItem
 	monMu    sync.RWMutex
 
-	schemas   map[string]*schema.DbSchema
-	schemasMu sync.RWMutex
+	locks   map[string]*Lock
+	locksMu sync.RWMutex
+
+	schemas        map[string]*schema.DbSchema
+	schemaHandlers []SchemaChangeHandler
+	schemasMu      sync.RWMutex
 
 	dbsNames   []string
 	dbsNamesMu sync.RWMutex
 
+	// srvRows is a content of _Server.Database table, nil if server does not provide _Server db
+	srvRows map[string]schema.Row
+	srvMu   sync.RWMutex
+
 	keepAlivePeriod  time.Duration
 	keepAliveTimeout time.Duration
+	delivery         DeliveryPolicy
+
+	events       chan Event
+	eventsBuffer int
+	eventsMu     sync.Mutex
+	eventsClosed bool
+
+	backoff          Backoff
+	maxAttempts      int
+	dialTimeout      time.Duration
+	handshakeTimeout time.Duration
+	restoreTimeout   time.Duration
 }
 
+// NewClient creates the Client connected to the server at addr over network ("tcp", "unix" or "ssl").
+// It blocks until the connection is established, retrying forever; use Dial
+// to limit the time or the number of attempts.
 func NewClient(network, addr string, opts ...ClientOpt) *Client {
+	c := newClient([]Remote{{Network: network, Address: addr}}, opts...)
+	_ = c.start(context.Background(), 0)
+	return c
+}
+
+// NewClusterClient creates the Client for the list of remotes in ovs-vsctl's --db format,
+// e.g. "tcp:10.0.0.1:6641,tcp:10.0.0.2:6641,tcp:10.0.0.3:6641".
+// The Client connects to the first available remote and switches to the next one
+// on disconnect. It blocks until the connection is established, retrying forever.
+func NewClusterClient(remotes string, opts ...ClientOpt) (*Client, error) {
+	rs, err := ParseRemotes(remotes)
+	if err != nil {
+		return nil, err
+	}
+	c := newClient(rs, opts...)
+	_ = c.start(context.Background(), 0)
+	return c, nil
+}
+
+// Dial creates the Client connected to the server at addr over network ("tcp", "unix" or "ssl").
+// It gives up when ctx ends or the number of attempts set by WithMaxAttempts is exhausted,
+// and returns *ConnectError in that case. The ctx limits the initial connection only,
+// once connected the Client reconnects on its own until Close is called.
+func Dial(ctx context.Context, network, addr string, opts ...ClientOpt) (*Client, error) {
+	c := newClient([]Remote{{Network: network, Address: addr}}, opts...)
+	if err := c.start(ctx, c.maxAttempts); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// DialCluster is like Dial, but for the list of remotes in ovs-vsctl's --db format,
+// see NewClusterClient.
+func DialCluster(ctx context.Context, remotes string, opts ...ClientOpt) (*Client, error) {
+	rs, err := ParseRemotes(remotes)
+	if err != nil {
+		return nil, err
+	}
+	c := newClient(rs, opts...)
+	if err := c.start(ctx, c.maxAttempts); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func newClient(remotes []Remote, opts ...ClientOpt) *Client {
 	c := Client{
-		network:          network,
-		address:          addr,
+		remotes:          remotes,
 		log:              slog.Default(),
 		jLog:             slog.Default(),
 		monitors:         make(map[string]*monitorItem),
+		locks:            make(map[string]*Lock),
 		schemas:          make(map[string]*schema.DbSchema),
 		keepAlivePeriod:  defaultKeepAlivePeriod,
 		keepAliveTimeout: defaultKeepAliveTimeout,
+		backoff:          defaultBackoff,
+		dialTimeout:      defaultDialTimeout,
+		handshakeTimeout: defaultHandshakeTimeout,
+		restoreTimeout:   defaultRestoreTimeout,
+		eventsBuffer:     defaultEventsBuffer,
 	}
 	for _, opt := range opts {
 		opt(&c)
 	}
+	c.events = make(chan Event, c.eventsBuffer)
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	return &c
+}
 
-	c.connect()
+// start establishes the first connection and runs the reconnection loop.
+func (c *Client) start(ctx context.Context, maxAttempts int) error {
+	if err := c.connect(ctx, maxAttempts); err != nil {
+		c.cancel()
+		return err
+	}
 	go c.loop()
-	return &c
+	return nil
 }
 
-func (c *Client) keepAlive() {
-	jConn := c.jConn
-	seq := 0
-	for {
+// conn returns the current connection to the server.
+func (c *Client) conn() jrpc.Connection {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.jConn
+}
+
+func (c *Client) keepAlive(jConn jrpc.Connection) {
+	for seq := 0; ; seq++ {
 		select {
 		case <-jConn.Done():
 			return
 		case <-time.After(c.keepAlivePeriod):
-			ctx, _ := context.WithTimeout(context.Background(), c.keepAliveTimeout)
-			msg := fmt.Sprintf("keep alive %d", seq)
-			resp, err := jConn.Call(ctx, "echo", msg)
-			if err != nil {
-				c.log.Warn("fail to send keep alive", slog.Any("local error", err))
-				jConn.Close()
-				return
-			}
-			if err := resp.Error(); err != nil {
-				c.log.Warn("fail to send keep alive", slog.Any("remote error", err.Error()))
-				jConn.Close()
-				return
-			}
-
-			var echoed []string
-			if err := json.Unmarshal(resp.GetResult(), &echoed); err != nil {
-				c.log.Warn("fail to send keep alive", slog.String("unmarshal error", err.Error()))
-				jConn.Close()
-				return
-			}
-			if len(echoed) != 1 {
-				c.log.Warn("fail to send keep alive", slog.String("unexpected response length", string(resp.GetResult())))
-				jConn.Close()
-				return
-			}
-			if msg != echoed[0] {
-				c.log.Warn("fail to send keep alive", slog.String("unexpected response", string(resp.GetResult())))
-				jConn.Close()
+			if err := c.echo(jConn, seq); err != nil {
+				c.log.Warn("fail to send keep alive", slog.Any("error", err))
+				c.emit(EventKeepAliveFailed, "", err)
+				c.dropConn(jConn, fmt.Errorf("keep alive: %w", err))
 				return
 			}
 		}
 	}
 }
+
+func (c *Client) echo(jConn jrpc.Connection, seq int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.keepAliveTimeout)
+	defer cancel()
+	msg := fmt.Sprintf("keep alive %d", seq)
+	resp, err := callConn(ctx, jConn, "echo", msg)
+	if err != nil {
+		return err
+	}
+
+	var echoed []string
+	if err := json.Unmarshal(resp.GetResult(), &echoed); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	if len(echoed) != 1 {
+		return fmt.Errorf("unexpected response length: %s", resp.GetResult())
+	}
+	if msg != echoed[0] {
+		return fmt.Errorf("unexpected response: %s", resp.GetResult())
+	}
+	return nil
+}
+
 func (c *Client) loop() {
 	for {
 		select {
-		case <-c.jConn.Done():
-			if c.closed {
+		case <-c.conn().Done():
+			c.lock.Lock()
+			closed := c.closed
+			cause := c.dropCause
+			c.dropCause = nil
+			c.lock.Unlock()
+			if closed {
+				return
+			}
+			if cause == nil {
+				cause = ErrConnectionClosed
+			}
+			c.emit(EventDisconnected, "", cause)
+			// prefer another server, current one may be down or not a leader anymore
+			c.nextRemote()
+			if err := c.connect(c.ctx, 0); err != nil {
+				// the Client is closed
 				return
 			}
-			c.connect()
 		}
 	}
 }
 
-func (c *Client) connect() {
-	c.log.Debug("creating new connection",
-		slog.String("net", c.network),
-		slog.String("addr", c.address))
-	ctx, cancel := context.WithCancel(context.TODO())
-	defer cancel()
-	for {
-		jConn, err := jrpc.NewClient(c.network, c.address, c.jLog)
-		if err != nil {
-			c.log.Warn("fail to connect to server", slog.Any("error", err))
-			time.Sleep(1 * time.Second)
-			continue
-		}
-		c.log.Debug("connected to server", slog.String("addr", c.network+"://"+c.address))
+// currentRemote returns the remote to try first.
+func (c *Client) currentRemote() Remote {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.remotes[c.remoteIdx]
+}
 
-		// setup handlers
-		if err := jConn.HandleCall("echo", c.echoHandler()); err != nil {
-			c.log.Warn("fail to setup call echo handler", slog.Any("error", err))
-			_ = jConn.Close()
-			continue
+// nextRemote switches to the next remote in turn.
+func (c *Client) nextRemote() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.remoteIdx = (c.remoteIdx + 1) % len(c.remotes)
+}
+
+// connect tries the remotes in turn until the connection is established, ctx ends
+// or maxAttempts (if positive) attempts fail.
+func (c *Client) connect(ctx context.Context, maxAttempts int) error {
+	delay := c.backoff.Initial
+	var lastErr error
+	for failed := 0; ; failed++ {
+		if maxAttempts > 0 && failed >= maxAttempts {
+			return &ConnectError{Remotes: c.remotes, Attempts: failed, Err: lastErr}
 		}
-		if err = jConn.HandleNotification("update3", c.updates3Dispatcher()); err != nil {
-			c.log.Warn("fail to setup update3 handler", slog.Any("error", err))
-			_ = jConn.Close()
-			continue
+		if failed > 0 && failed%len(c.remotes) == 0 {
+			// all remotes failed, wait before next round
+			d := c.backoff.jittered(delay)
+			c.log.Debug("waiting before reconnect", slog.Duration("delay", d))
+			select {
+			case <-ctx.Done():
+				return &ConnectError{Remotes: c.remotes, Attempts: failed, Err: lastErr, Cause: ctx.Err()}
+			case <-time.After(d):
+			}
+			delay = c.backoff.next(delay)
 		}
-		if err = jConn.HandleNotification("update2", c.updates2Dispatcher()); err != nil {
-			c.log.Warn("fail to setup update2 handler", slog.Any("error", err))
-			_ = jConn.Close()
-			continue
+		if err := ctx.Err(); err != nil {
+			return &ConnectError{Remotes: c.remotes, Attempts: failed, Err: lastErr, Cause: err}
 		}
-		if err = jConn.HandleNotification("update", c.updatesDispatcher()); err != nil {
-			c.log.Warn("fail to setup update handler", slog.Any("error", err))
-			_ = jConn.Close()
+		r := c.currentRemote()
+		if err := c.connectTo(ctx, r); err != nil {
+			c.log.Warn("fail to connect to server", slog.String("remote", r.String()), slog.Any("error", err))
+			lastErr = err
+			c.nextRemote()
 			continue
 		}
+		break
+	}
 
-		err = func() error {
-			c.monMu.RLock()
-			defer c.monMu.RUnlock()
-			c.jConn = jConn
+	go c.keepAlive(c.conn())
 
-			_ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
-			defer cancel()
+	c.log.Debug("connection established", slog.String("remote", c.currentRemote().String()))
+	if c.connected {
+		c.emit(EventReconnected, "", nil)
+	} else {
+		c.connected = true
+		c.emit(EventConnected, "", nil)
+	}
+	return nil
+}
 
-			dbs, err := c.ListDbs(_ctx)
-			if err != nil {
-				c.log.Warn("fail to list dbs", slog.Any("error", err))
-				_ = jConn.Close()
-				return err
-			}
-			c.log.Debug("dbs listed")
-
-			for _, db := range dbs {
-				sch, err := c.GetSchema(_ctx, db)
-				if err != nil {
-					c.log.Warn("fail to get db schema", slog.String("dbName", db), slog.Any("error", err))
-					_ = jConn.Close()
-					return err
-				}
-				c.schemas[db] = sch
-			}
+func (c *Client) connectTo(ctx context.Context, r Remote) error {
+	c.log.Debug("creating new connection",
+		slog.String("net", r.Network),
+		slog.String("addr", r.Address))
+	dialCtx, cancel := context.WithTimeout(ctx, c.dialTimeout)
+	conn, err := c.dial(dialCtx, r)
+	cancel()
+	if err != nil {
+		return err
+	}
+	// monitor updates are dispatched by the Client, see orderUpdates
+	oConn := c.orderUpdates(conn)
+	jConn := jrpc.NewConnection(oConn, c.jLog)
+	c.log.Debug("connected to server", slog.String("remote", r.String()))
+
+	// setup handlers
+	if err := jConn.HandleCall("echo", c.echoHandler()); err != nil {
+		_ = jConn.Close()
+		return fmt.Errorf("fail to setup call echo handler: %w", err)
+	}
+	if err = jConn.HandleNotification("locked", c.lockedHandler()); err != nil {
+		_ = jConn.Close()
+		return fmt.Errorf("fail to setup locked handler: %w", err)
+	}
+	if err = jConn.HandleNotification("stolen", c.stolenHandler()); err != nil {
+		_ = jConn.Close()
+		return fmt.Errorf("fail to setup stolen handler: %w", err)
+	}
+	if err = jConn.HandleNotification("monitor_canceled", c.monitorCanceledHandler()); err != nil {
+		_ = jConn.Close()
+		return fmt.Errorf("fail to setup monitor_canceled handler: %w", err)
+	}
 
-			if err := c.restoreMonitors(); err != nil {
-				c.log.Warn("fail to restore monitors", slog.Any("error", err))
-				_ = jConn.Close()
-				return err
-			}
-			return nil
-		}()
+	c.monMu.RLock()
+	defer c.monMu.RUnlock()
+	c.lock.Lock()
+	c.jConn = jConn
+	c.oConn = oConn
+	c.remote = r
+	c.dropCause = nil
+	c.lock.Unlock()
+
+	_ctx, cancel := context.WithTimeout(ctx, c.handshakeTimeout)
+	defer cancel()
+	// another server may serve another set of dbs, so skip the caches
+	dbs, err := c.listDbs(_ctx)
+	if err != nil {
+		_ = jConn.Close()
+		return fmt.Errorf("fail to list dbs: %w", err)
+	}
+	c.log.Debug("dbs listed")
+
+	if err := c.setDbChangeAware(_ctx); err != nil {
+		// old servers close the connection on db change instead
+		c.log.Debug("server is not db change aware", slog.Any("error", err))
+	}
+
+	for _, db := range dbs {
+		sch, err := c.getSchema(_ctx, db)
 		if err != nil {
-			continue
+			_ = jConn.Close()
+			return fmt.Errorf("fail to get db %q schema: %w", db, err)
 		}
-		break
+		c.schemasMu.Lock()
+		c.schemas[db] = sch
+		c.schemasMu.Unlock()
 	}
 
-	go c.keepAlive()
+	c.srvMu.Lock()
+	c.srvRows = nil
+	c.srvMu.Unlock()
+	if slices.Contains(dbs, serverDb) {
+		if err := c.watchServer(_ctx); err != nil {
+			_ = jConn.Close()
+			return fmt.Errorf("fail to monitor server status: %w", err)
+		}
+	}
+	if c.leaderOnly {
+		if err := c.checkLeader(); err != nil {
+			_ = jConn.Close()
+			return err
+		}
+	}
+
+	restoreCtx, cancelRestore := context.WithTimeout(ctx, c.restoreTimeout)
+	defer cancelRestore()
+	if err := c.restoreMonitors(restoreCtx); err != nil {
+		_ = jConn.Close()
+		return fmt.Errorf("fail to restore monitors: %w", err)
+	}
+	if len(c.monitors) > 0 {
+		c.emit(EventMonitorsRestored, "", nil)
+	}
 
-	c.log.Debug("connection established")
+	if err := c.restoreLocks(restoreCtx); err != nil {
+		_ = jConn.Close()
+		return fmt.Errorf("fail to restore locks: %w", err)
+	}
+	return nil
 }
 
-func (c *Client) restoreMonitors() error {
+// restoreMonitors sets the monitors on the new connection, the snapshots are delivered
+// in background (see release). The caller must hold c.monMu.
+func (c *Client) restoreMonitors(ctx context.Context) error {
 	c.log.Debug("restoring monitors")
-	// restore monitors
-	c.monMu.RLock()
 	for _, item := range c.monitors {
-		ctx, _ := context.WithTimeout(context.Background(), 5*time.Second)
-		switch {
-		case item.updChan2 != nil && item.renewReqs != nil:
-			res, err := c.callMonitorCondSince(ctx, item.db, item.monName, item.lastTxnId, item.initialReqs)
-			if err != nil {
-				return err
-			}
-			if !res.found {
-				item.updChan2 <- res.update2
-			}
-			item.lastTxnId = res.lastTxnID
-		case item.updChan2 != nil && item.renewReqs == nil:
+		if err := c.restoreMonitor(ctx, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreMonitor sets the monitor on the new connection. The conditional monitors are restored
+// by monitor_cond_since, so only the changes missed while disconnected are delivered if
+// the server knows the last transaction seen, or the full snapshot otherwise. The servers
+// not supporting monitor_cond_since get monitor_cond and send the full snapshot.
+func (c *Client) restoreMonitor(ctx context.Context, item *monitorItem) error {
+	c.hold(item)
+	var snap *snapshot
+	defer func() { c.release(item, snap) }()
+	switch {
+	case item.updChan2 != nil:
+		lastTxnId := item.lastTxnId
+		if lastTxnId == "" {
+			// set by monitor_cond, the updates have no transaction ids
+			lastTxnId = types.ZeroUUID
+		}
+		res, err := c.callMonitorCondSince(ctx, item.db, item.monName, lastTxnId, item.initialReqs)
+		if isUnknownMethod(err) {
+			// the server does not support monitor_cond_since, the update is the full snapshot
 			upd2, err := c.callMonitorCond(ctx, item.db, item.monName, item.initialReqs)
 			if err != nil {
 				return err
 			}
-			item.updChan2 <- upd2
-		case item.updChan != nil:
-			upd, err := c.callMonitor(ctx, item.db, item.monName, item.initialReqs)
-			if err != nil {
-				return err
-			}
-			item.updChan <- upd
+			snap = &snapshot{upd2: item.markResync2(upd2)}
+			item.lastTxnId = ""
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !res.found {
+			// the server can't send the changes since lastTxnId, the update is the full snapshot
+			snap = &snapshot{upd2: item.markResync2(res.update2)}
+		} else if len(res.update2) > 0 {
+			// the changes missed while disconnected
+			snap = &snapshot{upd2: res.update2}
 		}
+		item.lastTxnId = res.lastTxnID
+	case item.updChan != nil:
+		upd, err := c.callMonitor(ctx, item.db, item.monName, item.initialReqs)
+		if err != nil {
+			return err
+		}
+		snap = &snapshot{upd: item.markResync(upd)}
 	}
-	c.monMu.RUnlock()
 	return nil
-
 }
 
 func (c *Client) echoHandler() func(p json.RawMessage) (json.RawMessage, error) {
@@ -271,16 +506,17 @@ func (c *Client) updates3Dispatcher() func(string, string, monitor.RawTableSetUp
 			return
 		}
 
-		select {
-		case item.updChan2 <- upd:
-		default:
-		}
+		c.deliver2(item, upd)
 	}
 }
 
 func (c *Client) updates2Dispatcher() func(string, monitor.RawTableSetUpdate2) {
 	return func(monName string, _upd monitor.RawTableSetUpdate2) {
 		c.log.Debug("updates2 dispatcher")
+		if monName == serverMonName {
+			c.serverUpdatesDispatcher(_upd)
+			return
+		}
 		c.monMu.RLock()
 		item, ok := c.monitors[monName]
 		c.monMu.RUnlock()
@@ -303,10 +539,7 @@ func (c *Client) updates2Dispatcher() func(string, monitor.RawTableSetUpdate2) {
 			return
 		}
 
-		select {
-		case item.updChan2 <- upd:
-		default:
-		}
+		c.deliver2(item, upd)
 	}
 }
 
@@ -333,15 +566,23 @@ func (c *Client) updatesDispatcher() func(string, monitor.RawTableSetUpdate) {
 			return
 		}
 
-		select {
-		case item.updChan <- upd:
-		default:
-		}
+		c.deliver1(item, upd)
 	}
 }
 
 func (c *Client) Close() error {
+	c.lock.Lock()
 	c.closed = true
-	err := c.jConn.Close()
+	jConn := c.jConn
+	c.lock.Unlock()
+	c.cancel()
+	err := jConn.Close()
+	c.monMu.RLock()
+	for _, item := range c.monitors {
+		item.stop()
+	}
+	c.monMu.RUnlock()
+	c.emit(EventDisconnected, "", ErrClientClosed)
+	c.closeEvents()
 	return err
 }
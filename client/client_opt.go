@@ -30,3 +30,127 @@ func WithKeepAliveTimeout(timeout time.Duration) ClientOpt {
 		c.keepAliveTimeout = timeout
 	}
 }
+
+// WithLeaderOnly makes the Client stay connected only to the leader of clustered databases.
+// On connection to a follower, or when the server reports the leadership loss,
+// the Client disconnects and tries the next remote.
+func WithLeaderOnly(leaderOnly bool) ClientOpt {
+	return func(c *Client) {
+		c.leaderOnly = leaderOnly
+	}
+}
+
+// WithTLSCertificate sets the PEM encoded certificate and private key files
+// used to authenticate the Client on ssl: remotes (like --certificate and --private-key of OVS tools).
+func WithTLSCertificate(certFile, keyFile string) ClientOpt {
+	return func(c *Client) {
+		c.tls.certFile = certFile
+		c.tls.keyFile = keyFile
+	}
+}
+
+// WithTLSCACert sets the PEM encoded CA certificate file used to verify
+// the server on ssl: remotes (like --ca-cert of OVS tools).
+func WithTLSCACert(caFile string) ClientOpt {
+	return func(c *Client) {
+		c.tls.caFile = caFile
+		c.tls.bootstrapCA = false
+	}
+}
+
+// WithTLSBootstrapCA is like WithTLSCACert, but if caFile does not exist, the CA certificate
+// sent by the server is saved to caFile on the first connection (trust on first use),
+// like --bootstrap-ca-cert of OVS tools. The first connection is dropped after that and
+// the Client reconnects verifying the server with the saved certificate.
+func WithTLSBootstrapCA(caFile string) ClientOpt {
+	return func(c *Client) {
+		c.tls.caFile = caFile
+		c.tls.bootstrapCA = true
+	}
+}
+
+// WithTLSPeerName makes the Client to verify that the server certificate is issued for name.
+// By default, like in OVS, only the certificate chain is verified.
+func WithTLSPeerName(name string) ClientOpt {
+	return func(c *Client) {
+		c.tls.peerName = name
+	}
+}
+
+// WithDialer sets the function used to establish the transport connection on
+// every (re)connect, instead of dialing the remote address. It allows to connect
+// over any net.Conn: SSH tunnel, socket opened in another network namespace,
+// in-process net.Pipe, etc. The network and address given to NewClient are
+// used only in logs.
+func WithDialer(dialer Dialer) ClientOpt {
+	return func(c *Client) {
+		c.dialer = dialer
+	}
+}
+
+// WithBackoff sets the delays between connection attempts, see Backoff.
+// Zero fields of b keep the defaults (1s initial, 8s max, factor 2, ±20% jitter),
+// except Jitter, which is used as is.
+func WithBackoff(b Backoff) ClientOpt {
+	return func(c *Client) {
+		if b.Initial > 0 {
+			c.backoff.Initial = b.Initial
+		}
+		if b.Max > 0 {
+			c.backoff.Max = b.Max
+		}
+		if b.Factor > 0 {
+			c.backoff.Factor = b.Factor
+		}
+		c.backoff.Jitter = b.Jitter
+	}
+}
+
+// WithMaxAttempts limits the number of connection attempts made by Dial and DialCluster,
+// every remote tried counts as an attempt. Zero means no limit (default).
+// Reconnection after the connection is lost is never limited.
+func WithMaxAttempts(n int) ClientOpt {
+	return func(c *Client) {
+		c.maxAttempts = n
+	}
+}
+
+// WithDialTimeout sets the timeout of the transport connection establishment,
+// including TLS handshake on ssl: remotes. Default is 15s.
+func WithDialTimeout(timeout time.Duration) ClientOpt {
+	return func(c *Client) {
+		c.dialTimeout = timeout
+	}
+}
+
+// WithHandshakeTimeout sets the timeout of the session setup after the transport is
+// connected: listing dbs, fetching their schemas and checking the server status. Default is 15s.
+func WithHandshakeTimeout(timeout time.Duration) ClientOpt {
+	return func(c *Client) {
+		c.handshakeTimeout = timeout
+	}
+}
+
+// WithRestoreTimeout sets the timeout of the monitors and locks restoring on reconnect,
+// and of the monitor re-establishing after the db schema change. Default is 15s.
+func WithRestoreTimeout(timeout time.Duration) ClientOpt {
+	return func(c *Client) {
+		c.restoreTimeout = timeout
+	}
+}
+
+// WithEventsBuffer sets the capacity of the Events channel, default is 64.
+// The oldest events are dropped when the channel is full.
+func WithEventsBuffer(n int) ClientOpt {
+	return func(c *Client) {
+		c.eventsBuffer = n
+	}
+}
+
+// WithDeliveryPolicy sets the default delivery policy of the monitor updates
+// for the slow consumers, DeliveryDrop by default. See also WithMonitorDelivery.
+func WithDeliveryPolicy(p DeliveryPolicy) ClientOpt {
+	return func(c *Client) {
+		c.delivery = p
+	}
+}
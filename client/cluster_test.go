@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/monitor"
+	"github.com/kazmanavt/ovsdb/v2/ovsdbtest"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/transact"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serverSchema is the schema of _Server db, the fixture is shared with the schema package.
+var serverSchema = func() []byte {
+	b, err := os.ReadFile(filepath.Join("..", "schema", "testdata", "_Server.json"))
+	if err != nil {
+		panic(err)
+	}
+	return b
+}()
+
+// cluster is the set of in-memory servers of the Test db listening on unix sockets,
+// each reporting its own leadership of Test in _Server db.
+type cluster struct {
+	srvs    []*ovsdbtest.Server
+	paths   []string
+	sch     *schema.DbSchema
+	srvSch  *schema.DbSchema
+	remotes string
+}
+
+func newCluster(t *testing.T, leaders ...bool) *cluster {
+	cl := &cluster{sch: &schema.DbSchema{}, srvSch: &schema.DbSchema{}}
+	require.NoError(t, json.Unmarshal([]byte(testSchema), cl.sch))
+	require.NoError(t, json.Unmarshal(serverSchema, cl.srvSch))
+	dir := t.TempDir()
+	var remotes []string
+	for i, leader := range leaders {
+		srv := ovsdbtest.NewServer(cl.sch, ovsdbtest.WithDatabase(cl.srvSch))
+		t.Cleanup(func() { _ = srv.Close() })
+		tr := transact.NewTransaction(cl.srvSch)
+		tr.Insert(cl.srvSch.Tables["Database"].NewRow("name", "Test", "model", "clustered",
+			"connected", true, "leader", leader))
+		require.NoError(t, srv.Transact(serverDb, tr))
+
+		path := filepath.Join(dir, fmt.Sprintf("db%d.sock", i))
+		ln, err := net.Listen("unix", path)
+		require.NoError(t, err)
+		go func() { _ = srv.Serve(ln) }()
+		cl.srvs = append(cl.srvs, srv)
+		cl.paths = append(cl.paths, path)
+		remotes = append(remotes, "unix:"+path)
+	}
+	cl.remotes = strings.Join(remotes, ",")
+	return cl
+}
+
+func (cl *cluster) dial(t *testing.T, opts ...ClientOpt) *Client {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	opts = append(opts, WithBackoff(Backoff{Initial: 10 * time.Millisecond, Max: 50 * time.Millisecond}))
+	c, err := DialCluster(ctx, cl.remotes, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func (cl *cluster) setLeader(t *testing.T, i int, leader bool) {
+	tr := transact.NewTransaction(cl.srvSch)
+	tr.Update([]types.Condition{types.Equal("name", "Test")}, cl.srvSch.Tables["Database"].NewRow("leader", leader))
+	require.NoError(t, cl.srvs[i].Transact(serverDb, tr))
+}
+
+func TestClient_Failover(t *testing.T) {
+	cl := newCluster(t, true, true)
+	c := cl.dial(t)
+	ev := nextEventOf(t, c, EventConnected)
+	assert.Equal(t, cl.paths[0], ev.Remote.Address)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	reqs := monitor.NewMonCondReqSet(cl.sch).Add("T", monitor.MonCondReq{})
+	_, updates, err := c.SetMonitorCond(ctx, "Test", "mon", reqs, WithResyncMarker())
+	require.NoError(t, err)
+
+	// the server is down, the Client moves to the next one
+	_ = cl.srvs[0].Close()
+	ev = nextEventOf(t, c, EventReconnected)
+	assert.Equal(t, cl.paths[1], ev.Remote.Address)
+
+	// the history of another server is unknown, the monitor is restored from scratch
+	select {
+	case upd := <-updates:
+		assert.True(t, upd.IsResync())
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "snapshot is not received")
+	}
+	tr := transact.NewTransaction(cl.sch)
+	tr.Insert(cl.sch.Tables["T"].NewRow("x", 1))
+	require.NoError(t, c.Transact(ctx, "Test", tr))
+	u, err := tr.Results().Inserted(0)
+	require.NoError(t, err)
+	select {
+	case upd := <-updates:
+		assert.NotNil(t, upd["T"][string(u)].Insert)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "update is not received")
+	}
+}
+
+func TestClient_LeaderOnly(t *testing.T) {
+	cl := newCluster(t, false, true)
+	c := cl.dial(t, WithLeaderOnly(true))
+	// the follower is skipped
+	ev := nextEventOf(t, c, EventConnected)
+	assert.Equal(t, cl.paths[1], ev.Remote.Address)
+	st, err := c.ServerStatus("Test")
+	require.NoError(t, err)
+	assert.True(t, st.Leader)
+
+	// the leadership moves to another server
+	cl.setLeader(t, 0, true)
+	cl.setLeader(t, 1, false)
+	ev = nextEventOf(t, c, EventDisconnected)
+	assert.ErrorIs(t, ev.Err, ErrNotLeader)
+	ev = nextEventOf(t, c, EventReconnected)
+	assert.Equal(t, cl.paths[0], ev.Remote.Address)
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+)
+
+// ConvertError is returned by Convert when the server rejects the conversion.
+type ConvertError struct {
+	DB      string // name of the db to convert
+	Err     string // error reported by the server, e.g. "constraint violation"
+	Details string // details of the error, if provided by the server
+}
+
+func (e *ConvertError) Error() string {
+	msg := fmt.Sprintf("convert db %q: %s", e.DB, e.Err)
+	if e.Details != "" {
+		msg += fmt.Sprintf(" (%s)", e.Details)
+	}
+	return msg
+}
+
+// Convert converts db on the server to the newSchema online (convert method).
+// The schema is validated before it is sent. On success the schema cache of the Client
+// is refreshed and the monitors of db are re-established (see OnSchemaChange).
+// If the server rejects the conversion *ConvertError is returned.
+func (c *Client) Convert(ctx context.Context, db string, newSchema *schema.DbSchema) error {
+	if newSchema == nil {
+		return fmt.Errorf("convert db %q: nil schema", db)
+	}
+	if newSchema.Name != db {
+		return fmt.Errorf("convert db %q: schema is for db %q", db, newSchema.Name)
+	}
+	if err := newSchema.Validate(); err != nil {
+		return fmt.Errorf("convert db %q: invalid schema: %w", db, err)
+	}
+
+	if _, err := c.call(ctx, "convert", db, newSchema); err != nil {
+		var rErr *RPCError
+		if errors.As(err, &rErr) {
+			return &ConvertError{DB: db, Err: rErr.Err, Details: rErr.Details}
+		}
+		return err
+	}
+
+	if _, err := c.refreshSchema(ctx, db); err != nil {
+		return fmt.Errorf("convert db %q: refresh schema: %w", db, err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestClient_Convert(t *testing.T) {
+	e := newE2E(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	e.insert(t, 1)
+	changed := make(chan *schema.DbSchema, 1)
+	e.c.OnSchemaChange(func(db string, sch *schema.DbSchema) {
+		if db == "Test" {
+			changed <- sch
+		}
+	})
+
+	// the rows violating the new type of the column
+	var bad schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &bad))
+	bad.Tables["T"].Columns["x"].Type.Key.Type = "string"
+	err := e.c.Convert(ctx, "Test", &bad)
+	var cErr *ConvertError
+	require.ErrorAs(t, err, &cErr)
+	assert.Equal(t, "Test", cErr.DB)
+	assert.Equal(t, "constraint violation", cErr.Err)
+
+	// invalid schema is not sent
+	bad.Name = "Other"
+	assert.ErrorContains(t, e.c.Convert(ctx, "Test", &bad), "schema is for db")
+	var invalid schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &invalid))
+	two := 2
+	invalid.Tables["T"].Columns["x"].Type.Min = &two
+	err = e.c.Convert(ctx, "Test", &invalid)
+	assert.ErrorContains(t, err, "invalid schema")
+	assert.False(t, errors.As(err, &cErr), "invalid schema should be rejected locally")
+
+	var next schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &next))
+	next.Version = "1.1.0"
+	next.Tables["T"].Columns["y"] = &schema.ColumnSchema{Name: "y", Type: next.Tables["T"].Columns["x"].Type, Mutable: true}
+	require.NoError(t, e.c.Convert(ctx, "Test", &next))
+	select {
+	case sch := <-changed:
+		assert.Equal(t, "1.1.0", sch.Version)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "schema change is not reported")
+	}
+	sch, err := e.c.GetSchema(ctx, "Test")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", sch.Version)
+	assert.Contains(t, sch.Tables["T"].Columns, "y")
+}
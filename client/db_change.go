@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/monitor"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"log/slog"
+	"reflect"
+)
+
+// SchemaChangeHandler is called when the schema of db is changed on the server,
+// e.g. by "ovsdb-client convert". The handler is called before the affected
+// monitors are re-established, so the application can rebuild its db.DB
+// for the new schema before the new initial update arrives.
+type SchemaChangeHandler func(db string, sch *schema.DbSchema)
+
+// OnSchemaChange registers the handler of db schema changes.
+func (c *Client) OnSchemaChange(h SchemaChangeHandler) {
+	c.schemasMu.Lock()
+	defer c.schemasMu.Unlock()
+	c.schemaHandlers = append(c.schemaHandlers, h)
+}
+
+// setDbChangeAware asks the server to cancel the monitors of the db being
+// converted or removed instead of closing the connection (set_db_change_aware method).
+func (c *Client) setDbChangeAware(ctx context.Context) error {
+	resp, err := c.call(ctx, "set_db_change_aware", true)
+	if err != nil {
+		return err
+	}
+	var res json.RawMessage
+	if err := json.Unmarshal(resp.GetResult(), &res); err != nil {
+		return fmt.Errorf("set_db_change_aware: unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// refreshSchema fetches the schema of db bypassing the cache.
+// It notifies schema change handlers if the schema differs from the cached one.
+func (c *Client) refreshSchema(ctx context.Context, db string) (*schema.DbSchema, error) {
+	sch, err := c.getSchema(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	c.schemasMu.Lock()
+	prev, ok := c.schemas[db]
+	if ok && reflect.DeepEqual(prev, sch) {
+		c.schemasMu.Unlock()
+		return prev, nil
+	}
+	c.schemas[db] = sch
+	handlers := c.schemaHandlers
+	c.schemasMu.Unlock()
+
+	c.log.Info("db schema changed", slog.String("db", db), slog.String("version", sch.Version))
+	c.emit(EventSchemaRefreshed, db, nil)
+	for _, h := range handlers {
+		h(db, sch)
+	}
+	return sch, nil
+}
+
+func (c *Client) monitorCanceledHandler() func(string) {
+	return func(monName string) {
+		c.log.Debug("monitor canceled handler", slog.String("monitor", monName))
+		ctx, cancel := context.WithTimeout(c.ctx, c.restoreTimeout)
+		defer cancel()
+
+		if monName == serverMonName {
+			if err := c.watchServer(ctx); err != nil {
+				c.log.Warn("fail to re-establish server status monitor", slog.String("error", err.Error()))
+			}
+			return
+		}
+
+		c.monMu.RLock()
+		item, ok := c.monitors[monName]
+		c.monMu.RUnlock()
+		if !ok {
+			c.log.Warn("monitor canceled handler", slog.String("monitor not found", monName))
+			return
+		}
+
+		sch, err := c.refreshSchema(ctx, item.db)
+		if err != nil {
+			// the db is removed or not available anymore
+			c.failMonitor(item, fmt.Errorf("refresh db schema: %w", err))
+			return
+		}
+		initialReqs, err := monitor.Rebind(item.initialReqs, sch)
+		if err != nil {
+			c.failMonitor(item, fmt.Errorf("requests don't fit new schema: %w", err))
+			return
+		}
+
+		item.reestablishMu.Lock()
+		defer item.reestablishMu.Unlock()
+		c.monMu.Lock()
+		if c.monitors[monName] != item {
+			// canceled meanwhile
+			c.monMu.Unlock()
+			return
+		}
+		item.initialReqs = initialReqs
+		if item.renewReqs != nil {
+			item.renewReqs = initialReqs.WithoutInitial()
+		}
+		c.monMu.Unlock()
+		// the txn history of converted db is not usable, re-establish the monitor from scratch
+		err = c.reestablishMonitor(ctx, item)
+		var rErr *RPCError
+		switch {
+		case errors.As(err, &rErr):
+			c.failMonitor(item, err)
+		case errors.Is(err, errMonitorSuperseded):
+		case err != nil:
+			// the connection is dropped, the monitor is restored on reconnect
+			c.log.Warn("fail to re-establish monitor", slog.String("monitor", monName), slog.String("error", err.Error()))
+		default:
+			c.emit(EventMonitorsRestored, item.db, nil)
+		}
+	}
+}
+
+// failMonitor forgets the monitor canceled by the server, which can't be re-established.
+func (c *Client) failMonitor(item *monitorItem, err error) {
+	c.log.Warn("monitor canceled, fail to re-establish",
+		slog.String("monitor", item.monName),
+		slog.String("db", item.db),
+		slog.String("error", err.Error()))
+	c.monMu.Lock()
+	if c.monitors[item.monName] == item {
+		delete(c.monitors, item.monName)
+		item.stop()
+	}
+	c.monMu.Unlock()
+	c.emitEvent(Event{Type: EventMonitorFailed, DB: item.db, Monitor: item.monName, Err: err})
+}
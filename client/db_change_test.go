@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kazmanavt/ovsdb/v2/monitor"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// nextEventOf skips the events until the one of type typ.
+func nextEventOf(t *testing.T, c *Client, typ EventType) Event {
+	t.Helper()
+	for {
+		if ev := nextEvent(t, c); ev.Type == typ {
+			return ev
+		}
+	}
+}
+
+// convertTest converts the Test db adding the column y to the table T.
+func convertTest(t *testing.T, e *e2e, mangle func(sch *schema.DbSchema)) *schema.DbSchema {
+	var next schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &next))
+	next.Version = "1.1.0"
+	var y schema.ColumnSchema
+	require.NoError(t, json.Unmarshal([]byte(`{"type": "string"}`), &y))
+	next.Tables["T"].Columns["y"] = &y
+	if mangle != nil {
+		mangle(&next)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, e.c.Convert(ctx, "Test", &next))
+	return &next
+}
+
+func TestClient_MonitorCanceled(t *testing.T) {
+	e := newE2E(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	u := e.insert(t, 1)
+
+	changed := make(chan *schema.DbSchema, 1)
+	e.c.OnSchemaChange(func(db string, sch *schema.DbSchema) {
+		changed <- sch
+	})
+	reqs := monitor.NewMonCondReqSet(e.sch).Add("T", monitor.MonCondReq{})
+	_, updates, err := e.c.SetMonitorCond(ctx, "Test", "mon", reqs, WithResyncMarker())
+	require.NoError(t, err)
+
+	convertTest(t, e, nil)
+	select {
+	case sch := <-changed:
+		assert.Equal(t, "1.1.0", sch.Version)
+		assert.Contains(t, sch.Tables["T"].Columns, "y")
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "schema change is not reported")
+	}
+	ev := nextEventOf(t, e.c, EventMonitorsRestored)
+	assert.Equal(t, "Test", ev.DB)
+
+	// the fresh snapshot has the rows of the new schema
+	select {
+	case upd := <-updates:
+		require.True(t, upd.IsResync())
+		row := upd["T"][string(u)].Initial
+		require.NotNil(t, row)
+		assert.Contains(t, row.TableSchema().Columns, "y")
+		assert.Equal(t, "", row.Get("y"))
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "snapshot is not received")
+	}
+	second := e.insert(t, 2)
+	select {
+	case upd := <-updates:
+		assert.NotNil(t, upd["T"][string(second)].Insert)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "update is not received")
+	}
+}
+
+func TestClient_MonitorCanceledFailed(t *testing.T) {
+	e := newE2E(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	reqs := monitor.NewMonCondReqSet(e.sch).Add("T", monitor.MonCondReq{Columns: []string{"x"}})
+	_, _, err := e.c.SetMonitorCond(ctx, "Test", "mon", reqs)
+	require.NoError(t, err)
+
+	// the monitored column is removed
+	convertTest(t, e, func(sch *schema.DbSchema) { delete(sch.Tables["T"].Columns, "x") })
+	ev := nextEventOf(t, e.c, EventMonitorFailed)
+	assert.Equal(t, "Test", ev.DB)
+	assert.Equal(t, "mon", ev.Monitor)
+	assert.ErrorContains(t, ev.Err, "x")
+	e.c.monMu.RLock()
+	assert.NotContains(t, e.c.monitors, "mon")
+	e.c.monMu.RUnlock()
+}
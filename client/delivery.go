@@ -0,0 +1,442 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	jrpc "github.com/kazmanavt/jsonrpc/v2/jrpc1"
+	"github.com/kazmanavt/ovsdb/v2/monitor"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"log/slog"
+	"slices"
+	"sync"
+)
+
+// DeliveryPolicy defines what the Client does with a monitor update when
+// the consumer is slow and the updates channel is full.
+//
+// Regardless of the policy, the snapshots delivered after reconnect (unless
+// monitor_cond_since resumes from the last transaction) and after the db schema
+// change are never dropped, the updates following them wait until they are delivered.
+type DeliveryPolicy int
+
+const (
+	// DeliveryDrop drops the update (default). The consumer state diverges from the server.
+	DeliveryDrop DeliveryPolicy = iota
+	// DeliveryBlock waits until the consumer reads the channel.
+	DeliveryBlock
+	// DeliveryQueue puts the update to the unbounded queue of the monitor, the channel
+	// is fed from the queue in order.
+	DeliveryQueue
+	// DeliveryResync drops the update, cancels the monitor and re-establishes it.
+	// The fresh initial snapshot is delivered then, it is marked as by WithResyncMarker,
+	// so the consumer can rebuild its state.
+	DeliveryResync
+)
+
+func (p DeliveryPolicy) String() string {
+	switch p {
+	case DeliveryDrop:
+		return "drop"
+	case DeliveryBlock:
+		return "block"
+	case DeliveryQueue:
+		return "queue"
+	case DeliveryResync:
+		return "resync"
+	}
+	return fmt.Sprintf("DeliveryPolicy(%d)", int(p))
+}
+
+// MonitorOpt is an option of the monitor set by SetMonitor, SetMonitorCond or SetMonitorCondSince.
+type MonitorOpt func(m *monitorItem)
+
+// WithMonitorDelivery sets the delivery policy of the monitor updates,
+// overriding the one set by WithDeliveryPolicy for the Client.
+func WithMonitorDelivery(p DeliveryPolicy) MonitorOpt {
+	return func(m *monitorItem) {
+		m.delivery = p
+	}
+}
+
+// WithResyncMarker marks the snapshots replacing the state of the consumer (after reconnect,
+// resync or the db schema change) by the pseudo table monitor.ResyncTable, see
+// TableSetUpdate2.IsResync. Without it the snapshots are delivered as they are,
+// so ranging over the tables of the update gives the real tables only.
+func WithResyncMarker() MonitorOpt {
+	return func(m *monitorItem) {
+		m.resyncMarker = true
+	}
+}
+
+// newMonitorItem creates the monitor delivering updates to exactly one of updChan2 and updChan.
+func (c *Client) newMonitorItem(db, monName string, updChan2 chan<- monitor.TableSetUpdate2, updChan chan<- monitor.TableSetUpdate, opts []MonitorOpt) *monitorItem {
+	item := &monitorItem{
+		db:       db,
+		monName:  monName,
+		updChan2: updChan2,
+		updChan:  updChan,
+		delivery: c.delivery,
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(item)
+	}
+	if item.delivery == DeliveryResync {
+		item.resyncMarker = true
+	}
+	if item.delivery == DeliveryQueue {
+		if updChan2 != nil {
+			item.queue2 = newQueue(updChan2, item.done)
+		}
+		if updChan != nil {
+			item.queue = newQueue(updChan, item.done)
+		}
+	}
+	return item
+}
+
+// stop releases the delivery of the forgotten monitor.
+func (m *monitorItem) stop() {
+	m.stopOnce.Do(func() { close(m.done) })
+}
+
+// queue is an unbounded FIFO feeding the out channel.
+type queue[T any] struct {
+	mu    sync.Mutex
+	items []T
+	wake  chan struct{}
+}
+
+func newQueue[T any](out chan<- T, done <-chan struct{}) *queue[T] {
+	q := &queue[T]{wake: make(chan struct{}, 1)}
+	go q.run(out, done)
+	return q
+}
+
+func (q *queue[T]) push(v T) {
+	q.mu.Lock()
+	q.items = append(q.items, v)
+	q.mu.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *queue[T]) run(out chan<- T, done <-chan struct{}) {
+	var zero T
+	for {
+		q.mu.Lock()
+		if len(q.items) == 0 {
+			q.mu.Unlock()
+			select {
+			case <-q.wake:
+				continue
+			case <-done:
+				return
+			}
+		}
+		v := q.items[0]
+		q.items[0] = zero
+		q.items = q.items[1:]
+		q.mu.Unlock()
+
+		select {
+		case out <- v:
+		case <-done:
+			return
+		}
+	}
+}
+
+// snapshot is the initial content of the monitored tables held for delivery.
+type snapshot struct {
+	upd2 monitor.TableSetUpdate2
+	upd  monitor.TableSetUpdate
+}
+
+// send passes the update to the consumer according to the delivery policy.
+// It returns false if the update is dropped.
+func send[T any](item *monitorItem, ch chan<- T, q *queue[T], upd T) bool {
+	switch item.delivery {
+	case DeliveryBlock:
+		select {
+		case ch <- upd:
+		case <-item.done:
+		}
+		return true
+	case DeliveryQueue:
+		q.push(upd)
+		return true
+	}
+	select {
+	case ch <- upd:
+		return true
+	default:
+		return false
+	}
+}
+
+// sendSnapshot passes the initial content of the monitored tables to the consumer,
+// it is never dropped.
+func sendSnapshot[T any](item *monitorItem, ch chan<- T, q *queue[T], upd T) {
+	if q != nil {
+		q.push(upd)
+		return
+	}
+	select {
+	case ch <- upd:
+	case <-item.done:
+	}
+}
+
+// deliver passes the update to the consumer, unless it is held until the snapshot
+// is delivered or superseded by the coming snapshot of DeliveryResync.
+// It returns false if the update is dropped.
+func deliver[T any](item *monitorItem, ch chan<- T, q *queue[T], upd T) bool {
+	item.deliverMu.Lock()
+	switch {
+	case item.holding || item.flushing:
+		item.held = append(item.held, upd)
+		item.deliverMu.Unlock()
+		return true
+	case item.resyncing:
+		item.deliverMu.Unlock()
+		return true
+	}
+	item.deliverMu.Unlock()
+	return send(item, ch, q, upd)
+}
+
+func (c *Client) deliver2(item *monitorItem, upd monitor.TableSetUpdate2) {
+	if !deliver(item, item.updChan2, item.queue2, upd) {
+		c.overflow(item)
+	}
+}
+
+func (c *Client) deliver1(item *monitorItem, upd monitor.TableSetUpdate) {
+	if !deliver(item, item.updChan, item.queue, upd) {
+		c.overflow(item)
+	}
+}
+
+// hold makes the updates of the monitor wait for the snapshot being requested,
+// release puts the snapshot before them. The requests of the snapshots are serialized by c.monMu.
+func (c *Client) hold(item *monitorItem) {
+	item.deliverMu.Lock()
+	defer item.deliverMu.Unlock()
+	item.holding = true
+	item.resyncing = false
+	item.mark = len(item.held)
+}
+
+// release delivers the snapshot (if not nil) and the updates held since hold in background,
+// so the caller may keep the locks.
+func (c *Client) release(item *monitorItem, snap *snapshot) {
+	item.deliverMu.Lock()
+	defer item.deliverMu.Unlock()
+	if !item.holding {
+		return
+	}
+	item.holding = false
+	if snap != nil {
+		item.held = slices.Insert(item.held, item.mark, any(*snap))
+	}
+	if len(item.held) > 0 && !item.flushing {
+		item.flushing = true
+		go c.flush(item)
+	}
+}
+
+// flush delivers the held snapshots and updates in order until none is left
+// or the next snapshot is still requested.
+func (c *Client) flush(item *monitorItem) {
+	for {
+		item.deliverMu.Lock()
+		if len(item.held) == 0 || item.holding && item.mark == 0 {
+			item.flushing = false
+			item.deliverMu.Unlock()
+			return
+		}
+		v := item.held[0]
+		item.held[0] = nil
+		item.held = item.held[1:]
+		if item.holding {
+			item.mark--
+		}
+		item.deliverMu.Unlock()
+
+		ok := true
+		switch v := v.(type) {
+		case snapshot:
+			if item.updChan2 != nil {
+				sendSnapshot(item, item.updChan2, item.queue2, v.upd2)
+			} else {
+				sendSnapshot(item, item.updChan, item.queue, v.upd)
+			}
+		case monitor.TableSetUpdate2:
+			ok = send(item, item.updChan2, item.queue2, v)
+		case monitor.TableSetUpdate:
+			ok = send(item, item.updChan, item.queue, v)
+		}
+		if !ok {
+			c.overflow(item)
+		}
+	}
+}
+
+func (c *Client) overflow(item *monitorItem) {
+	if item.delivery != DeliveryResync {
+		c.log.Warn("monitor update dropped, consumer is too slow", slog.String("monitor", item.monName))
+		return
+	}
+	if c.requestResync(item) {
+		c.log.Warn("monitor update dropped, consumer is too slow, resyncing", slog.String("monitor", item.monName))
+	}
+}
+
+// requestResync starts the resync of the monitor unless it is already running,
+// the updates are dropped until the monitor is re-established. It returns false
+// if the resync is already running.
+func (c *Client) requestResync(item *monitorItem) bool {
+	item.deliverMu.Lock()
+	defer item.deliverMu.Unlock()
+	if item.resyncing {
+		return false
+	}
+	item.resyncing = true
+	go c.resync(item)
+	return true
+}
+
+// resync cancels the monitor and re-establishes it delivering the fresh snapshot.
+// The updates sent by the server before the monitor is canceled are dropped, the ones
+// of the new monitor wait until the snapshot is delivered.
+func (c *Client) resync(item *monitorItem) {
+	ctx, cancel := context.WithTimeout(c.ctx, c.restoreTimeout)
+	defer cancel()
+
+	item.reestablishMu.Lock()
+	defer item.reestablishMu.Unlock()
+	c.monMu.RLock()
+	live := c.monitors[item.monName] == item
+	c.monMu.RUnlock()
+	if !live {
+		// canceled meanwhile
+		return
+	}
+	jConn := c.conn()
+	_, err := callConn(ctx, jConn, "monitor_cancel", item.monName)
+	var rErr *RPCError
+	if err != nil && !errors.As(err, &rErr) {
+		// it is unknown whether the monitor is still set on the server
+		c.log.Warn("fail to cancel monitor for resync", slog.String("monitor", item.monName), slog.Any("error", err))
+		c.monitorLost(jConn, item, err)
+		return
+	}
+	// the server not knowing the monitor has it canceled already
+	err = c.reestablishMonitor(ctx, item)
+	switch {
+	case errors.As(err, &rErr):
+		c.failMonitor(item, err)
+	case err != nil && !errors.Is(err, errMonitorSuperseded):
+		c.log.Warn("fail to re-establish monitor for resync", slog.String("monitor", item.monName), slog.Any("error", err))
+	}
+}
+
+// errMonitorSuperseded is returned by reestablishMonitor if the monitor is canceled
+// or the connection is replaced (so the monitor is restored on reconnect) meanwhile.
+var errMonitorSuperseded = errors.New("monitor is canceled or restored on reconnect")
+
+// reestablishMonitor sets the monitor from scratch, the initial snapshot is delivered
+// in background (see release). The request is made without c.monMu, its result is kept
+// only if the monitor is not canceled and the connection is not replaced meanwhile,
+// errMonitorSuperseded is returned otherwise. If the server doesn't answer, the connection
+// is dropped to restore the monitor on reconnect (see monitorLost), *RPCError is returned
+// if the server rejects the monitor. The caller must hold item.reestablishMu.
+func (c *Client) reestablishMonitor(ctx context.Context, item *monitorItem) error {
+	c.monMu.RLock()
+	live := c.monitors[item.monName] == item
+	reqs := item.initialReqs
+	c.monMu.RUnlock()
+	if !live {
+		return errMonitorSuperseded
+	}
+	jConn := c.conn()
+
+	c.hold(item)
+	var snap *snapshot
+	defer func() { c.release(item, snap) }()
+	var (
+		res m3Resp
+		upd monitor.TableSetUpdate
+		err error
+	)
+	switch {
+	case item.updChan2 != nil:
+		// monitor_cond_since to resume from the last transaction on reconnect, see restoreMonitor
+		res, err = c.callMonitorCondSince(ctx, item.db, item.monName, types.ZeroUUID, reqs)
+	case item.updChan != nil:
+		upd, err = c.callMonitor(ctx, item.db, item.monName, reqs)
+	}
+
+	c.monMu.Lock()
+	if c.monitors[item.monName] != item || c.conn() != jConn {
+		c.monMu.Unlock()
+		return errMonitorSuperseded
+	}
+	var rErr *RPCError
+	switch {
+	case errors.As(err, &rErr):
+	case err != nil:
+		c.monMu.Unlock()
+		c.monitorLost(jConn, item, err)
+		return err
+	case item.updChan2 != nil:
+		item.lastTxnId = res.lastTxnID
+		snap = &snapshot{upd2: item.markResync2(res.update2)}
+	default:
+		snap = &snapshot{upd: item.markResync(upd)}
+	}
+	c.monMu.Unlock()
+	return err
+}
+
+// monitorLost handles the request on jConn re-establishing the monitor not answered
+// by the server. The state of the monitor on the server is unknown, so the connection
+// is dropped and the monitor is restored from scratch on reconnect.
+func (c *Client) monitorLost(jConn jrpc.Connection, item *monitorItem, err error) {
+	c.monMu.Lock()
+	if c.monitors[item.monName] == item {
+		item.lastTxnId = types.ZeroUUID
+	}
+	c.monMu.Unlock()
+	if jConn == nil || c.ctx.Err() != nil {
+		// not connected or closed
+		return
+	}
+	c.dropConn(jConn, fmt.Errorf("re-establish monitor %q: %w", item.monName, err))
+}
+
+// markResync2 marks the snapshot by monitor.ResyncTable if the monitor asks for it.
+func (m *monitorItem) markResync2(upd monitor.TableSetUpdate2) monitor.TableSetUpdate2 {
+	if upd == nil {
+		upd = make(monitor.TableSetUpdate2)
+	}
+	if m.resyncMarker {
+		upd[monitor.ResyncTable] = monitor.TableUpdate2{}
+	}
+	return upd
+}
+
+// markResync marks the snapshot by monitor.ResyncTable if the monitor asks for it.
+func (m *monitorItem) markResync(upd monitor.TableSetUpdate) monitor.TableSetUpdate {
+	if upd == nil {
+		upd = make(monitor.TableSetUpdate)
+	}
+	if m.resyncMarker {
+		upd[monitor.ResyncTable] = monitor.TableUpdate{}
+	}
+	return upd
+}
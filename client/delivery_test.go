@@ -0,0 +1,243 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	jrpc "github.com/kazmanavt/jsonrpc/v2/jrpc1"
+	"github.com/kazmanavt/ovsdb/v2/monitor"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testSchema = `{
+  "name": "Test",
+  "version": "1.0.0",
+  "tables": {
+    "T": {
+      "columns": {
+        "x": {"type": "integer"}
+      }
+    }
+  }
+}`
+
+const testRowUUID = "36c2c8ab-6c6c-4d5b-a0c5-6d0b9e3ac1b7"
+
+// monServer is a fake server of the Test db able to push monitor notifications.
+type monServer struct {
+	conn    atomic.Pointer[jrpc.ClientConn]
+	cancels atomic.Int32
+	x       atomic.Int32
+	row     atomic.Value // uuid of the only row, testRowUUID if not set
+	reject  atomic.Bool  // reject the monitor requests
+	stall   atomic.Bool  // answer monitor_cancel in a second
+}
+
+func (s *monServer) dial(_ context.Context) (net.Conn, error) {
+	cli, srv := net.Pipe()
+	conn := jrpc.NewConnection(srv, nil)
+	handlers := map[string]any{
+		"list_dbs": func() ([]string, error) {
+			return []string{"Test"}, nil
+		},
+		"get_schema": func(string) (json.RawMessage, error) {
+			return json.RawMessage(testSchema), nil
+		},
+		"set_db_change_aware": func(bool) (struct{}, error) {
+			return struct{}{}, nil
+		},
+		"monitor_cond": func(db, monName string, reqs json.RawMessage) (json.RawMessage, error) {
+			return s.snapshot(), nil
+		},
+		"monitor_cond_since": func(db, monName string, reqs json.RawMessage, lastTxnId string) (json.RawMessage, error) {
+			if s.reject.Load() {
+				return nil, fmt.Errorf("syntax error")
+			}
+			// the history is never kept
+			return json.RawMessage(fmt.Sprintf(`[false, %q, %s]`, types.ZeroUUID, s.snapshot())), nil
+		},
+		"monitor_cancel": func(string) (struct{}, error) {
+			s.cancels.Add(1)
+			if s.stall.Load() {
+				time.Sleep(time.Second)
+			}
+			return struct{}{}, nil
+		},
+	}
+	for method, h := range handlers {
+		if err := conn.HandleCall(method, h); err != nil {
+			return nil, err
+		}
+	}
+	s.conn.Store(conn)
+	return cli, nil
+}
+
+func (s *monServer) rowUUID() string {
+	if u, ok := s.row.Load().(string); ok {
+		return u
+	}
+	return testRowUUID
+}
+
+func (s *monServer) snapshot() json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{"T":{%q:{"initial":{"x":%d}}}}`, s.rowUUID(), s.x.Load()))
+}
+
+func (s *monServer) update(t *testing.T, monName string) {
+	x := s.x.Add(1)
+	upd := json.RawMessage(fmt.Sprintf(`{"T":{%q:{"modify":{"x":%d}}}}`, s.rowUUID(), x))
+	require.NoError(t, s.conn.Load().Notify(context.Background(), "update2", monName, upd))
+}
+
+func (s *monServer) setMonitor(t *testing.T, opts ...MonitorOpt) (*Client, <-chan monitor.TableSetUpdate2) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := Dial(ctx, "pipe", "test", WithDialer(s.dial))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	var sch schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &sch))
+	reqs := monitor.NewMonCondReqSet(&sch).Add("T", monitor.MonCondReq{})
+	initial, updates, err := c.SetMonitorCond(ctx, "Test", "mon", reqs, opts...)
+	require.NoError(t, err)
+	require.Contains(t, initial["T"], testRowUUID)
+	return c, updates
+}
+
+func TestDelivery_Queue(t *testing.T) {
+	s := &monServer{}
+	_, updates := s.setMonitor(t, WithMonitorDelivery(DeliveryQueue))
+
+	const n = 50
+	for range n {
+		s.update(t, "mon")
+	}
+	for i := range n {
+		select {
+		case upd := <-updates:
+			assert.False(t, upd.IsResync())
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "update lost", "got %d of %d", i, n)
+		}
+	}
+}
+
+func TestDelivery_Block(t *testing.T) {
+	s := &monServer{}
+	_, updates := s.setMonitor(t, WithMonitorDelivery(DeliveryBlock))
+
+	const n = 30
+	for range n {
+		s.update(t, "mon")
+	}
+	for i := range n {
+		select {
+		case <-updates:
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "update lost", "got %d of %d", i, n)
+		}
+	}
+}
+
+func TestDelivery_Resync(t *testing.T) {
+	s := &monServer{}
+	_, updates := s.setMonitor(t, WithMonitorDelivery(DeliveryResync))
+
+	// overflow the channel of capacity 10
+	for range 20 {
+		s.update(t, "mon")
+	}
+	require.Eventually(t, func() bool { return s.cancels.Load() > 0 }, 5*time.Second, 10*time.Millisecond)
+
+	// the updates made after the resync snapshot is taken follow it
+	resynced := false
+	var x any
+	for !resynced || x != 20 {
+		select {
+		case upd := <-updates:
+			if upd.IsResync() {
+				resynced = true
+				row := upd["T"][testRowUUID].Initial
+				require.NotNil(t, row)
+				x = row.Get("x")
+			} else if resynced {
+				x = upd["T"][testRowUUID].Modify.Get("x")
+			}
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "no resync snapshot or updates following it", "x = %v", x)
+		}
+	}
+}
+
+func TestDelivery_ResyncRejected(t *testing.T) {
+	s := &monServer{}
+	c, _ := s.setMonitor(t, WithMonitorDelivery(DeliveryResync))
+
+	s.reject.Store(true)
+	// overflow the channel of capacity 10
+	for range 20 {
+		s.update(t, "mon")
+	}
+	ev := nextEventOf(t, c, EventMonitorFailed)
+	assert.Equal(t, "Test", ev.DB)
+	assert.Equal(t, "mon", ev.Monitor)
+	var rErr *RPCError
+	assert.ErrorAs(t, ev.Err, &rErr)
+
+	c.monMu.RLock()
+	defer c.monMu.RUnlock()
+	assert.NotContains(t, c.monitors, "mon")
+}
+
+func TestDelivery_ResyncCancelTimeout(t *testing.T) {
+	s := &monServer{}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := Dial(ctx, "pipe", "test", WithDialer(s.dial), WithRestoreTimeout(100*time.Millisecond))
+	require.NoError(t, err)
+	defer func() { _ = c.Close() }()
+	var sch schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &sch))
+	reqs := monitor.NewMonCondReqSet(&sch).Add("T", monitor.MonCondReq{})
+	_, updates, err := c.SetMonitorCond(ctx, "Test", "mon", reqs, WithMonitorDelivery(DeliveryResync))
+	require.NoError(t, err)
+
+	s.stall.Store(true)
+	// overflow the channel of capacity 10
+	for range 20 {
+		s.update(t, "mon")
+	}
+	// the monitor state is unknown, it is restored on reconnect
+	nextEventOf(t, c, EventReconnected)
+	for {
+		select {
+		case upd := <-updates:
+			if upd.IsResync() {
+				return
+			}
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "no resync snapshot")
+		}
+	}
+}
+
+func TestDelivery_Drop(t *testing.T) {
+	s := &monServer{}
+	_, updates := s.setMonitor(t)
+
+	for range 20 {
+		s.update(t, "mon")
+	}
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, updates, 10)
+	assert.Zero(t, s.cancels.Load())
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+)
+
+// Dialer establishes the transport connection to the OVSDB server.
+type Dialer func(ctx context.Context) (net.Conn, error)
+
+// dial establishes the transport connection to the remote.
+// The custom Dialer, if set, is used instead of the remote address.
+func (c *Client) dial(ctx context.Context, r Remote) (net.Conn, error) {
+	if c.dialer != nil {
+		return c.dialer(ctx)
+	}
+
+	var d net.Dialer
+	if r.Network != "ssl" {
+		return d.DialContext(ctx, r.Network, r.Address)
+	}
+
+	cfg, err := c.tls.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := d.DialContext(ctx, "tcp", r.Address)
+	if err != nil {
+		return nil, err
+	}
+	tlsConn := tls.Client(conn, cfg)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	jrpc "github.com/kazmanavt/jsonrpc/v2/jrpc1"
+	"github.com/stretchr/testify/require"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pipeListener is net.Listener serving in-process net.Pipe connections.
+type pipeListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	select {
+	case <-l.done:
+	default:
+		close(l.done)
+	}
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return pipeAddr{}
+}
+
+func (l *pipeListener) Dial(ctx context.Context) (net.Conn, error) {
+	cli, srv := net.Pipe()
+	select {
+	case l.conns <- srv:
+		return cli, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string { return "pipe" }
+func (pipeAddr) String() string  { return "pipe" }
+
+func TestClient_WithDialer(t *testing.T) {
+	l := newPipeListener()
+	s := jrpc.NewServerConnection(l, nil)
+	defer s.Close()
+	require.NoError(t, s.HandleCall("list_dbs", func() ([]string, error) {
+		return []string{}, nil
+	}))
+	require.NoError(t, s.HandleCall("set_db_change_aware", func(bool) (struct{}, error) {
+		return struct{}{}, nil
+	}))
+	require.NoError(t, s.HandleCall("echo", func(args ...string) ([]string, error) {
+		return args, nil
+	}))
+
+	var dials atomic.Int32
+	c := NewClient("pipe", "test", WithDialer(func(ctx context.Context) (net.Conn, error) {
+		dials.Add(1)
+		return l.Dial(ctx)
+	}))
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, c.Echo(ctx))
+	require.EqualValues(t, 1, dials.Load())
+
+	// break the connection, the client should reconnect with the same dialer
+	_ = c.conn().Close()
+	require.Eventually(t, func() bool { return dials.Load() == 2 }, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,257 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/kazmanavt/ovsdb/v2/db"
+	"github.com/kazmanavt/ovsdb/v2/monitor"
+	"github.com/kazmanavt/ovsdb/v2/ovsdbtest"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/transact"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// e2e runs the Client against the in-memory server of the Test db.
+type e2e struct {
+	srv *ovsdbtest.Server
+	sch *schema.DbSchema
+	c   *Client
+	// failed counts the connection attempts rejected while reconnection is held, see hold
+	failed  atomic.Int32
+	release chan struct{}
+}
+
+func newE2E(t *testing.T, opts ...ovsdbtest.ServerOpt) *e2e {
+	var sch schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &sch))
+	e := &e2e{srv: ovsdbtest.NewServer(&sch, opts...), sch: &sch}
+	t.Cleanup(func() { _ = e.srv.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := Dial(ctx, "pipe", "test", WithDialer(e.srv.Dial),
+		WithBackoff(Backoff{Initial: 10 * time.Millisecond, Max: 50 * time.Millisecond}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+	e.c = c
+	return e
+}
+
+// hold drops the connection and fails the reconnection attempts until resume is called.
+func (e *e2e) hold(t *testing.T) {
+	e.failed.Store(0)
+	e.release = make(chan struct{})
+	release := e.release
+	e.srv.SetHook(func(method string, _ []json.RawMessage) error {
+		if method != "list_dbs" {
+			return nil
+		}
+		select {
+		case <-release:
+			return nil
+		default:
+			e.failed.Add(1)
+			return errors.New("injected")
+		}
+	})
+	e.srv.Disconnect()
+	require.Eventually(t, func() bool { return e.failed.Load() > 0 }, 5*time.Second, time.Millisecond)
+}
+
+// resume lets the Client reconnect and waits until its monitors are restored.
+func (e *e2e) resume(t *testing.T) {
+	close(e.release)
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-e.c.Events():
+			if ev.Type == EventMonitorsRestored {
+				return
+			}
+		case <-timeout:
+			require.FailNow(t, "monitors are not restored")
+		}
+	}
+}
+
+func (e *e2e) insert(t *testing.T, x int) types.UUID {
+	tr := transact.NewTransaction(e.sch)
+	tr.Insert(e.sch.Tables["T"].NewRow("x", x))
+	require.NoError(t, e.srv.Transact("Test", tr))
+	u, err := tr.Results().Inserted(0)
+	require.NoError(t, err)
+	return u
+}
+
+func TestClient_E2E_RestoreMonitors(t *testing.T) {
+	e := newE2E(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cache, err := e.c.Cache(ctx, "Test", nil)
+	require.NoError(t, err)
+	reqs := monitor.NewMonCondReqSet(e.sch).Add("T", monitor.MonCondReq{})
+	_, updates, err := e.c.SetMonitorCond(ctx, "Test", "mon", reqs, WithMonitorDelivery(DeliveryQueue), WithResyncMarker())
+	require.NoError(t, err)
+	_, plain, err := e.c.SetMonitorCond(ctx, "Test", "plain", reqs, WithMonitorDelivery(DeliveryQueue))
+	require.NoError(t, err)
+
+	tr := transact.NewTransaction(e.sch)
+	tr.Insert(e.sch.Tables["T"].NewRow("x", 1))
+	require.NoError(t, e.c.Transact(ctx, "Test", tr))
+	first, err := tr.Results().Inserted(0)
+	require.NoError(t, err)
+	select {
+	case upd := <-updates:
+		require.Contains(t, upd["T"], string(first))
+		assert.NotNil(t, upd["T"][string(first)].Insert)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "update is not received")
+	}
+	require.Eventually(t, func() bool { return cache.TableLen("T") == 1 }, 5*time.Second, time.Millisecond)
+
+	// the change made while the client is away
+	e.hold(t)
+	second := e.insert(t, 2)
+	e.resume(t)
+
+	// monitor_cond_since resumes from the last transaction seen
+	require.Eventually(t, func() bool { return cache.TableRow("T", second) != nil }, 5*time.Second, time.Millisecond)
+	assert.Equal(t, 2, cache.TableLen("T"))
+	assert.Equal(t, 2, cache.Get("T", second, "x"))
+
+	// monitor_cond has no transaction id to resume from, it is restored with the fresh snapshot
+	select {
+	case upd := <-updates:
+		require.True(t, upd.IsResync())
+		assert.Len(t, upd["T"], 2)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "snapshot is not received")
+	}
+	// the snapshot is not marked without WithResyncMarker
+	select {
+	case upd := <-plain:
+		require.NotNil(t, upd["T"][string(first)].Insert)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "update is not received")
+	}
+	select {
+	case upd := <-plain:
+		assert.NotContains(t, upd, monitor.ResyncTable)
+		assert.Len(t, upd["T"], 2)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "snapshot is not received")
+	}
+
+	// then it is restored by monitor_cond_since with the missed changes only
+	e.hold(t)
+	third := e.insert(t, 3)
+	e.resume(t)
+	select {
+	case upd := <-updates:
+		require.False(t, upd.IsResync())
+		require.Len(t, upd["T"], 1)
+		assert.NotNil(t, upd["T"][string(third)].Insert)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "missed change is not received")
+	}
+}
+
+func TestClient_E2E_CacheResync(t *testing.T) {
+	// no history, so the restored monitor_cond_since gets the snapshot
+	e := newE2E(t, ovsdbtest.WithHistory(0))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	first, second := e.insert(t, 1), e.insert(t, 2)
+	cache, err := e.c.Cache(ctx, "Test", nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, cache.TableLen("T"))
+
+	e.hold(t)
+	tr := transact.NewTransaction(e.sch)
+	tr.Delete("T", []types.Condition{types.Equal("_uuid", first)})
+	require.NoError(t, e.srv.Transact("Test", tr))
+	e.resume(t)
+
+	require.Eventually(t, func() bool { return cache.TableRow("T", first) == nil }, 5*time.Second, time.Millisecond)
+	assert.Equal(t, 1, cache.TableLen("T"))
+	assert.NotNil(t, cache.TableRow("T", second))
+}
+
+func TestClient_E2E_RestoreWithoutMonitorCondSince(t *testing.T) {
+	e := newE2E(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	first := e.insert(t, 1)
+	reqs := monitor.NewMonCondReqSet(e.sch).Add("T", monitor.MonCondReq{})
+	_, updates, err := e.c.SetMonitorCond(ctx, "Test", "mon", reqs, WithMonitorDelivery(DeliveryQueue), WithResyncMarker())
+	require.NoError(t, err)
+
+	// the server of the old version
+	e.srv.SetHook(func(method string, _ []json.RawMessage) error {
+		if method == "monitor_cond_since" {
+			return errors.New("unknown method")
+		}
+		return nil
+	})
+	for range 2 {
+		e.srv.Disconnect()
+
+		// the monitor is restored by monitor_cond with the full snapshot
+		select {
+		case upd := <-updates:
+			require.True(t, upd.IsResync())
+			assert.Len(t, upd["T"], 1)
+			assert.NotNil(t, upd["T"][string(first)].Initial)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "snapshot is not received")
+		}
+	}
+}
+
+func TestClient_E2E_RunTxnVerifiesVersion(t *testing.T) {
+	e := newE2E(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	u := e.insert(t, 1)
+	cache, err := e.c.Cache(ctx, "Test", nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, cache.Get("T", u, "_version"), "the cache keeps _version of the rows")
+	where := []types.Condition{types.Equal("_uuid", u)}
+	increment := func(calls *int, concurrent func()) TxnFunc {
+		return func(view db.DB, tr transact.Transaction) error {
+			*calls++
+			x := view.Get("T", u, "x").(int)
+			if concurrent != nil && *calls == 1 {
+				concurrent()
+			}
+			tr.Update(where, e.sch.Tables["T"].NewRow("x", x+1))
+			return nil
+		}
+	}
+
+	// the row is not changed, so the wait on its _version is satisfied at once
+	var calls int
+	require.NoError(t, e.c.RunTxn(ctx, cache, increment(&calls, nil)))
+	assert.Equal(t, 1, calls)
+	require.Eventually(t, func() bool { return cache.Get("T", u, "x") == 2 }, 5*time.Second, time.Millisecond)
+
+	// the row changed by another client after it is read fails the wait
+	calls = 0
+	require.NoError(t, e.c.RunTxn(ctx, cache, increment(&calls, func() {
+		tr := transact.NewTransaction(e.sch)
+		tr.Update(where, e.sch.Tables["T"].NewRow("x", 10))
+		require.NoError(t, e.srv.Transact("Test", tr))
+	})))
+	assert.Equal(t, 2, calls)
+	require.Eventually(t, func() bool { return cache.Get("T", u, "x") == 11 }, 5*time.Second, time.Millisecond)
+}
@@ -9,7 +9,7 @@ import (
 
 func (c *Client) Echo(ctx context.Context) error {
 	UUID := types.NewNamedUUID()
-	resp, err := c.jConn.Call(ctx, "echo", UUID)
+	resp, err := c.call(ctx, "echo", UUID)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	jrpc "github.com/kazmanavt/jsonrpc/v2/jrpc1"
+)
+
+// Connection level errors of the calls to the server.
+var (
+	// ErrNotConnected is returned when the Client is not connected to the server,
+	// or the connection is lost before the response is received.
+	ErrNotConnected = errors.New("not connected")
+	// ErrCallTimeout is returned when the call context deadline is exceeded before the response is received.
+	ErrCallTimeout = errors.New("call timeout")
+	// ErrCanceled is returned when the call context is canceled before the response is received,
+	// or the server reports the request canceled (see CancelTransact).
+	ErrCanceled = errors.New("call canceled")
+)
+
+// RPCError is the JSON-RPC error returned by the server in response to the call.
+type RPCError struct {
+	Method  string          // called method
+	Err     string          // error reported by the server, e.g. "unknown database"
+	Details string          // details of the error, if provided by the server
+	Raw     json.RawMessage // error as received
+}
+
+func (e *RPCError) Error() string {
+	msg := fmt.Sprintf("%s: %s", e.Method, e.Err)
+	if e.Details != "" {
+		msg += fmt.Sprintf(" (%s)", e.Details)
+	}
+	return msg
+}
+
+// Is makes RPCError of the canceled request match ErrCanceled.
+func (e *RPCError) Is(target error) bool {
+	return target == ErrCanceled && e.Err == "canceled"
+}
+
+func newRPCError(method string, rawErr []byte) *RPCError {
+	rErr := RPCError{Method: method, Raw: rawErr}
+	var obj struct {
+		Error   string `json:"error"`
+		Details string `json:"details"`
+	}
+	if err := json.Unmarshal(rawErr, &obj); err == nil && obj.Error != "" {
+		rErr.Err, rErr.Details = obj.Error, obj.Details
+		return &rErr
+	}
+	if err := json.Unmarshal(rawErr, &rErr.Err); err != nil {
+		rErr.Err = string(rawErr)
+	}
+	return &rErr
+}
+
+// isUnknownMethod reports whether err is the error of the server not supporting the called method.
+func isUnknownMethod(err error) bool {
+	var rErr *RPCError
+	return errors.As(err, &rErr) && rErr.Err == "unknown method"
+}
+
+// connClosedErr is the error of the pending requests failed by jrpc on disconnect.
+var connClosedErr = []byte(`"connection closed"`)
+
+// call calls method on the current connection, see callConn.
+func (c *Client) call(ctx context.Context, method string, params ...any) (jrpc.Response, error) {
+	return callConn(ctx, c.conn(), method, params...)
+}
+
+// callConn calls method on jConn waiting for the response until ctx ends.
+// The error is ErrNotConnected, ErrCallTimeout or ErrCanceled (wrapped) on connection level
+// failures, and *RPCError if the server responds with an error.
+func callConn(ctx context.Context, jConn jrpc.Connection, method string, params ...any) (jrpc.Response, error) {
+	respChan, err := sendConn(ctx, jConn, method, params...)
+	if err != nil {
+		return nil, err
+	}
+	return awaitResponse(ctx, method, respChan)
+}
+
+// sendConn sends the request of method to jConn, see callConn.
+func sendConn(ctx context.Context, jConn jrpc.Connection, method string, params ...any) (<-chan jrpc.Response, error) {
+	if jConn == nil {
+		return nil, fmt.Errorf("%s: %w", method, ErrNotConnected)
+	}
+	// Send blocks forever on the closed connection
+	select {
+	case <-jConn.Done():
+		return nil, fmt.Errorf("%s: %w", method, ErrNotConnected)
+	default:
+	}
+
+	respChan, err := jConn.Send(ctx, method, params...)
+	if err != nil {
+		if ctxErr := ctxError(ctx); ctxErr != nil {
+			return nil, fmt.Errorf("%s: %w: %w", method, ctxErr, err)
+		}
+		return nil, fmt.Errorf("%s: %w: %w", method, ErrNotConnected, err)
+	}
+	return respChan, nil
+}
+
+// awaitResponse waits for the response of method on respChan, see callConn.
+func awaitResponse(ctx context.Context, method string, respChan <-chan jrpc.Response) (jrpc.Response, error) {
+	var resp jrpc.Response
+	select {
+	case resp = <-respChan:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w: %w", method, ctxError(ctx), ctx.Err())
+	}
+	if resp == nil {
+		return nil, fmt.Errorf("%s: %w", method, ErrNotConnected)
+	}
+	if rawErr := resp.GetErr(); rawErr != nil && !bytes.Equal(rawErr, []byte("null")) {
+		if bytes.Equal(rawErr, connClosedErr) {
+			return nil, fmt.Errorf("%s: %w: connection closed", method, ErrNotConnected)
+		}
+		return nil, newRPCError(method, rawErr)
+	}
+	return resp, nil
+}
+
+func ctxError(ctx context.Context) error {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return ErrCallTimeout
+	case ctx.Err() != nil:
+		return ErrCanceled
+	}
+	return nil
+}
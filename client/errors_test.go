@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/transact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestClient_CallErrors(t *testing.T) {
+	s := &monServer{}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := Dial(ctx, "pipe", "test", WithDialer(s.dial))
+	require.NoError(t, err)
+	defer c.Close()
+
+	conn := s.conn.Load()
+	require.NoError(t, conn.HandleCall("get_server_id", func() (string, error) {
+		return "", errors.New("unknown method")
+	}))
+	// the slow transaction ignores the cancel and is not answered until the end
+	slow := make(chan struct{})
+	defer close(slow)
+	require.NoError(t, conn.HandleCall("transact", func(db string, ops ...json.RawMessage) (json.RawMessage, error) {
+		if db == "slow" {
+			<-slow
+		}
+		if db == "canceled" {
+			return nil, errors.New("canceled")
+		}
+		return json.RawMessage(`[{"count": 1}, {"error": "constraint violation", "details": "x must be positive"}]`), nil
+	}))
+
+	// server JSON-RPC error
+	_, err = c.GetServerID(ctx)
+	var rErr *RPCError
+	require.ErrorAs(t, err, &rErr)
+	assert.Equal(t, "get_server_id", rErr.Method)
+	assert.Equal(t, "unknown method", rErr.Err)
+
+	// failed operation
+	var sch schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &sch))
+	newTxn := func() transact.Transaction {
+		row := sch.Tables["T"].NewRow()
+		row.Set("x", -1)
+		return transact.NewTransaction(&sch).Delete("T", nil).Insert(row)
+	}
+	err = c.Transact(ctx, "Test", newTxn())
+	require.ErrorIs(t, err, transact.ErrConstraintViolation)
+	var opErr *transact.OpError
+	require.ErrorAs(t, err, &opErr)
+	assert.Equal(t, 1, opErr.Index)
+	assert.Equal(t, "insert", opErr.Op)
+	assert.Equal(t, "x must be positive", opErr.Details)
+
+	// canceled by server
+	err = c.Transact(ctx, "canceled", newTxn())
+	assert.ErrorIs(t, err, ErrCanceled)
+
+	// call timeout
+	tCtx, tCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer tCancel()
+	err = c.Transact(tCtx, "slow", newTxn())
+	assert.ErrorIs(t, err, ErrCallTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// not connected
+	require.NoError(t, c.Close())
+	_, err = c.GetServerID(ctx)
+	assert.ErrorIs(t, err, ErrNotConnected)
+}
+
+func TestClient_TransactAssert(t *testing.T) {
+	s := &monServer{}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := Dial(ctx, "pipe", "test", WithDialer(s.dial))
+	require.NoError(t, err)
+	defer c.Close()
+
+	conn := s.conn.Load()
+	locked := false
+	require.NoError(t, conn.HandleCall("lock", func(string) (map[string]bool, error) {
+		return map[string]bool{"locked": locked}, nil
+	}))
+	require.NoError(t, conn.HandleCall("transact", func(db string, ops ...json.RawMessage) (json.RawMessage, error) {
+		return json.RawMessage(`[{}, {"count": 0}]`), nil
+	}))
+
+	var sch schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &sch))
+	newTxn := func() transact.Transaction {
+		return transact.NewTransaction(&sch).Assert("owner").Delete("T", nil)
+	}
+
+	// the lock is not requested
+	err = c.Transact(ctx, "Test", newTxn())
+	require.ErrorIs(t, err, transact.ErrNotOwner)
+
+	// the lock is owned by another client
+	_, err = c.Lock(ctx, "owner")
+	require.NoError(t, err)
+	err = c.Transact(ctx, "Test", newTxn())
+	require.ErrorIs(t, err, transact.ErrNotOwner)
+
+	// the lock is acquired
+	c.lockedHandler()("owner")
+	require.NoError(t, c.Transact(ctx, "Test", newTxn()))
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	jrpc "github.com/kazmanavt/jsonrpc/v2/jrpc1"
+	"log/slog"
+	"time"
+)
+
+const defaultEventsBuffer = 64
+
+var (
+	// ErrConnectionClosed is the cause of EventDisconnected when the connection
+	// is closed by the server or by the network failure.
+	ErrConnectionClosed = errors.New("connection closed")
+	// ErrClientClosed is the cause of EventDisconnected emitted by Client.Close.
+	ErrClientClosed = errors.New("client closed")
+)
+
+// EventType is a type of the connection lifecycle event.
+type EventType int
+
+const (
+	// EventConnected is emitted when the first connection is established by NewClient or Dial.
+	EventConnected EventType = iota
+	// EventDisconnected is emitted when the established connection is lost, Event.Err holds the cause.
+	EventDisconnected
+	// EventReconnected is emitted when the connection is re-established, after monitors and locks are restored.
+	EventReconnected
+	// EventMonitorsRestored is emitted when the monitors are re-established on reconnect,
+	// or after the db schema change (Event.DB is set in this case).
+	EventMonitorsRestored
+	// EventSchemaRefreshed is emitted when the schema of Event.DB is changed on the server.
+	EventSchemaRefreshed
+	// EventKeepAliveFailed is emitted when the keep alive echo fails, Event.Err holds the cause.
+	// The connection is closed after that and EventDisconnected follows.
+	EventKeepAliveFailed
+	// EventMonitorFailed is emitted when the monitor Event.Monitor of Event.DB canceled by the server
+	// (e.g. on the db schema change) or by DeliveryResync is rejected by the server on re-establishing,
+	// Event.Err holds the cause.
+	// The monitor is forgotten then, its updates channel is not fed anymore.
+	EventMonitorFailed
+	// EventServerStatus is emitted when the status of Event.DB reported by _Server database
+	// changes, Event.Err holds ErrNotLeader or ErrClusterDisconnected (wrapped, see ServerStatus.Check),
+	// or nil if the db is able to accept writes again.
+	EventServerStatus
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventConnected:
+		return "connected"
+	case EventDisconnected:
+		return "disconnected"
+	case EventReconnected:
+		return "reconnected"
+	case EventMonitorsRestored:
+		return "monitors restored"
+	case EventSchemaRefreshed:
+		return "schema refreshed"
+	case EventKeepAliveFailed:
+		return "keep alive failed"
+	case EventMonitorFailed:
+		return "monitor failed"
+	case EventServerStatus:
+		return "server status"
+	}
+	return fmt.Sprintf("EventType(%d)", int(t))
+}
+
+// Event is a connection lifecycle event of the Client.
+type Event struct {
+	Type    EventType
+	Time    time.Time
+	Remote  Remote // remote the Client is (or was) connected to
+	DB      string // db name for EventSchemaRefreshed, EventMonitorFailed, EventServerStatus and EventMonitorsRestored after the schema change
+	Monitor string // monitor name for EventMonitorFailed
+	Err     error  // cause of EventDisconnected, EventKeepAliveFailed, EventMonitorFailed and EventServerStatus
+}
+
+func (e Event) String() string {
+	s := fmt.Sprintf("%s %s", e.Type, e.Remote)
+	if e.DB != "" {
+		s += fmt.Sprintf(" db=%s", e.DB)
+	}
+	if e.Monitor != "" {
+		s += fmt.Sprintf(" monitor=%s", e.Monitor)
+	}
+	if e.Err != nil {
+		s += fmt.Sprintf(": %s", e.Err)
+	}
+	return s
+}
+
+// Events returns the channel of the connection lifecycle events.
+// The channel keeps only the latest events if the reader is slow (see WithEventsBuffer),
+// and it is closed by Close.
+func (c *Client) Events() <-chan Event {
+	return c.events
+}
+
+func (c *Client) emit(t EventType, db string, err error) {
+	c.emitEvent(Event{Type: t, DB: db, Err: err})
+}
+
+// emitEvent sends ev stamped with the time and the remote.
+func (c *Client) emitEvent(ev Event) {
+	c.lock.RLock()
+	ev.Remote = c.remote
+	c.lock.RUnlock()
+	ev.Time = time.Now()
+	c.log.Debug("client event", slog.String("event", ev.String()))
+
+	c.eventsMu.Lock()
+	defer c.eventsMu.Unlock()
+	if c.eventsClosed {
+		return
+	}
+	select {
+	case c.events <- ev:
+	default:
+		// drop the oldest event to keep the latest one
+		select {
+		case <-c.events:
+		default:
+		}
+		select {
+		case c.events <- ev:
+		default:
+		}
+	}
+}
+
+func (c *Client) closeEvents() {
+	c.eventsMu.Lock()
+	defer c.eventsMu.Unlock()
+	if !c.eventsClosed {
+		c.eventsClosed = true
+		close(c.events)
+	}
+}
+
+// dropConn closes jConn remembering the cause for EventDisconnected.
+func (c *Client) dropConn(jConn jrpc.Connection, cause error) {
+	c.lock.Lock()
+	if c.jConn == jConn && c.dropCause == nil {
+		c.dropCause = cause
+	}
+	c.lock.Unlock()
+	_ = jConn.Close()
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	jrpc "github.com/kazmanavt/jsonrpc/v2/jrpc1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func nextEvent(t *testing.T, c *Client) Event {
+	t.Helper()
+	select {
+	case ev, ok := <-c.Events():
+		require.True(t, ok, "events channel closed")
+		return ev
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no event")
+	}
+	return Event{}
+}
+
+func startEchoServer(t *testing.T, echo func(args ...string) ([]string, error)) *pipeListener {
+	l := newPipeListener()
+	s := jrpc.NewServerConnection(l, nil)
+	t.Cleanup(func() { _ = s.Close() })
+	require.NoError(t, s.HandleCall("list_dbs", func() ([]string, error) {
+		return []string{}, nil
+	}))
+	require.NoError(t, s.HandleCall("set_db_change_aware", func(bool) (struct{}, error) {
+		return struct{}{}, nil
+	}))
+	require.NoError(t, s.HandleCall("echo", echo))
+	return l
+}
+
+func TestClient_Events(t *testing.T) {
+	l := startEchoServer(t, func(args ...string) ([]string, error) {
+		return args, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := Dial(ctx, "pipe", "test", WithDialer(l.Dial))
+	require.NoError(t, err)
+
+	ev := nextEvent(t, c)
+	assert.Equal(t, EventConnected, ev.Type)
+	assert.Equal(t, Remote{Network: "pipe", Address: "test"}, ev.Remote)
+
+	// connection lost
+	_ = c.conn().Close()
+	ev = nextEvent(t, c)
+	assert.Equal(t, EventDisconnected, ev.Type)
+	assert.ErrorIs(t, ev.Err, ErrConnectionClosed)
+	assert.Equal(t, EventReconnected, nextEvent(t, c).Type)
+
+	require.NoError(t, c.Close())
+	var last Event
+	for ev := range c.Events() {
+		last = ev
+	}
+	assert.Equal(t, EventDisconnected, last.Type)
+	assert.ErrorIs(t, last.Err, ErrClientClosed)
+}
+
+func TestClient_EventsKeepAliveFailed(t *testing.T) {
+	l := startEchoServer(t, func(args ...string) ([]string, error) {
+		return []string{"garbage"}, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := Dial(ctx, "pipe", "test", WithDialer(l.Dial), WithKeepAlivePeriod(20*time.Millisecond))
+	require.NoError(t, err)
+	defer c.Close()
+
+	assert.Equal(t, EventConnected, nextEvent(t, c).Type)
+	ev := nextEvent(t, c)
+	assert.Equal(t, EventKeepAliveFailed, ev.Type)
+	assert.ErrorContains(t, ev.Err, "unexpected response")
+	ev = nextEvent(t, c)
+	assert.Equal(t, EventDisconnected, ev.Type)
+	assert.ErrorContains(t, ev.Err, "keep alive")
+	assert.Equal(t, EventReconnected, nextEvent(t, c).Type)
+}
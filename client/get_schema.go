@@ -15,11 +15,21 @@ func (c *Client) GetSchema(ctx context.Context, db string) (*schema.DbSchema, er
 	}
 	c.schemasMu.RUnlock()
 
-	resp, err := c.jConn.Call(ctx, "get_schema", db)
+	sch, err := c.getSchema(ctx, db)
 	if err != nil {
 		return nil, err
 	}
-	if err := resp.Error(); err != nil {
+	c.schemasMu.Lock()
+	defer c.schemasMu.Unlock()
+	c.schemas[db] = sch
+
+	return sch, nil
+}
+
+// getSchema fetches db schema from the server bypassing the cache.
+func (c *Client) getSchema(ctx context.Context, db string) (*schema.DbSchema, error) {
+	resp, err := c.call(ctx, "get_schema", db)
+	if err != nil {
 		return nil, err
 	}
 	var sch schema.DbSchema
@@ -27,9 +37,5 @@ func (c *Client) GetSchema(ctx context.Context, db string) (*schema.DbSchema, er
 		c.log.Debug("get schema: fail unmarshal response", slog.String("error", err.Error()))
 		return nil, err
 	}
-	c.schemasMu.Lock()
-	defer c.schemasMu.Unlock()
-	c.schemas[db] = &sch
-
 	return &sch, nil
 }
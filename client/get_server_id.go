@@ -0,0 +1,23 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/types"
+)
+
+// GetServerID returns the unique server ID of the ovsdb-server the Client
+// is connected to (get_server_id method). For clustered databases it differs
+// from the raft server ID reported in _Server database.
+func (c *Client) GetServerID(ctx context.Context) (types.UUID, error) {
+	resp, err := c.call(ctx, "get_server_id")
+	if err != nil {
+		return "", err
+	}
+	var id string
+	if err := json.Unmarshal(resp.GetResult(), &id); err != nil {
+		return "", fmt.Errorf("get_server_id: unmarshal response: %w", err)
+	}
+	return types.UUID(id), nil
+}
@@ -10,17 +10,19 @@ import (
 func (c *Client) ListDbs(ctx context.Context) ([]string, error) {
 	c.dbsNamesMu.RLock()
 	if c.dbsNames != nil {
+		defer c.dbsNamesMu.RUnlock()
 		return slices.Clone(c.dbsNames), nil
 	}
 	c.dbsNamesMu.RUnlock()
+	return c.listDbs(ctx)
+}
 
-	resp, err := c.jConn.Call(ctx, "list_dbs")
+// listDbs lists dbs on the server and updates the cache.
+func (c *Client) listDbs(ctx context.Context) ([]string, error) {
+	resp, err := c.call(ctx, "list_dbs")
 	if err != nil {
 		return nil, err
 	}
-	if err := resp.Error(); err != nil {
-		return nil, err
-	}
 
 	var dbs []string
 	if err := json.Unmarshal(resp.GetResult(), &dbs); err != nil {
@@ -0,0 +1,252 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// LockState is a state of the OVSDB lock requested by the Client (RFC 7047 4.1.8).
+type LockState int
+
+const (
+	// LockWaiting means the lock is requested, but it is owned by another client.
+	LockWaiting LockState = iota
+	// LockAcquired means the lock is owned by the Client.
+	LockAcquired
+	// LockStolen means the lock was stolen by another client. The Client stays in
+	// the server's queue of waiters and gets the lock back once it is released.
+	LockStolen
+)
+
+func (s LockState) String() string {
+	switch s {
+	case LockWaiting:
+		return "waiting"
+	case LockAcquired:
+		return "acquired"
+	case LockStolen:
+		return "stolen"
+	}
+	return fmt.Sprintf("LockState(%d)", int(s))
+}
+
+// Lock is a handle of the lock requested by Lock or Steal.
+// The handle stays valid until Unlock is called, the lock is re-requested
+// automatically after the Client reconnects to the server.
+type Lock struct {
+	id      string
+	mu      sync.RWMutex
+	state   LockState
+	changes chan LockState
+	closed  bool // changes is closed by Unlock
+}
+
+// ID returns the lock name.
+func (l *Lock) ID() string {
+	return l.id
+}
+
+// State returns the current state of the lock.
+func (l *Lock) State() LockState {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.state
+}
+
+// Changes returns the channel of the lock state changes.
+// The channel keeps only the latest changes if the reader is slow, and
+// it is closed by Unlock.
+func (l *Lock) Changes() <-chan LockState {
+	return l.changes
+}
+
+func (l *Lock) setState(s LockState) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed || l.state == s {
+		return
+	}
+	l.state = s
+	select {
+	case l.changes <- s:
+	default:
+		// drop the oldest change to keep the latest one
+		select {
+		case <-l.changes:
+		default:
+		}
+		select {
+		case l.changes <- s:
+		default:
+		}
+	}
+}
+
+// close closes the changes channel, the late notifications are ignored then.
+func (l *Lock) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.closed {
+		l.closed = true
+		close(l.changes)
+	}
+}
+
+type lockResp struct {
+	Locked bool `json:"locked"`
+}
+
+func (c *Client) callLock(ctx context.Context, method string, id string) (bool, error) {
+	resp, err := c.call(ctx, method, id)
+	if err != nil {
+		return false, err
+	}
+	var res lockResp
+	if err := json.Unmarshal(resp.GetResult(), &res); err != nil {
+		return false, fmt.Errorf("%s: unmarshal response: %w", method, err)
+	}
+	return res.Locked, nil
+}
+
+// Lock requests the lock with the given name (RFC 7047 4.1.8).
+// It returns the lock handle in LockAcquired state if the lock was granted
+// immediately, or in LockWaiting state otherwise.
+func (c *Client) Lock(ctx context.Context, id string) (*Lock, error) {
+	// register the lock before call, "locked" notification may come before the response
+	c.locksMu.Lock()
+	if _, ok := c.locks[id]; ok {
+		c.locksMu.Unlock()
+		return nil, fmt.Errorf("lock %q already requested", id)
+	}
+	l := newLock(id)
+	c.locks[id] = l
+	c.locksMu.Unlock()
+
+	locked, err := c.callLock(ctx, "lock", id)
+	if err != nil {
+		c.forgetLock(l)
+		return nil, err
+	}
+	if locked {
+		l.setState(LockAcquired)
+	}
+	return l, nil
+}
+
+// Steal takes over the lock with the given name even if it is owned by another client (RFC 7047 4.1.9).
+// The previous owner gets "stolen" notification. If the lock was requested
+// before by Lock, the same handle is returned. After the Client reconnects, the lock
+// is requested by "lock", so the client which has stolen it meanwhile keeps it.
+func (c *Client) Steal(ctx context.Context, id string) (*Lock, error) {
+	c.locksMu.Lock()
+	l, ok := c.locks[id]
+	if !ok {
+		l = newLock(id)
+		c.locks[id] = l
+	}
+	c.locksMu.Unlock()
+
+	if _, err := c.callLock(ctx, "steal", id); err != nil {
+		if !ok {
+			c.forgetLock(l)
+		}
+		return nil, err
+	}
+	l.setState(LockAcquired)
+	return l, nil
+}
+
+func newLock(id string) *Lock {
+	return &Lock{
+		id:      id,
+		state:   LockWaiting,
+		changes: make(chan LockState, 10),
+	}
+}
+
+// forgetLock removes the lock l registered by the failed Lock or Steal.
+func (c *Client) forgetLock(l *Lock) {
+	c.locksMu.Lock()
+	defer c.locksMu.Unlock()
+	if c.locks[l.id] == l {
+		delete(c.locks, l.id)
+	}
+}
+
+// Unlock releases the lock with the given name or cancels the waiting for it (RFC 7047 4.1.10).
+// The lock handle becomes invalid and its Changes channel is closed.
+func (c *Client) Unlock(ctx context.Context, id string) error {
+	c.locksMu.RLock()
+	l, ok := c.locks[id]
+	c.locksMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("lock %q not requested", id)
+	}
+
+	if _, err := c.call(ctx, "unlock", id); err != nil {
+		return err
+	}
+
+	c.locksMu.Lock()
+	if c.locks[id] == l {
+		delete(c.locks, id)
+	}
+	c.locksMu.Unlock()
+	l.close()
+	return nil
+}
+
+func (c *Client) restoreLocks(ctx context.Context) error {
+	c.log.Debug("restoring locks")
+	c.locksMu.RLock()
+	locks := make([]*Lock, 0, len(c.locks))
+	for _, l := range c.locks {
+		locks = append(locks, l)
+	}
+	c.locksMu.RUnlock()
+	// the locks taken by Steal are requested by "lock" as well, stealing them again would
+	// take them from the client which has stolen them while the Client was disconnected
+	for _, l := range locks {
+		// new session, the lock is not owned until server says so
+		l.setState(LockWaiting)
+		locked, err := c.callLock(ctx, "lock", l.id)
+		if err != nil {
+			return err
+		}
+		if locked {
+			l.setState(LockAcquired)
+		}
+	}
+	return nil
+}
+
+func (c *Client) lockedHandler() func(string) {
+	return func(id string) {
+		c.log.Debug("locked handler", slog.String("lock", id))
+		c.locksMu.RLock()
+		l, ok := c.locks[id]
+		c.locksMu.RUnlock()
+		if !ok {
+			c.log.Warn("locked handler", slog.String("lock not found", id))
+			return
+		}
+		l.setState(LockAcquired)
+	}
+}
+
+func (c *Client) stolenHandler() func(string) {
+	return func(id string) {
+		c.log.Debug("stolen handler", slog.String("lock", id))
+		c.locksMu.RLock()
+		l, ok := c.locks[id]
+		c.locksMu.RUnlock()
+		if !ok {
+			c.log.Warn("stolen handler", slog.String("lock not found", id))
+			return
+		}
+		l.setState(LockStolen)
+	}
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// dialMore connects one more Client to the server of e.
+func (e *e2e) dialMore(t *testing.T) *Client {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := Dial(ctx, "pipe", "test", WithDialer(e.srv.Dial))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func nextLockState(t *testing.T, l *Lock) LockState {
+	t.Helper()
+	select {
+	case s, ok := <-l.Changes():
+		require.True(t, ok, "changes channel closed")
+		return s
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no lock state change")
+	}
+	return 0
+}
+
+func TestClient_LockLocked(t *testing.T) {
+	e := newE2E(t)
+	other := e.dialMore(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	owner, err := e.c.Lock(ctx, "l")
+	require.NoError(t, err)
+	assert.Equal(t, LockAcquired, owner.State())
+	waiter, err := other.Lock(ctx, "l")
+	require.NoError(t, err)
+	assert.Equal(t, LockWaiting, waiter.State())
+
+	// the waiter gets "locked" notification once the lock is released
+	require.NoError(t, e.c.Unlock(ctx, "l"))
+	assert.Equal(t, LockAcquired, nextLockState(t, waiter))
+	assert.Equal(t, LockAcquired, waiter.State())
+	assert.Equal(t, LockAcquired, nextLockState(t, owner))
+	_, ok := <-owner.Changes()
+	assert.False(t, ok, "changes channel is closed by Unlock")
+	assert.Error(t, e.c.Unlock(ctx, "l"))
+
+	// the late notification of the unlocked lock is ignored
+	assert.NotPanics(t, func() { owner.setState(LockStolen) })
+}
+
+func TestClient_LockStolen(t *testing.T) {
+	e := newE2E(t)
+	thief := e.dialMore(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	owner, err := e.c.Lock(ctx, "l")
+	require.NoError(t, err)
+	require.Equal(t, LockAcquired, owner.State())
+	stolen, err := thief.Steal(ctx, "l")
+	require.NoError(t, err)
+	assert.Equal(t, LockAcquired, stolen.State())
+
+	assert.Equal(t, LockAcquired, nextLockState(t, owner))
+	assert.Equal(t, LockStolen, nextLockState(t, owner))
+	assert.Equal(t, LockStolen, owner.State())
+
+	// the previous owner gets the lock back when the thief releases it
+	require.NoError(t, thief.Unlock(ctx, "l"))
+	assert.Equal(t, LockAcquired, nextLockState(t, owner))
+}
+
+func TestClient_StealRestoredByLock(t *testing.T) {
+	e := newE2E(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	l, err := e.c.Steal(ctx, "l")
+	require.NoError(t, err)
+	require.Equal(t, LockAcquired, l.State())
+
+	methods := make(chan string, 10)
+	e.srv.SetHook(func(method string, _ []json.RawMessage) error {
+		if method == "lock" || method == "steal" {
+			methods <- method
+		}
+		return nil
+	})
+	e.srv.Disconnect()
+	for {
+		select {
+		case ev := <-e.c.Events():
+			if ev.Type != EventReconnected {
+				continue
+			}
+		case <-ctx.Done():
+			require.FailNow(t, "not reconnected")
+		}
+		break
+	}
+	require.Len(t, methods, 1)
+	assert.Equal(t, "lock", <-methods, "the stolen lock is not stolen again")
+	assert.Equal(t, LockAcquired, l.State())
+}
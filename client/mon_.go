@@ -13,17 +13,12 @@ func (c *Client) _monitor(ctx context.Context, monMethod string, db string, monN
 	var resp jrpc.Response
 	var err error
 	if since == nil {
-		resp, err = c.jConn.Call(ctx, monMethod, db, monName, monReqs)
+		resp, err = c.call(ctx, monMethod, db, monName, monReqs)
 	} else {
-		resp, err = c.jConn.Call(ctx, monMethod, db, monName, monReqs, since)
+		resp, err = c.call(ctx, monMethod, db, monName, monReqs, since)
 	}
 	if err != nil {
 		return nil, err
 	}
-
-	if err := resp.Error(); err != nil {
-		return nil, err
-	}
-
 	return resp, nil
 }
@@ -3,13 +3,16 @@ package client
 import "context"
 
 func (c *Client) CancelMonitor(ctx context.Context, monName string) error {
-	resp, err := c.jConn.Call(ctx, "monitor_cancel", monName)
-	if err != nil {
-		return err
-	}
-	if err := resp.Error(); err != nil {
+	if _, err := c.call(ctx, "monitor_cancel", monName); err != nil {
 		return err
 	}
 
+	// forget the monitor, so it is not restored on reconnect
+	c.monMu.Lock()
+	if item, ok := c.monitors[monName]; ok {
+		delete(c.monitors, monName)
+		item.stop()
+	}
+	c.monMu.Unlock()
 	return nil
 }
@@ -29,10 +29,6 @@ func (c *Client) callMonitor(ctx context.Context, db string, monName string, mon
 		return nil, err
 	}
 
-	if err := resp.Error(); err != nil {
-		return nil, fmt.Errorf("monitor: %w", err)
-	}
-
 	upd, err := fromJSON(c, db, resp.GetResult())
 	if err != nil {
 		return nil, err
@@ -40,7 +36,7 @@ func (c *Client) callMonitor(ctx context.Context, db string, monName string, mon
 
 	return upd, nil
 }
-func (c *Client) SetMonitor(ctx context.Context, db string, monName string, monReqs monitor.MonReqSet) (monitor.TableSetUpdate, <-chan monitor.TableSetUpdate, error) {
+func (c *Client) SetMonitor(ctx context.Context, db string, monName string, monReqs monitor.MonReqSet, opts ...MonitorOpt) (monitor.TableSetUpdate, <-chan monitor.TableSetUpdate, error) {
 	c.monMu.Lock()
 	defer c.monMu.Unlock()
 
@@ -50,15 +46,9 @@ func (c *Client) SetMonitor(ctx context.Context, db string, monName string, monR
 	}
 
 	tuChan := make(chan monitor.TableSetUpdate, 10)
-	mon := monitorItem{
-		db:          db,
-		monName:     monName,
-		initialReqs: monReqs,
-		renewReqs:   nil,
-		updChan2:    nil,
-		updChan:     tuChan,
-	}
-	c.monitors[monName] = &mon
+	mon := c.newMonitorItem(db, monName, nil, tuChan, opts)
+	mon.initialReqs = monReqs
+	c.monitors[monName] = mon
 
 	return upd, tuChan, nil
 }
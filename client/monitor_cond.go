@@ -30,10 +30,6 @@ func (c *Client) callMonitorCond(ctx context.Context, db string, monName string,
 		return nil, err
 	}
 
-	if err := resp.Error(); err != nil {
-		return nil, fmt.Errorf("monitor_cond: %w", err)
-	}
-
 	upd2, err := u2FromJSON(c, db, resp.GetResult())
 	if err != nil {
 		return nil, err
@@ -41,7 +37,7 @@ func (c *Client) callMonitorCond(ctx context.Context, db string, monName string,
 
 	return upd2, nil
 }
-func (c *Client) SetMonitorCond(ctx context.Context, db string, monName string, monReqs monitor.MonCondReqSet) (monitor.TableSetUpdate2, <-chan monitor.TableSetUpdate2, error) {
+func (c *Client) SetMonitorCond(ctx context.Context, db string, monName string, monReqs monitor.MonCondReqSet, opts ...MonitorOpt) (monitor.TableSetUpdate2, <-chan monitor.TableSetUpdate2, error) {
 	c.monMu.Lock()
 	defer c.monMu.Unlock()
 
@@ -51,15 +47,9 @@ func (c *Client) SetMonitorCond(ctx context.Context, db string, monName string,
 	}
 
 	tuChan := make(chan monitor.TableSetUpdate2, 10)
-	mon := monitorItem{
-		db:          db,
-		monName:     monName,
-		initialReqs: monReqs,
-		renewReqs:   nil,
-		updChan2:    tuChan,
-		updChan:     nil,
-	}
-	c.monitors[monName] = &mon
+	mon := c.newMonitorItem(db, monName, tuChan, nil, opts)
+	mon.initialReqs = monReqs
+	c.monitors[monName] = mon
 
 	return upd2, tuChan, nil
 }
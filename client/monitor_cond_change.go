@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/monitor"
+)
+
+// ChangeMonitorCond changes the conditions of the monitor monName set up by SetMonitorCond
+// or SetMonitorCondSince (monitor_cond_change method). Only the conditions may change:
+// the server doesn't accept other columns, so newMonReqs must have the tables, columns
+// and select of the monitor, otherwise the error is returned without the call.
+// The rows entering or leaving the new conditions are delivered as an update
+// on the monitor channel. The new requests are used on monitor restore after reconnect.
+func (c *Client) ChangeMonitorCond(ctx context.Context, monName string, newMonReqs monitor.MonCondReqSet) error {
+	if err := newMonReqs.Validate(); err != nil {
+		return err
+	}
+
+	c.monMu.RLock()
+	item, ok := c.monitors[monName]
+	var cur monitor.GenericMonReqSet
+	if ok {
+		cur = item.initialReqs
+	}
+	c.monMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("monitor %q not found", monName)
+	}
+	if item.updChan2 == nil {
+		return fmt.Errorf("monitor %q is not conditional", monName)
+	}
+	if err := monitor.CheckChange(cur, newMonReqs); err != nil {
+		return fmt.Errorf("monitor %q: %w", monName, err)
+	}
+
+	if _, err := c.call(ctx, "monitor_cond_change", monName, monName, newMonReqs.ChangeRequests()); err != nil {
+		return err
+	}
+
+	c.monMu.Lock()
+	defer c.monMu.Unlock()
+	if c.monitors[monName] == item {
+		item.initialReqs = newMonReqs
+		if item.renewReqs != nil {
+			item.renewReqs = newMonReqs.WithoutInitial()
+		}
+	}
+	return nil
+}
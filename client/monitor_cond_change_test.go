@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"github.com/kazmanavt/ovsdb/v2/monitor"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestClient_ChangeMonitorCond(t *testing.T) {
+	e := newE2E(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	first := e.insert(t, 1)
+	second := e.insert(t, 2)
+
+	reqs := monitor.NewMonCondReqSet(e.sch).
+		Add("T", monitor.MonCondReq{Columns: []string{"x"}, Where: []types.Condition{types.Equal("x", 1)}})
+	initial, updates, err := e.c.SetMonitorCond(ctx, "Test", "mon", reqs)
+	require.NoError(t, err)
+	require.Len(t, initial["T"], 1)
+	require.Contains(t, initial["T"], string(first))
+
+	// the columns can't be changed, the monitor is kept as is
+	err = e.c.ChangeMonitorCond(ctx, "mon", monitor.NewMonCondReqSet(e.sch).Add("T", monitor.MonCondReq{}))
+	require.ErrorContains(t, err, "columns can't be changed")
+	err = e.c.ChangeMonitorCond(ctx, "none", reqs)
+	require.ErrorContains(t, err, "not found")
+
+	next := monitor.NewMonCondReqSet(e.sch).
+		Add("T", monitor.MonCondReq{Columns: []string{"x"}, Where: []types.Condition{types.Equal("x", 2)}})
+	require.NoError(t, e.c.ChangeMonitorCond(ctx, "mon", next))
+	select {
+	case upd := <-updates:
+		require.Len(t, upd["T"], 2)
+		assert.NotNil(t, upd["T"][string(first)].Delete)
+		require.NotNil(t, upd["T"][string(second)].Insert)
+		assert.Equal(t, 2, upd["T"][string(second)].Insert.Get("x"))
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "update is not received")
+	}
+
+	// the updates follow the new condition
+	e.insert(t, 1)
+	third := e.insert(t, 2)
+	select {
+	case upd := <-updates:
+		require.Len(t, upd["T"], 1)
+		assert.NotNil(t, upd["T"][string(third)].Insert)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "update is not received")
+	}
+
+	e.c.monMu.RLock()
+	assert.Same(t, next, e.c.monitors["mon"].initialReqs)
+	e.c.monMu.RUnlock()
+}
@@ -43,10 +43,6 @@ func (c *Client) callMonitorCondSince(ctx context.Context, db string, monName, l
 		return m3Resp{}, err
 	}
 
-	if err := resp.Error(); err != nil {
-		return m3Resp{}, fmt.Errorf("monitor_cond_since: %w", err)
-	}
-
 	var res m3Resp
 	err = res.fromJSON(c, db, resp.GetResult())
 	if err != nil {
@@ -56,7 +52,7 @@ func (c *Client) callMonitorCondSince(ctx context.Context, db string, monName, l
 	return res, nil
 }
 
-func (c *Client) SetMonitorCondSince(ctx context.Context, db string, monName string, monReqs monitor.MonCondReqSet) (monitor.TableSetUpdate2, <-chan monitor.TableSetUpdate2, error) {
+func (c *Client) SetMonitorCondSince(ctx context.Context, db string, monName string, monReqs monitor.MonCondReqSet, opts ...MonitorOpt) (monitor.TableSetUpdate2, <-chan monitor.TableSetUpdate2, error) {
 	c.monMu.Lock()
 	defer c.monMu.Unlock()
 
@@ -66,16 +62,11 @@ func (c *Client) SetMonitorCondSince(ctx context.Context, db string, monName str
 	}
 
 	tuChan := make(chan monitor.TableSetUpdate2, 10)
-	mon := monitorItem{
-		db:          db,
-		lastTxnId:   res.lastTxnID,
-		monName:     monName,
-		initialReqs: monReqs,
-		renewReqs:   monReqs.WithoutInitial(),
-		updChan2:    tuChan,
-		updChan:     nil,
-	}
-	c.monitors[monName] = &mon
+	mon := c.newMonitorItem(db, monName, tuChan, nil, opts)
+	mon.lastTxnId = res.lastTxnID
+	mon.initialReqs = monReqs
+	mon.renewReqs = monReqs.WithoutInitial()
+	c.monitors[monName] = mon
 
 	return res.update2, tuChan, nil
 }
@@ -0,0 +1,273 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	jrpc "github.com/kazmanavt/jsonrpc/v2/jrpc1"
+	"github.com/kazmanavt/ovsdb/v2/monitor"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// notification is a monitor update notification received from the server.
+type notification struct {
+	method string
+	params []json.RawMessage
+}
+
+// orderedConn is net.Conn passing the JSON-RPC stream to the jrpc connection, except
+// the monitor update notifications. The jrpc connection runs every notification
+// handler in its own goroutine, which does not keep the order of updates,
+// so the Client takes them from the stream and dispatches them in order.
+// orderedConn also sends the requests which id must be known to the Client, see send;
+// the responses to them are taken from the stream as well.
+type orderedConn struct {
+	net.Conn
+	r *io.PipeReader
+
+	// wMu serializes the writes of jrpc and of send, wDeadline is the write deadline set by jrpc
+	wMu       sync.Mutex
+	wDeadline time.Time
+
+	mu      sync.Mutex
+	seq     uint64
+	pending map[string]chan jrpc.Response
+	closed  bool
+}
+
+func (oc *orderedConn) Read(p []byte) (int, error) {
+	return oc.r.Read(p)
+}
+
+func (oc *orderedConn) Write(p []byte) (int, error) {
+	oc.wMu.Lock()
+	defer oc.wMu.Unlock()
+	return oc.write(oc.wDeadline, p)
+}
+
+// SetWriteDeadline keeps the deadline for the next Write, so it does not affect the requests of send.
+func (oc *orderedConn) SetWriteDeadline(t time.Time) error {
+	oc.wMu.Lock()
+	defer oc.wMu.Unlock()
+	oc.wDeadline = t
+	return nil
+}
+
+func (oc *orderedConn) write(deadline time.Time, p []byte) (int, error) {
+	if err := oc.Conn.SetWriteDeadline(deadline); err != nil {
+		return 0, err
+	}
+	return oc.Conn.Write(p)
+}
+
+// reqIDPrefix starts the ids of the requests sent by orderedConn, jrpc uses decimal numbers.
+const reqIDPrefix = "ovsdb-"
+
+// send sends the request of method with the id chosen by orderedConn and returns the id
+// with the channel receiving the response. The pending requests are failed with
+// the "connection closed" error when the stream is over, as jrpc does.
+func (oc *orderedConn) send(ctx context.Context, method string, params ...any) (string, <-chan jrpc.Response, error) {
+	if params == nil {
+		params = []any{}
+	}
+	oc.mu.Lock()
+	if oc.closed {
+		oc.mu.Unlock()
+		return "", nil, errors.New("connection closed")
+	}
+	oc.seq++
+	id := reqIDPrefix + strconv.FormatUint(oc.seq, 10)
+	respChan := make(chan jrpc.Response, 1)
+	oc.pending[id] = respChan
+	oc.mu.Unlock()
+
+	req, err := json.Marshal(struct {
+		ID     string `json:"id"`
+		Method string `json:"method"`
+		Params []any  `json:"params"`
+	}{id, method, params})
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err == nil {
+		deadline, _ := ctx.Deadline()
+		oc.wMu.Lock()
+		_, err = oc.write(deadline, req)
+		oc.wMu.Unlock()
+		if err != nil {
+			// the stream may be broken in the middle of the request
+			_ = oc.Conn.Close()
+		}
+	}
+	if err != nil {
+		oc.mu.Lock()
+		delete(oc.pending, id)
+		oc.mu.Unlock()
+		return "", nil, err
+	}
+	return id, respChan, nil
+}
+
+// resolve passes the response to the request of send, it returns false if id is not of such request.
+func (oc *orderedConn) resolve(id json.RawMessage, resp *response) bool {
+	var sid string
+	if json.Unmarshal(id, &sid) != nil || !strings.HasPrefix(sid, reqIDPrefix) {
+		return false
+	}
+	oc.mu.Lock()
+	respChan, ok := oc.pending[sid]
+	delete(oc.pending, sid)
+	oc.mu.Unlock()
+	if ok {
+		respChan <- resp
+	}
+	return true
+}
+
+// closePending fails the pending requests of send.
+func (oc *orderedConn) closePending() {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	oc.closed = true
+	for id, respChan := range oc.pending {
+		respChan <- &response{Err: connClosedErr}
+		delete(oc.pending, id)
+	}
+}
+
+// response is jrpc.Response to the request of orderedConn.send.
+type response struct {
+	Res json.RawMessage `json:"result"`
+	Err json.RawMessage `json:"error"`
+}
+
+func (r *response) GetErr() []byte {
+	return r.Err
+}
+
+func (r *response) GetResult() []byte {
+	return r.Res
+}
+
+func (r *response) Error() error {
+	if r.Err == nil || bytes.Equal(r.Err, []byte("null")) {
+		return nil
+	}
+	return errors.New(string(r.Err))
+}
+
+// orderUpdates wraps conn, so the monitor updates it delivers are dispatched in order.
+func (c *Client) orderUpdates(conn net.Conn) *orderedConn {
+	r, pw := io.Pipe()
+	oc := &orderedConn{Conn: conn, r: r, pending: make(map[string]chan jrpc.Response)}
+
+	stop := make(chan struct{})
+	out := make(chan notification)
+	q := newQueue(out, stop)
+	go func() {
+		defer close(stop)
+		for n := range out {
+			if n.method == "" {
+				// the stream is over
+				return
+			}
+			c.dispatchUpdate(n)
+		}
+	}()
+
+	go func() {
+		defer oc.closePending()
+		dec := json.NewDecoder(conn)
+		for {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				q.push(notification{})
+				_ = pw.CloseWithError(err)
+				return
+			}
+			var msg struct {
+				ID     json.RawMessage   `json:"id"`
+				Method string            `json:"method"`
+				Params []json.RawMessage `json:"params"`
+				response
+			}
+			if err := json.Unmarshal(raw, &msg); err == nil {
+				if isUpdateMethod(msg.Method) && (msg.ID == nil || bytes.Equal(msg.ID, []byte("null"))) {
+					q.push(notification{method: msg.Method, params: msg.Params})
+					continue
+				}
+				if msg.Method == "" && oc.resolve(msg.ID, &msg.response) {
+					continue
+				}
+			}
+			if _, err := pw.Write(append(raw, '\n')); err != nil {
+				q.push(notification{})
+				_ = conn.Close()
+				return
+			}
+		}
+	}()
+	return oc
+}
+
+func isUpdateMethod(method string) bool {
+	return method == "update" || method == "update2" || method == "update3"
+}
+
+func (c *Client) dispatchUpdate(n notification) {
+	if err := c._dispatchUpdate(n); err != nil {
+		c.log.Warn("fail to dispatch monitor update", slog.String("method", n.method), slog.String("error", err.Error()))
+	}
+}
+
+func (c *Client) _dispatchUpdate(n notification) error {
+	var monName string
+	if len(n.params) == 0 {
+		return fmt.Errorf("no params")
+	}
+	if err := json.Unmarshal(n.params[0], &monName); err != nil {
+		return fmt.Errorf("unmarshal monitor name: %w", err)
+	}
+	switch n.method {
+	case "update":
+		if len(n.params) != 2 {
+			return fmt.Errorf("wrong number of params: %d", len(n.params))
+		}
+		var upd monitor.RawTableSetUpdate
+		if err := json.Unmarshal(n.params[1], &upd); err != nil {
+			return fmt.Errorf("unmarshal update: %w", err)
+		}
+		c.updatesDispatcher()(monName, upd)
+	case "update2":
+		if len(n.params) != 2 {
+			return fmt.Errorf("wrong number of params: %d", len(n.params))
+		}
+		var upd monitor.RawTableSetUpdate2
+		if err := json.Unmarshal(n.params[1], &upd); err != nil {
+			return fmt.Errorf("unmarshal update2: %w", err)
+		}
+		c.updates2Dispatcher()(monName, upd)
+	case "update3":
+		if len(n.params) != 3 {
+			return fmt.Errorf("wrong number of params: %d", len(n.params))
+		}
+		var txnId string
+		if err := json.Unmarshal(n.params[1], &txnId); err != nil {
+			return fmt.Errorf("unmarshal last txn id: %w", err)
+		}
+		var upd monitor.RawTableSetUpdate2
+		if err := json.Unmarshal(n.params[2], &upd); err != nil {
+			return fmt.Errorf("unmarshal update3: %w", err)
+		}
+		c.updates3Dispatcher()(monName, txnId, upd)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+const defaultPort = "6640"
+
+// Remote is an address of the OVSDB server.
+type Remote struct {
+	Network string // "tcp", "ssl" or "unix"
+	Address string // "host:port" for tcp and ssl, socket path for unix
+}
+
+// String returns the remote in ovs-vsctl's --db format, e.g. "tcp:127.0.0.1:6640".
+func (r Remote) String() string {
+	return r.Network + ":" + r.Address
+}
+
+// ParseRemotes parses comma separated list of remotes in ovs-vsctl's --db format:
+//
+//	tcp:host[:port]
+//	ssl:host[:port]
+//	unix:path
+//
+// IPv6 host must be given in brackets, e.g. "tcp:[::1]:6641". Port defaults to 6640.
+func ParseRemotes(s string) ([]Remote, error) {
+	var res []Remote
+	for _, rs := range strings.Split(s, ",") {
+		rs = strings.TrimSpace(rs)
+		network, addr, ok := strings.Cut(rs, ":")
+		if !ok || addr == "" {
+			return nil, fmt.Errorf("invalid remote %q", rs)
+		}
+		switch network {
+		case "tcp", "ssl":
+			if _, _, err := net.SplitHostPort(addr); err != nil {
+				addr = net.JoinHostPort(strings.Trim(addr, "[]"), defaultPort)
+			}
+		case "unix":
+		default:
+			return nil, fmt.Errorf("remote %q: unsupported connection method %q", rs, network)
+		}
+		res = append(res, Remote{Network: network, Address: addr})
+	}
+	return res, nil
+}
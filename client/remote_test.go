@@ -0,0 +1,26 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseRemotes(t *testing.T) {
+	rs, err := ParseRemotes("tcp:10.0.0.1:6641, tcp:10.0.0.2,tcp:[::1]:6643,tcp:[fe80::1],ssl:ovn-nb.example.com,unix:/var/run/openvswitch/db.sock")
+	require.NoError(t, err)
+	assert.Equal(t, []Remote{
+		{Network: "tcp", Address: "10.0.0.1:6641"},
+		{Network: "tcp", Address: "10.0.0.2:6640"},
+		{Network: "tcp", Address: "[::1]:6643"},
+		{Network: "tcp", Address: "[fe80::1]:6640"},
+		{Network: "ssl", Address: "ovn-nb.example.com:6640"},
+		{Network: "unix", Address: "/var/run/openvswitch/db.sock"},
+	}, rs)
+	assert.Equal(t, "tcp:10.0.0.1:6641", rs[0].String())
+
+	for _, s := range []string{"", "tcp", "tcp:", "udp:1.1.1.1:6640", "10.0.0.1:6640"} {
+		_, err := ParseRemotes(s)
+		assert.Errorf(t, err, "remote %q should fail", s)
+	}
+}
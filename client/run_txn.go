@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/db"
+	"github.com/kazmanavt/ovsdb/v2/transact"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// TxnFunc builds the transaction: it reads the data from view and adds the operations to tr.
+type TxnFunc func(view db.DB, tr transact.Transaction) error
+
+var runTxnSeq atomic.Int64
+
+// RunTxn runs the read-modify-write transaction on the database of cache with optimistic
+// concurrency, like the transactions of OVSDB IDL. fn reads the data through the view
+// recording the rows and columns read (see db.View) and adds the write operations to tr.
+// The transaction sent to the server checks the data read before the operations of fn,
+// so it fails with "timed out" error if the data is changed in the meantime.
+// Then fn is called again once the cache receives an update, after the delay of the Client
+// backoff, until ctx ends. fn must not have side effects other than on tr.
+// On success the results of the operations of fn are available by tr.Results() of the last call.
+// cache must be kept in sync with the server, e.g. by Cache.
+func (c *Client) RunTxn(ctx context.Context, cache db.DB, fn TxnFunc) error {
+	sch := cache.Schema()
+	subId := fmt.Sprintf("_txn.%d", runTxnSeq.Add(1))
+	updated := cache.SubscribeUpdates(subId)
+	defer cache.UnsubscribeUpdates(subId)
+
+	var delay time.Duration
+	for attempt := 1; ; attempt++ {
+		// updates before the reads are seen by them
+		drain(updated)
+
+		view := db.NewView(cache)
+		ops := transact.NewTransaction(sch)
+		if err := fn(view, ops); err != nil {
+			return err
+		}
+		if ops.Len() == 0 {
+			return nil
+		}
+		tr := view.Verify(transact.NewTransaction(sch))
+		waits := tr.Len()
+		tr.Append(ops)
+
+		err := c.Transact(ctx, sch.Name, tr)
+		var opErr *transact.OpError
+		if err == nil || !errors.As(err, &opErr) || !errors.Is(opErr, transact.ErrTimedOut) || opErr.Index >= waits {
+			return err
+		}
+		c.log.Debug("transaction verification failed, retry",
+			slog.String("db", sch.Name), slog.Int("attempt", attempt), slog.Int("operation", opErr.Index))
+
+		// wait for the cache to catch up with the server
+		select {
+		case <-updated:
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		}
+		if delay == 0 {
+			delay = c.backoff.Initial
+		} else {
+			delay = c.backoff.next(delay)
+		}
+		select {
+		case <-time.After(c.backoff.jittered(delay)):
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		}
+	}
+}
+
+func drain(ch <-chan struct{}) {
+	for {
+		select {
+		case <-ch:
+		default:
+			return
+		}
+	}
+}
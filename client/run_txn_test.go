@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kazmanavt/ovsdb/v2/db"
+	"github.com/kazmanavt/ovsdb/v2/transact"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_RunTxn(t *testing.T) {
+	s := &monServer{}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := Dial(ctx, "pipe", "test", WithDialer(s.dial),
+		WithBackoff(Backoff{Initial: 10 * time.Millisecond, Max: 10 * time.Millisecond}))
+	require.NoError(t, err)
+	defer c.Close()
+
+	d, err := c.Cache(ctx, "Test", nil)
+	require.NoError(t, err)
+	var monName string
+	c.monMu.RLock()
+	for name := range c.monitors {
+		monName = name
+	}
+	c.monMu.RUnlock()
+
+	var written atomic.Int32
+	require.NoError(t, s.conn.Load().HandleCall("transact", func(dbName string, ops ...json.RawMessage) (json.RawMessage, error) {
+		var wait struct {
+			Op      string           `json:"op"`
+			Timeout *int             `json:"timeout"`
+			Columns []string         `json:"columns"`
+			Rows    []map[string]int `json:"rows"`
+		}
+		require.NoError(t, json.Unmarshal(ops[0], &wait))
+		require.Equal(t, "wait", wait.Op)
+		require.NotNil(t, wait.Timeout)
+		require.Equal(t, 0, *wait.Timeout)
+		require.Equal(t, []string{"x"}, wait.Columns)
+		if written.Load() == 0 {
+			// another client updates the row first
+			s.update(t, monName)
+			written.Add(1)
+		}
+		if wait.Rows[0]["x"] != int(s.x.Load()) {
+			return json.RawMessage(`[{"error": "timed out"}]`), nil
+		}
+		var upd struct {
+			Row map[string]int `json:"row"`
+		}
+		require.NoError(t, json.Unmarshal(ops[1], &upd))
+		written.Store(int32(upd.Row["x"]))
+		return json.RawMessage(`[{}, {"count": 1}]`), nil
+	}))
+
+	var calls int
+	err = c.RunTxn(ctx, d, func(view db.DB, tr transact.Transaction) error {
+		calls++
+		x := view.GetS("T", testRowUUID, "x").(int)
+		row := view.TableSchema("T").NewRow("x", x+10)
+		tr.Update([]types.Condition{types.Equal("_uuid", types.UUID(testRowUUID))}, row)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.EqualValues(t, 11, written.Load())
+}
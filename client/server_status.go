@@ -0,0 +1,233 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/monitor"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"log/slog"
+)
+
+const (
+	serverDb      = "_Server"
+	serverTable   = "Database"
+	serverMonName = "_Server.Database"
+)
+
+var (
+	// ErrNotLeader reports that the server is a follower of the database cluster.
+	ErrNotLeader = errors.New("server is not the cluster leader")
+	// ErrClusterDisconnected reports that the server lost connection to the database cluster.
+	ErrClusterDisconnected = errors.New("server is disconnected from the cluster")
+)
+
+// ServerStatus is the state of a database on the server the Client is connected to,
+// as reported by the Database table of _Server database.
+type ServerStatus struct {
+	Name      string     // database name
+	Model     string     // "standalone", "clustered" or "relay"
+	Connected bool       // true if the server is connected to the cluster (always true for standalone)
+	Leader    bool       // true if the server is the cluster leader (always true for standalone)
+	Index     int        // index of the last raft log entry seen by the server (clustered only)
+	Cid       types.UUID // cluster ID (clustered only)
+	Sid       types.UUID // raft server ID (clustered only)
+}
+
+// Check returns ErrClusterDisconnected or ErrNotLeader if the database on the server
+// is not able to accept writes on its own, nil otherwise.
+func (s ServerStatus) Check() error {
+	if !s.Connected {
+		return fmt.Errorf("db %q: %w", s.Name, ErrClusterDisconnected)
+	}
+	if !s.Leader {
+		return fmt.Errorf("db %q: %w", s.Name, ErrNotLeader)
+	}
+	return nil
+}
+
+// serverStatusFromRow decodes the row of Database table, it fails if the row lacks
+// the mandatory columns.
+func serverStatusFromRow(row schema.Row) (ServerStatus, error) {
+	var st ServerStatus
+	var ok bool
+	if st.Name, ok = row.Get("name").(string); !ok {
+		return ServerStatus{}, fmt.Errorf("server status: invalid name %v", row.Get("name"))
+	}
+	if st.Connected, ok = row.Get("connected").(bool); !ok {
+		return ServerStatus{}, fmt.Errorf("server status of db %q: invalid connected %v", st.Name, row.Get("connected"))
+	}
+	if st.Leader, ok = row.Get("leader").(bool); !ok {
+		return ServerStatus{}, fmt.Errorf("server status of db %q: invalid leader %v", st.Name, row.Get("leader"))
+	}
+	if v, ok := row.Get("model").(string); ok {
+		st.Model = v
+	} else if v, ok := row.Get("model").(types.Set[string]); ok && len(v) == 1 {
+		st.Model = v[0]
+	}
+	if v, ok := row.Get("index").(types.Set[int]); ok && len(v) == 1 {
+		st.Index = v[0]
+	}
+	if v, ok := row.Get("cid").(types.Set[types.UUID]); ok && len(v) == 1 {
+		st.Cid = v[0]
+	}
+	if v, ok := row.Get("sid").(types.Set[types.UUID]); ok && len(v) == 1 {
+		st.Sid = v[0]
+	}
+	return st, nil
+}
+
+// ServerStatus returns the status of the database db on the server the Client is connected to.
+// It fails if the server does not provide _Server database or does not serve db.
+func (c *Client) ServerStatus(db string) (ServerStatus, error) {
+	c.srvMu.RLock()
+	defer c.srvMu.RUnlock()
+	if c.srvRows == nil {
+		return ServerStatus{}, fmt.Errorf("server status not available")
+	}
+	for _, row := range c.srvRows {
+		// the rows of other dbs are not decoded, so the broken ones don't matter
+		if name, _ := row.Get("name").(string); name == db {
+			return serverStatusFromRow(row)
+		}
+	}
+	return ServerStatus{}, fmt.Errorf("db %q not found in server status", db)
+}
+
+// checkServerStatus returns the error of ServerStatus.Check for db if the status is known.
+func (c *Client) checkServerStatus(db string) error {
+	st, err := c.ServerStatus(db)
+	if err != nil {
+		return nil
+	}
+	return st.Check()
+}
+
+// checkLeader returns an error if the server is not the leader of any clustered database.
+func (c *Client) checkLeader() error {
+	c.srvMu.RLock()
+	defer c.srvMu.RUnlock()
+	for _, row := range c.srvRows {
+		st, err := serverStatusFromRow(row)
+		if err != nil {
+			c.log.Warn("server status skipped", slog.String("error", err.Error()))
+			continue
+		}
+		if st.Model != "clustered" {
+			continue
+		}
+		if err := st.Check(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watchServer sets up the built-in monitor of _Server database.
+func (c *Client) watchServer(ctx context.Context) error {
+	c.schemasMu.RLock()
+	sch, ok := c.schemas[serverDb]
+	c.schemasMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("db %s not found in schemas", serverDb)
+	}
+	monReqs := monitor.NewMonCondReqSet(sch).Add(serverTable, monitor.MonCondReq{
+		Columns: []string{"name", "model", "connected", "leader", "index", "cid", "sid"},
+	})
+
+	upd2, err := c.callMonitorCond(ctx, serverDb, serverMonName, monReqs)
+	if err != nil {
+		return err
+	}
+
+	c.srvMu.Lock()
+	c.srvRows = make(map[string]schema.Row)
+	c.srvMu.Unlock()
+	c.applyServerUpdate(upd2)
+	return nil
+}
+
+func (c *Client) serverUpdatesDispatcher(_upd monitor.RawTableSetUpdate2) {
+	c.schemasMu.RLock()
+	dSch, ok := c.schemas[serverDb]
+	c.schemasMu.RUnlock()
+	if !ok {
+		c.log.Warn("server updates dispatcher: db schema not found", slog.String("name", serverDb))
+		return
+	}
+	upd, err := monitor.TableSetUpdateFromRaw2(dSch, _upd)
+	if err != nil {
+		c.log.Warn("server updates dispatcher", slog.String("update error", err.Error()))
+		return
+	}
+	c.applyServerUpdate(upd)
+
+	if c.leaderOnly {
+		if err := c.checkLeader(); err != nil {
+			c.log.Warn("leadership lost, reconnecting", slog.String("error", err.Error()))
+			c.dropConn(c.conn(), err)
+		}
+	}
+}
+
+// applyServerUpdate updates the server status rows, EventServerStatus is emitted
+// for the dbs which status is changed.
+func (c *Client) applyServerUpdate(upd2 monitor.TableSetUpdate2) {
+	var events []Event
+	defer func() {
+		for _, ev := range events {
+			c.emitEvent(ev)
+		}
+	}()
+	c.srvMu.Lock()
+	defer c.srvMu.Unlock()
+	if c.srvRows == nil {
+		return
+	}
+	for uuid, ru := range upd2[serverTable] {
+		var prevErr error
+		if row, ok := c.srvRows[uuid]; ok {
+			prevErr = checkServerRow(row)
+		}
+		switch {
+		case ru.Initial != nil:
+			c.srvRows[uuid] = ru.Initial
+		case ru.Insert != nil:
+			c.srvRows[uuid] = ru.Insert
+		case ru.Delete != nil:
+			delete(c.srvRows, uuid)
+			continue
+		case ru.Modify != nil:
+			row, ok := c.srvRows[uuid]
+			if !ok {
+				continue
+			}
+			if err := row.Update2(ru.Modify); err != nil {
+				c.log.Warn("server status update", slog.String("error", err.Error()))
+				continue
+			}
+		}
+		st, err := serverStatusFromRow(c.srvRows[uuid])
+		if err != nil {
+			c.log.Warn("server status update", slog.String("error", err.Error()))
+			continue
+		}
+		if err := st.Check(); err != nil && prevErr == nil {
+			c.log.Warn("server status changed", slog.String("db", st.Name), slog.String("status", err.Error()))
+			events = append(events, Event{Type: EventServerStatus, DB: st.Name, Err: err})
+		} else if err == nil && prevErr != nil {
+			c.log.Info("server status changed", slog.String("db", st.Name), slog.String("status", "ok"))
+			events = append(events, Event{Type: EventServerStatus, DB: st.Name})
+		}
+	}
+}
+
+// checkServerRow returns the error of ServerStatus.Check of the row or the decode error.
+func checkServerRow(row schema.Row) error {
+	st, err := serverStatusFromRow(row)
+	if err != nil {
+		return err
+	}
+	return st.Check()
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestClient_GetServerID(t *testing.T) {
+	e := newE2E(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	id, err := e.c.GetServerID(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, e.srv.ID(), id)
+}
+
+func TestClient_ServerStatus(t *testing.T) {
+	cl := newCluster(t, false)
+	c := cl.dial(t)
+	st, err := c.ServerStatus("Test")
+	require.NoError(t, err)
+	assert.Equal(t, ServerStatus{Name: "Test", Model: "clustered", Connected: true}, st)
+	assert.ErrorIs(t, st.Check(), ErrNotLeader)
+
+	cl.setLeader(t, 0, true)
+	require.Eventually(t, func() bool {
+		st, err := c.ServerStatus("Test")
+		return err == nil && st.Leader
+	}, 5*time.Second, time.Millisecond)
+
+	_, err = c.ServerStatus("none")
+	assert.ErrorContains(t, err, "not found")
+
+	// the server without _Server db
+	e := newE2E(t)
+	_, err = e.c.ServerStatus("Test")
+	assert.ErrorContains(t, err, "not available")
+}
+
+func TestClient_ServerStatusBadRow(t *testing.T) {
+	cl := newCluster(t, true)
+	c := cl.dial(t)
+
+	// the row of other db, which columns are of unexpected types
+	var other schema.DbSchema
+	require.NoError(t, json.Unmarshal(serverSchema, &other))
+	var col schema.ColumnSchema
+	require.NoError(t, json.Unmarshal([]byte(`{"type": "integer"}`), &col))
+	other.Tables["Database"].Columns["leader"] = &col
+	c.srvMu.Lock()
+	c.srvRows["bad"] = other.Tables["Database"].NewRow("name", "Other")
+	c.srvMu.Unlock()
+
+	st, err := c.ServerStatus("Test")
+	require.NoError(t, err)
+	assert.True(t, st.Leader)
+	_, err = c.ServerStatus("Other")
+	assert.ErrorContains(t, err, "invalid leader")
+	assert.NoError(t, c.checkLeader())
+}
+
+func TestClient_ServerStatusEvent(t *testing.T) {
+	cl := newCluster(t, true)
+	c := cl.dial(t)
+
+	cl.setLeader(t, 0, false)
+	ev := nextEventOf(t, c, EventServerStatus)
+	assert.Equal(t, "Test", ev.DB)
+	assert.ErrorIs(t, ev.Err, ErrNotLeader)
+
+	cl.setLeader(t, 0, true)
+	ev = nextEventOf(t, c, EventServerStatus)
+	assert.Equal(t, "Test", ev.DB)
+	assert.NoError(t, ev.Err)
+}
+
+func TestServerStatusFromRow(t *testing.T) {
+	var sch schema.DbSchema
+	require.NoError(t, json.Unmarshal(serverSchema, &sch))
+	tSch := sch.Tables["Database"]
+
+	st, err := serverStatusFromRow(tSch.NewRow("name", "Test", "model", "standalone",
+		"connected", true, "leader", true))
+	require.NoError(t, err)
+	assert.Equal(t, ServerStatus{Name: "Test", Model: "standalone", Connected: true, Leader: true}, st)
+
+	// the columns of unexpected types, e.g. of the other schema
+	for _, cName := range []string{"name", "connected", "leader"} {
+		var other schema.DbSchema
+		require.NoError(t, json.Unmarshal(serverSchema, &other))
+		var col schema.ColumnSchema
+		require.NoError(t, json.Unmarshal([]byte(`{"type": "integer"}`), &col))
+		other.Tables["Database"].Columns[cName] = &col
+		_, err = serverStatusFromRow(other.Tables["Database"].NewRow())
+		assert.ErrorContains(t, err, "invalid "+cName)
+	}
+}
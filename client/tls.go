@@ -0,0 +1,129 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// errCABootstrapped fails the handshake after the CA certificate of the peer is saved,
+// the next connection attempt verifies the peer with the saved certificate.
+var errCABootstrapped = errors.New("CA certificate bootstrapped")
+
+// tlsOpts is the configuration of ssl: remotes.
+type tlsOpts struct {
+	certFile, keyFile string
+	caFile            string
+	bootstrapCA       bool
+	peerName          string
+}
+
+// tlsConfig builds TLS configuration for ssl: remotes. It is called on every
+// connection attempt, so renewed certificates are picked up on reconnect.
+// Like OVS, the peer certificate is verified against CA certificate only,
+// the host name is checked only if the peer name is configured.
+func (o *tlsOpts) tlsConfig() (*tls.Config, error) {
+	if o.certFile == "" || o.keyFile == "" {
+		return nil, fmt.Errorf("ssl: private key and certificate are required")
+	}
+	cert, err := tls.LoadX509KeyPair(o.certFile, o.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("ssl: load certificate: %w", err)
+	}
+	if o.caFile == "" {
+		return nil, fmt.Errorf("ssl: CA certificate is required")
+	}
+
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		// the chain is verified in VerifyPeerCertificate, host name is optional
+		InsecureSkipVerify: true,
+	}
+
+	caPEM, err := os.ReadFile(o.caFile)
+	switch {
+	case err == nil:
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("ssl: no CA certificates in %s", o.caFile)
+		}
+		cfg.VerifyPeerCertificate = o.verifyPeer(pool)
+	case errors.Is(err, os.ErrNotExist) && o.bootstrapCA:
+		cfg.VerifyPeerCertificate = o.bootstrapPeerCA
+	default:
+		return nil, fmt.Errorf("ssl: read CA certificate: %w", err)
+	}
+	return cfg, nil
+}
+
+func (o *tlsOpts) verifyPeer(pool *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		certs, err := parseCerts(rawCerts)
+		if err != nil {
+			return err
+		}
+		vOpts := x509.VerifyOptions{
+			Roots:         pool,
+			Intermediates: x509.NewCertPool(),
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}
+		for _, cert := range certs[1:] {
+			vOpts.Intermediates.AddCert(cert)
+		}
+		if _, err := certs[0].Verify(vOpts); err != nil {
+			return fmt.Errorf("ssl: verify peer certificate: %w", err)
+		}
+		if o.peerName != "" {
+			if err := certs[0].VerifyHostname(o.peerName); err != nil {
+				return fmt.Errorf("ssl: verify peer name: %w", err)
+			}
+		}
+		return nil
+	}
+}
+
+// bootstrapPeerCA saves the self-signed CA certificate at the top of peer's chain
+// to the CA file, as OVS does with --bootstrap-ca-cert.
+func (o *tlsOpts) bootstrapPeerCA(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	certs, err := parseCerts(rawCerts)
+	if err != nil {
+		return err
+	}
+	ca := certs[len(certs)-1]
+	if !ca.IsCA || ca.CheckSignatureFrom(ca) != nil {
+		return fmt.Errorf("ssl: bootstrap CA: peer did not send self-signed CA certificate")
+	}
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw})
+	f, err := os.OpenFile(o.caFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("ssl: bootstrap CA: %w", err)
+	}
+	if _, err := f.Write(caPEM); err != nil {
+		_ = f.Close()
+		_ = os.Remove(o.caFile)
+		return fmt.Errorf("ssl: bootstrap CA: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("ssl: bootstrap CA: %w", err)
+	}
+	return errCABootstrapped
+}
+
+func parseCerts(rawCerts [][]byte) ([]*x509.Certificate, error) {
+	if len(rawCerts) == 0 {
+		return nil, fmt.Errorf("ssl: no peer certificate")
+	}
+	certs := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return nil, fmt.Errorf("ssl: parse peer certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	jrpc "github.com/kazmanavt/jsonrpc/v2/jrpc1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCert(t *testing.T, cn string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+	} else {
+		tmpl.DNSNames = []string{cn}
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key}
+}
+
+func (tc *testCert) writeFiles(t *testing.T, dir, name string) (certFile, keyFile string) {
+	certFile = filepath.Join(dir, name+"-cert.pem")
+	keyFile = filepath.Join(dir, name+"-privkey.pem")
+	keyDer, err := x509.MarshalECPrivateKey(tc.key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tc.cert.Raw}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	return certFile, keyFile
+}
+
+// startTLSServer starts minimal JSON-RPC server over TLS, it requires the client certificate signed by ca.
+func startTLSServer(t *testing.T, ca, srv *testCert) string {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{srv.cert.Raw, ca.cert.Raw},
+			PrivateKey:  srv.key,
+		}},
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  pool,
+	})
+	require.NoError(t, err)
+
+	s := jrpc.NewServerConnection(l, nil)
+	t.Cleanup(func() { _ = s.Close() })
+	require.NoError(t, s.HandleCall("list_dbs", func() ([]string, error) {
+		return []string{}, nil
+	}))
+	require.NoError(t, s.HandleCall("set_db_change_aware", func(bool) (struct{}, error) {
+		return struct{}{}, nil
+	}))
+	require.NoError(t, s.HandleCall("echo", func(args ...string) ([]string, error) {
+		return args, nil
+	}))
+	return l.Addr().String()
+}
+
+func TestClient_TLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "test CA", nil)
+	srv := newTestCert(t, "ovsdb.test", ca)
+	cli := newTestCert(t, "client", ca)
+	caFile, _ := ca.writeFiles(t, dir, "ca")
+	certFile, keyFile := cli.writeFiles(t, dir, "client")
+	addr := startTLSServer(t, ca, srv)
+	r := Remote{Network: "ssl", Address: addr}
+
+	dial := func(opts ...ClientOpt) error {
+		c := &Client{}
+		for _, opt := range opts {
+			opt(c)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		conn, err := c.dial(ctx, r)
+		if err == nil {
+			_ = conn.Close()
+		}
+		return err
+	}
+
+	t.Run("client", func(t *testing.T) {
+		c := NewClient("ssl", addr, WithTLSCertificate(certFile, keyFile), WithTLSCACert(caFile))
+		defer c.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		require.NoError(t, c.Echo(ctx))
+	})
+
+	t.Run("peer name", func(t *testing.T) {
+		assert.NoError(t, dial(WithTLSCertificate(certFile, keyFile), WithTLSCACert(caFile), WithTLSPeerName("ovsdb.test")))
+		assert.Error(t, dial(WithTLSCertificate(certFile, keyFile), WithTLSCACert(caFile), WithTLSPeerName("other.test")))
+	})
+
+	t.Run("wrong CA", func(t *testing.T) {
+		other := newTestCert(t, "other CA", nil)
+		otherFile, _ := other.writeFiles(t, dir, "other")
+		assert.Error(t, dial(WithTLSCertificate(certFile, keyFile), WithTLSCACert(otherFile)))
+	})
+
+	t.Run("no client certificate", func(t *testing.T) {
+		assert.Error(t, dial(WithTLSCACert(caFile)))
+	})
+
+	t.Run("bootstrap CA", func(t *testing.T) {
+		bootstrapFile := filepath.Join(dir, "bootstrap-cacert.pem")
+		opts := []ClientOpt{WithTLSCertificate(certFile, keyFile), WithTLSBootstrapCA(bootstrapFile)}
+		err := dial(opts...)
+		require.ErrorIs(t, err, errCABootstrapped)
+		saved, err := os.ReadFile(bootstrapFile)
+		require.NoError(t, err)
+		block, _ := pem.Decode(saved)
+		require.NotNil(t, block)
+		assert.Equal(t, ca.cert.Raw, block.Bytes)
+		assert.NoError(t, dial(opts...), "second connection should be verified by bootstrapped CA")
+	})
+}
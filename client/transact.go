@@ -2,23 +2,63 @@ package client
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	jrpc "github.com/kazmanavt/jsonrpc/v2/jrpc1"
 	"github.com/kazmanavt/ovsdb/v2/transact"
 )
 
+// Transact executes the transaction tr on db (RFC 7047 4.1.3). On success the results
+// of the operations are available by tr.Results(), e.g. real UUIDs of the rows inserted by InsertRef.
+// The transaction asserting the lock not held by the Client fails early with the error
+// matching transact.ErrNotOwner. If an operation fails, the error wraps *transact.OpError,
+// matching the transact.Err* sentinels by errors.Is. Connection and JSON-RPC level errors
+// are described by callConn. If ctx ends before the response, the server is asked
+// to cancel the transaction, see TransactAsync.
 func (c *Client) Transact(ctx context.Context, db string, tr transact.Transaction) error {
-	if err := tr.Validate(); err != nil {
+	f, err := c.TransactAsync(ctx, db, tr)
+	if err != nil {
 		return err
 	}
-	args := []any{db}
-	for _, op := range tr.Operations() {
-		args = append(args, op)
-	}
-	resp, err := c.jConn.Call(ctx, "transact", args...)
+	return f.Wait()
+}
+
+// transactResult decodes the response of the transaction tr into tr.
+func (c *Client) transactResult(db string, tr transact.Transaction, resp jrpc.Response, err error) error {
 	if err != nil {
+		var rErr *RPCError
+		if errors.As(err, &rErr) {
+			if stErr := c.checkServerStatus(db); stErr != nil {
+				return fmt.Errorf("%w: %w", stErr, err)
+			}
+		}
 		return err
 	}
 	if err := tr.DecodeResult(resp.GetResult()); err != nil {
 		return err
 	}
-	return tr.Error()
+	if err := tr.Error(); err != nil {
+		// give a hint if the server can't serve writes for db
+		if stErr := c.checkServerStatus(db); stErr != nil {
+			return fmt.Errorf("%w: %w", stErr, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// checkAssertedLocks fails if the Client does not hold any of the locks asserted by tr.
+func (c *Client) checkAssertedLocks(tr transact.Transaction) error {
+	c.locksMu.RLock()
+	defer c.locksMu.RUnlock()
+	for _, id := range tr.AssertedLocks() {
+		l, ok := c.locks[id]
+		if !ok {
+			return fmt.Errorf("assert lock %q: lock not requested: %w", id, transact.ErrNotOwner)
+		}
+		if st := l.State(); st != LockAcquired {
+			return fmt.Errorf("assert lock %q: lock %s: %w", id, st, transact.ErrNotOwner)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	jrpc "github.com/kazmanavt/jsonrpc/v2/jrpc1"
+	"github.com/kazmanavt/ovsdb/v2/transact"
+	"log/slog"
+	"time"
+)
+
+// TransactFuture is the transaction started by TransactAsync.
+type TransactFuture struct {
+	id    string
+	jConn jrpc.Connection
+	done  chan struct{}
+	err   error
+}
+
+// ID returns the JSON-RPC request id of the transaction.
+func (f *TransactFuture) ID() string {
+	return f.id
+}
+
+// Done returns the channel closed when the transaction is finished.
+func (f *TransactFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait waits for the transaction to finish and returns its error, see Transact.
+func (f *TransactFuture) Wait() error {
+	<-f.done
+	return f.err
+}
+
+// Cancel asks the server to cancel the transaction (RFC 7047 4.1.4). The server responds
+// to the canceled transaction with an error, so Wait returns the error matching ErrCanceled,
+// unless the transaction is already committed. Cancel of the finished transaction is a no-op.
+func (f *TransactFuture) Cancel() error {
+	select {
+	case <-f.done:
+		return nil
+	default:
+	}
+	return f.cancel()
+}
+
+func (f *TransactFuture) cancel() error {
+	return notifyCancel(context.Background(), f.jConn, f.id)
+}
+
+// cancelResponseTimeout bounds the wait for the response to the transaction canceled
+// on the end of its context.
+const cancelResponseTimeout = time.Second
+
+// TransactAsync sends the transaction tr on db and returns without waiting for the response.
+// The checks and the errors are the same as of Transact. ctx bounds the whole transaction:
+// if it ends before the response, the server is asked to cancel the transaction and
+// the response is awaited for a while (cancelResponseTimeout). If the transaction is committed
+// meanwhile, its result is given as usual. Otherwise the future is finished with the error
+// matching ErrCanceled or ErrCallTimeout; it matches the *RPCError "canceled" as well if the server
+// confirms the cancel, without it the transaction may still be applied.
+// tr must not be used until the future is finished.
+func (c *Client) TransactAsync(ctx context.Context, db string, tr transact.Transaction) (*TransactFuture, error) {
+	if err := tr.Validate(); err != nil {
+		return nil, err
+	}
+	if err := c.checkAssertedLocks(tr); err != nil {
+		return nil, err
+	}
+	args := []any{db}
+	for _, op := range tr.Operations() {
+		args = append(args, op)
+	}
+	jConn, id, respChan, err := c.sendTransact(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &TransactFuture{id: id, jConn: jConn, done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		resp, err := awaitResponse(ctx, "transact", respChan)
+		if err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+			if cErr := f.cancel(); cErr != nil {
+				c.log.Debug("fail to cancel transaction", slog.String("id", id), slog.String("error", cErr.Error()))
+			} else {
+				resp, err = awaitCanceled(respChan, err)
+			}
+		}
+		f.err = c.transactResult(db, tr, resp, err)
+	}()
+	return f, nil
+}
+
+// awaitCanceled waits for the response to the transaction canceled on ctxErr, the response
+// is returned if the transaction is committed before the cancel. The "canceled" error of the server
+// is joined to ctxErr, ctxErr is returned alone if the response doesn't come in time.
+func awaitCanceled(respChan <-chan jrpc.Response, ctxErr error) (jrpc.Response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cancelResponseTimeout)
+	defer cancel()
+	resp, err := awaitResponse(ctx, "transact", respChan)
+	var rErr *RPCError
+	switch {
+	case err == nil:
+		return resp, nil
+	case errors.Is(err, ErrCanceled) && errors.As(err, &rErr):
+		return nil, fmt.Errorf("%w: %w", ctxErr, rErr)
+	case errors.As(err, &rErr):
+		return nil, err
+	}
+	return nil, ctxErr
+}
+
+// sendTransact sends the transact request and returns the connection it is sent over
+// with the request id. jrpc does not expose the ids of its requests, so the request
+// is sent with the id chosen by the Client, see orderedConn.send.
+func (c *Client) sendTransact(ctx context.Context, args ...any) (jrpc.Connection, string, <-chan jrpc.Response, error) {
+	c.lock.RLock()
+	jConn, oConn := c.jConn, c.oConn
+	c.lock.RUnlock()
+	if jConn == nil {
+		return nil, "", nil, fmt.Errorf("transact: %w", ErrNotConnected)
+	}
+	select {
+	case <-jConn.Done():
+		return nil, "", nil, fmt.Errorf("transact: %w", ErrNotConnected)
+	default:
+	}
+	id, respChan, err := oConn.send(ctx, "transact", args...)
+	if err != nil {
+		if ctxErr := ctxError(ctx); ctxErr != nil {
+			return nil, "", nil, fmt.Errorf("transact: %w: %w", ctxErr, err)
+		}
+		return nil, "", nil, fmt.Errorf("transact: %w: %w", ErrNotConnected, err)
+	}
+	return jConn, id, respChan, nil
+}
+
+// CancelTransact asks the server to cancel the transaction with the request id (RFC 7047 4.1.4),
+// see TransactFuture.ID.
+func (c *Client) CancelTransact(ctx context.Context, id string) error {
+	return notifyCancel(ctx, c.conn(), id)
+}
+
+func notifyCancel(ctx context.Context, jConn jrpc.Connection, id string) error {
+	if jConn == nil {
+		return fmt.Errorf("cancel: %w", ErrNotConnected)
+	}
+	select {
+	case <-jConn.Done():
+		return fmt.Errorf("cancel: %w", ErrNotConnected)
+	default:
+	}
+	if err := jConn.Notify(ctx, "cancel", id); err != nil {
+		return fmt.Errorf("cancel: %w: %w", ErrNotConnected, err)
+	}
+	return nil
+}
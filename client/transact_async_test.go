@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/transact"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// waitTxn returns the transaction blocked by the server until the row with x is inserted.
+func (e *e2e) waitTxn(x int) transact.Transaction {
+	return transact.NewTransaction(e.sch).
+		Wait("T", []types.Condition{types.Equal("x", x)}, []string{"x"}, "!=", []schema.Row{}, 0)
+}
+
+// watchCancels passes the ids of the transactions canceled to the channel.
+func (e *e2e) watchCancels() <-chan string {
+	canceled := make(chan string, 10)
+	e.srv.SetHook(func(method string, params []json.RawMessage) error {
+		var id string
+		if method == "cancel" && len(params) > 0 && json.Unmarshal(params[0], &id) == nil {
+			canceled <- id
+		}
+		return nil
+	})
+	return canceled
+}
+
+func TestClient_TransactAsync(t *testing.T) {
+	e := newE2E(t)
+	canceled := e.watchCancels()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// not blocked
+	f, err := e.c.TransactAsync(ctx, "Test", transact.NewTransaction(e.sch).Select("T", nil, nil))
+	require.NoError(t, err)
+	assert.NotEmpty(t, f.ID())
+	require.NoError(t, f.Wait())
+	assert.NoError(t, f.Cancel())
+
+	// finished by the change
+	f, err = e.c.TransactAsync(ctx, "Test", e.waitTxn(1))
+	require.NoError(t, err)
+	select {
+	case <-f.Done():
+		t.Fatal("the transaction is not blocked")
+	case <-time.After(50 * time.Millisecond):
+	}
+	e.insert(t, 1)
+	require.NoError(t, f.Wait())
+
+	// canceled by the future
+	f1, err := e.c.TransactAsync(ctx, "Test", e.waitTxn(2))
+	require.NoError(t, err)
+	f2, err := e.c.TransactAsync(ctx, "Test", e.waitTxn(3))
+	require.NoError(t, err)
+	assert.NotEqual(t, f1.ID(), f2.ID())
+	require.NoError(t, f2.Cancel())
+	assert.Equal(t, f2.ID(), <-canceled)
+	assert.ErrorIs(t, f2.Wait(), ErrCanceled)
+	select {
+	case <-f1.Done():
+		t.Fatal("the other transaction is finished")
+	default:
+	}
+
+	// canceled by the context
+	tCtx, tCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer tCancel()
+	err = e.c.Transact(tCtx, "Test", e.waitTxn(4))
+	assert.ErrorIs(t, err, ErrCallTimeout)
+	// the cancel is confirmed by the server
+	var rErr *RPCError
+	assert.ErrorAs(t, err, &rErr)
+	select {
+	case id := <-canceled:
+		assert.NotEqual(t, f1.ID(), id)
+	case <-ctx.Done():
+		t.Fatal("cancel is not sent")
+	}
+
+	require.NoError(t, e.c.CancelTransact(ctx, f1.ID()))
+	assert.Equal(t, f1.ID(), <-canceled)
+	assert.ErrorIs(t, f1.Wait(), ErrCanceled)
+}
+
+func TestClient_TransactCanceledResponse(t *testing.T) {
+	e := newE2E(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// committed after the context ends, the result is given
+	e.srv.SetHook(func(method string, _ []json.RawMessage) error {
+		if method == "transact" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		return nil
+	})
+	tCtx, tCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer tCancel()
+	tr := transact.NewTransaction(e.sch).Insert(e.sch.Tables["T"].NewRow("x", 1))
+	require.NoError(t, e.c.Transact(tCtx, "Test", tr))
+	_, err := tr.Results().Inserted(0)
+	assert.NoError(t, err)
+
+	// the cancel is lost, the outcome is unknown
+	e.srv.SetHook(func(method string, _ []json.RawMessage) error {
+		if method == "cancel" {
+			return errors.New("dropped")
+		}
+		return nil
+	})
+	tCtx, tCancel = context.WithTimeout(ctx, 50*time.Millisecond)
+	defer tCancel()
+	start := time.Now()
+	err = e.c.Transact(tCtx, "Test", e.waitTxn(2))
+	assert.ErrorIs(t, err, ErrCallTimeout)
+	var rErr *RPCError
+	assert.False(t, errors.As(err, &rErr))
+	assert.GreaterOrEqual(t, time.Since(start), cancelResponseTimeout)
+}
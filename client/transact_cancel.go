@@ -1,8 +0,0 @@
-package client
-
-import "context"
-
-func (c *Client) CancelTransact(ctx context.Context, id string) error {
-	err := c.jConn.Notify(ctx, "cancel", id)
-	return err
-}
@@ -0,0 +1,466 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"go/format"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// kinds of the columns
+const (
+	kindScalar   = "scalar"   // exactly one value
+	kindOptional = "optional" // set of 0 or 1 value, the field is pointer
+	kindSet      = "set"
+	kindMap      = "map"
+)
+
+type model struct {
+	Package  string
+	Database string
+	Source   string
+	Tables   []*table
+}
+
+type table struct {
+	Name    string
+	GoName  string
+	Columns []*column
+	Enums   []*enum
+}
+
+type column struct {
+	Name      string
+	GoName    string
+	Const     string
+	Kind      string
+	Type      string // type of the field
+	Tag       string // tag of the field for schema.TableSchema.RowFromStruct
+	Key       string // base type of the value, of the element of set or of the key of map
+	Value     string // base type of the value of map
+	Synthetic bool   // _uuid and _version are not written to the row
+}
+
+type enum struct {
+	Type   string
+	Base   string
+	Column string
+	Values []enumValue
+}
+
+type enumValue struct {
+	Name    string
+	Literal string
+}
+
+var baseTypes = map[string]string{
+	"integer": "int",
+	"real":    "float64",
+	"string":  "string",
+	"boolean": "bool",
+	"uuid":    "types.UUID",
+}
+
+// initialisms are the words of the names written in upper case in Go identifiers
+var initialisms = map[string]string{
+	"acl": "ACL", "acls": "ACLs", "api": "API", "arp": "ARP", "bfd": "BFD", "cfm": "CFM", "cpu": "CPU",
+	"ct": "CT", "dhcp": "DHCP", "dns": "DNS", "http": "HTTP", "icmp": "ICMP", "id": "ID", "ids": "IDs",
+	"ip": "IP", "ips": "IPs", "ipfix": "IPFIX", "ipv4": "IPv4", "ipv6": "IPv6", "lacp": "LACP",
+	"mac": "MAC", "macs": "MACs", "mtu": "MTU", "nat": "NAT", "qos": "QoS", "rstp": "RSTP", "ssl": "SSL",
+	"stp": "STP", "tcp": "TCP", "tls": "TLS", "udp": "UDP", "url": "URL", "uuid": "UUID", "vlan": "VLAN",
+	"vlans": "VLANs",
+}
+
+// goName returns the exported Go identifier for the name of the schema, e.g. "external_ids" -> "ExternalIDs".
+func goName(name string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if s, ok := initialisms[strings.ToLower(word)]; ok {
+			b.WriteString(s)
+			continue
+		}
+		r := []rune(word)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	if b.Len() == 0 {
+		return "Empty"
+	}
+	return b.String()
+}
+
+// generate returns the source of the package pkg holding the models of the tables of sch.
+// src is the name of the schema file mentioned in the header of the file.
+func generate(sch *schema.DbSchema, pkg, src string) ([]byte, error) {
+	m := model{Package: pkg, Database: sch.Name, Source: src}
+	tNames := make([]string, 0, len(sch.Tables))
+	for tName := range sch.Tables {
+		tNames = append(tNames, tName)
+	}
+	slices.Sort(tNames)
+	for _, tName := range tNames {
+		t, err := newTable(sch.Tables[tName])
+		if err != nil {
+			return nil, fmt.Errorf("table %q: %w", tName, err)
+		}
+		m.Tables = append(m.Tables, t)
+	}
+
+	var buf bytes.Buffer
+	if err := modelTemplate.Execute(&buf, m); err != nil {
+		return nil, err
+	}
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w", err)
+	}
+	return code, nil
+}
+
+func newTable(tSch *schema.TableSchema) (*table, error) {
+	t := &table{Name: tSch.Name, GoName: goName(tSch.Name)}
+	cNames := make([]string, 0, len(tSch.Columns))
+	for cName := range tSch.Columns {
+		if cName != "_uuid" && cName != "_version" {
+			cNames = append(cNames, cName)
+		}
+	}
+	slices.Sort(cNames)
+	cNames = append([]string{"_uuid", "_version"}, cNames...)
+
+	for _, cName := range cNames {
+		cSch, ok := tSch.Columns[cName]
+		if !ok {
+			continue
+		}
+		c, err := t.newColumn(cSch)
+		if err != nil {
+			return nil, fmt.Errorf("column %q: %w", cName, err)
+		}
+		t.Columns = append(t.Columns, c)
+	}
+	return t, nil
+}
+
+func (t *table) newColumn(cSch *schema.ColumnSchema) (*column, error) {
+	c := &column{
+		Name:      cSch.Name,
+		GoName:    goName(cSch.Name),
+		Synthetic: cSch.Name == "_uuid" || cSch.Name == "_version",
+	}
+	c.Const = t.GoName + "Column" + c.GoName
+	c.Tag = fmt.Sprintf("`ovsdb:%q`", cSch.Name)
+	cType := cSch.Type
+	kind := cType.GetKind()
+	switch {
+	case strings.HasPrefix(kind, "Map["):
+		c.Kind = kindMap
+	case strings.HasPrefix(kind, "Set[") && *cType.Min == 0 && *cType.Max.(*int) == 1:
+		c.Kind = kindOptional
+	case strings.HasPrefix(kind, "Set["):
+		c.Kind = kindSet
+	case kind != "":
+		c.Kind = kindScalar
+	default:
+		return nil, fmt.Errorf("unsupported type, min %d max %v", *cType.Min, cType.Max)
+	}
+
+	var ok bool
+	if c.Key, ok = baseTypes[cType.Key.Type]; !ok {
+		return nil, fmt.Errorf("unknown type %q", cType.Key.Type)
+	}
+	key, err := t.newEnum(c, &cType.Key, c.Kind == kindMap, "Key")
+	if err != nil {
+		return nil, err
+	}
+	switch c.Kind {
+	case kindScalar:
+		c.Type = key
+	case kindOptional:
+		c.Type = "*" + key
+	case kindSet:
+		if key == c.Key {
+			c.Type = "types.Set[" + key + "]"
+		} else {
+			c.Type = "[]" + key
+		}
+	case kindMap:
+		if c.Value, ok = baseTypes[cType.Value.Type]; !ok {
+			return nil, fmt.Errorf("unknown type %q", cType.Value.Type)
+		}
+		value, err := t.newEnum(c, cType.Value, true, "Value")
+		if err != nil {
+			return nil, err
+		}
+		if key == c.Key && value == c.Value {
+			c.Type = "types.Map[" + key + ", " + value + "]"
+		} else {
+			c.Type = "map[" + key + "]" + value
+		}
+	}
+	return c, nil
+}
+
+// newEnum adds the type for the enum of base type bt of the column c and returns its name,
+// or returns the Go type of bt if there is no enum. The suffix is added to the name of the type
+// of the map column.
+func (t *table) newEnum(c *column, bt *schema.BaseType, isMap bool, suffix string) (string, error) {
+	base := baseTypes[bt.Type]
+	if len(bt.Enum) == 0 || bt.Type == "boolean" || bt.Type == "uuid" {
+		return base, nil
+	}
+	e := &enum{Type: t.GoName + c.GoName, Base: base, Column: c.Name}
+	if isMap {
+		e.Type += suffix
+	}
+	seen := make(map[string]bool)
+	for _, v := range bt.Enum {
+		var name, literal string
+		switch v := v.(type) {
+		case string:
+			name, literal = goName(v), strconv.Quote(v)
+		case float64:
+			if bt.Type == "integer" {
+				literal = strconv.Itoa(int(v))
+			} else {
+				literal = strconv.FormatFloat(v, 'g', -1, 64)
+			}
+			name = strings.NewReplacer("-", "Minus", ".", "_", "+", "").Replace(literal)
+		default:
+			return "", fmt.Errorf("invalid enum value %v", v)
+		}
+		name = e.Type + name
+		for i, base := 2, name; seen[name]; i++ {
+			name = fmt.Sprintf("%s_%d", base, i)
+		}
+		seen[name] = true
+		e.Values = append(e.Values, enumValue{Name: name, Literal: literal})
+	}
+	slices.SortFunc(e.Values, func(a, b enumValue) int { return strings.Compare(a.Name, b.Name) })
+	t.Enums = append(t.Enums, e)
+	return e.Type, nil
+}
+
+var modelTemplate = template.Must(template.New("model").Parse(`// Code generated by ovsdb-modelgen from {{ .Source }}. DO NOT EDIT.
+
+// Package {{ .Package }} holds the models of the tables of the {{ .Database }} database.
+package {{ .Package }}
+
+import (
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"reflect"
+)
+
+// DatabaseName is the name of the database of the models.
+const DatabaseName = "{{ .Database }}"
+{{ range $t := .Tables }}
+// {{ $t.GoName }}Table is the name of the {{ $t.Name }} table.
+const {{ $t.GoName }}Table = "{{ $t.Name }}"
+
+// columns of the {{ $t.Name }} table
+const (
+{{- range $t.Columns }}
+	{{ .Const }} = "{{ .Name }}"
+{{- end }}
+)
+{{ range $e := $t.Enums }}
+// {{ $e.Type }} is the enum of the {{ $e.Column }} column of the {{ $t.Name }} table.
+type {{ $e.Type }} {{ $e.Base }}
+
+const (
+{{- range $e.Values }}
+	{{ .Name }} {{ $e.Type }} = {{ .Literal }}
+{{- end }}
+)
+{{ end }}
+// {{ $t.GoName }} is the row of the {{ $t.Name }} table.
+type {{ $t.GoName }} struct {
+{{- range $t.Columns }}
+	{{ .GoName }} {{ .Type }} {{ .Tag }}
+{{- end }}
+}
+
+// TableName returns the name of the {{ $t.Name }} table.
+func ({{ $t.GoName }}) TableName() string {
+	return {{ $t.GoName }}Table
+}
+
+// ToRow returns the row of the {{ $t.Name }} table of sch holding the values of the columns given,
+// or of all the columns but _uuid and _version if none is given.
+func (m *{{ $t.GoName }}) ToRow(sch *schema.DbSchema, columns ...string) (schema.Row, error) {
+	values := map[string]any{
+{{- range $t.Columns }}{{ if not .Synthetic }}
+	{{- if eq .Kind "scalar" }}
+		{{ .Const }}: {{ if eq .Type .Key }}m.{{ .GoName }}{{ else }}{{ .Key }}(m.{{ .GoName }}){{ end }},
+	{{- else if eq .Kind "optional" }}
+		{{ .Const }}: optionalSet[{{ .Key }}](m.{{ .GoName }}),
+	{{- else if eq .Kind "set" }}
+		{{ .Const }}: toSet[{{ .Key }}](m.{{ .GoName }}),
+	{{- else }}
+		{{ .Const }}: toMap[{{ .Key }}, {{ .Value }}](m.{{ .GoName }}),
+	{{- end }}
+{{- end }}{{ end }}
+	}
+	return toRow(sch, {{ $t.GoName }}Table, values, columns)
+}
+
+// FromRow sets the fields of m to the values of the row of the {{ $t.Name }} table,
+// the fields of the columns not set in the row are reset.
+func (m *{{ $t.GoName }}) FromRow(row schema.Row) error {
+	if row.TableName() != {{ $t.GoName }}Table {
+		return fmt.Errorf("row of table %q, expect %q", row.TableName(), {{ $t.GoName }}Table)
+	}
+	var err error
+{{- range $t.Columns }}
+	{{- if eq .Kind "optional" }}
+	if m.{{ .GoName }}, err = getOptional[{{ slice .Type 1 }}](row, {{ .Const }}); err != nil {
+	{{- else if eq .Kind "set" }}
+	if m.{{ .GoName }}, err = getSlice[{{ .Type }}](row, {{ .Const }}); err != nil {
+	{{- else if eq .Kind "map" }}
+	if m.{{ .GoName }}, err = getMap[{{ .Type }}](row, {{ .Const }}); err != nil {
+	{{- else }}
+	if m.{{ .GoName }}, err = get[{{ .Type }}](row, {{ .Const }}); err != nil {
+	{{- end }}
+		return err
+	}
+{{- end }}
+	return nil
+}
+{{ end }}
+func toRow(sch *schema.DbSchema, tName string, values map[string]any, columns []string) (schema.Row, error) {
+	tSch, ok := sch.Tables[tName]
+	if !ok {
+		return nil, fmt.Errorf("table %q is not in schema", tName)
+	}
+	if len(columns) == 0 {
+		for cName := range values {
+			if _, ok := tSch.Columns[cName]; ok {
+				columns = append(columns, cName)
+			}
+		}
+	}
+	row := tSch.NewRow()
+	for _, cName := range columns {
+		cSch, ok := tSch.Columns[cName]
+		if !ok {
+			return nil, fmt.Errorf("table %q: column %q is not in schema", tName, cName)
+		}
+		value, ok := values[cName]
+		if !ok {
+			return nil, fmt.Errorf("table %q: column %q can not be set", tName, cName)
+		}
+		if err := cSch.ValidateValue(value); err != nil {
+			return nil, fmt.Errorf("table %q: column %q: %w", tName, cName, err)
+		}
+		row.Set(cName, value)
+	}
+	return row, nil
+}
+
+func optionalSet[T types.AtomicType, E any](p *E) types.Set[T] {
+	if p == nil {
+		return types.Set[T]{}
+	}
+	return types.Set[T]{convert[T](*p)}
+}
+
+func toSet[T types.AtomicType](s any) types.Set[T] {
+	rs := reflect.ValueOf(s)
+	set := make(types.Set[T], 0, rs.Len())
+	for i := 0; i < rs.Len(); i++ {
+		set = append(set, convert[T](rs.Index(i).Interface()))
+	}
+	return set
+}
+
+func toMap[K, V types.AtomicType](m any) types.Map[K, V] {
+	rm := reflect.ValueOf(m)
+	res := make(types.Map[K, V], rm.Len())
+	for it := rm.MapRange(); it.Next(); {
+		res[convert[K](it.Key().Interface())] = convert[V](it.Value().Interface())
+	}
+	return res
+}
+
+func convert[T any](v any) T {
+	if t, ok := v.(T); ok {
+		return t
+	}
+	return reflect.ValueOf(v).Convert(reflect.TypeFor[T]()).Interface().(T)
+}
+
+func value(row schema.Row, cName string, kind reflect.Kind) (reflect.Value, error) {
+	v, ok := row.GetE(cName)
+	if !ok || v == nil {
+		return reflect.Value{}, nil
+	}
+	rv := reflect.ValueOf(v)
+	if kind != reflect.Invalid && rv.Kind() != kind {
+		return reflect.Value{}, fmt.Errorf("table %q: column %q: unexpected type %T", row.TableName(), cName, v)
+	}
+	return rv, nil
+}
+
+func get[T any](row schema.Row, cName string) (T, error) {
+	var t T
+	rv, err := value(row, cName, reflect.Invalid)
+	if err != nil || !rv.IsValid() {
+		return t, err
+	}
+	if !rv.CanConvert(reflect.TypeFor[T]()) {
+		return t, fmt.Errorf("table %q: column %q: unexpected type %s", row.TableName(), cName, rv.Type())
+	}
+	return rv.Convert(reflect.TypeFor[T]()).Interface().(T), nil
+}
+
+func getOptional[T any](row schema.Row, cName string) (*T, error) {
+	rv, err := value(row, cName, reflect.Slice)
+	if err != nil || !rv.IsValid() || rv.Len() == 0 {
+		return nil, err
+	}
+	if !rv.Index(0).CanConvert(reflect.TypeFor[T]()) {
+		return nil, fmt.Errorf("table %q: column %q: unexpected type %s", row.TableName(), cName, rv.Type())
+	}
+	t := rv.Index(0).Convert(reflect.TypeFor[T]()).Interface().(T)
+	return &t, nil
+}
+
+func getSlice[S ~[]E, E any](row schema.Row, cName string) (S, error) {
+	rv, err := value(row, cName, reflect.Slice)
+	if err != nil || !rv.IsValid() {
+		return nil, err
+	}
+	s := make(S, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		if !rv.Index(i).CanConvert(reflect.TypeFor[E]()) {
+			return nil, fmt.Errorf("table %q: column %q: unexpected type %s", row.TableName(), cName, rv.Type())
+		}
+		s = append(s, rv.Index(i).Convert(reflect.TypeFor[E]()).Interface().(E))
+	}
+	return s, nil
+}
+
+func getMap[M ~map[K]V, K comparable, V any](row schema.Row, cName string) (M, error) {
+	rv, err := value(row, cName, reflect.Map)
+	if err != nil || !rv.IsValid() {
+		return nil, err
+	}
+	m := make(M, rv.Len())
+	for it := rv.MapRange(); it.Next(); {
+		if !it.Key().CanConvert(reflect.TypeFor[K]()) || !it.Value().CanConvert(reflect.TypeFor[V]()) {
+			return nil, fmt.Errorf("table %q: column %q: unexpected type %s", row.TableName(), cName, rv.Type())
+		}
+		m[it.Key().Convert(reflect.TypeFor[K]()).Interface().(K)] = it.Value().Convert(reflect.TypeFor[V]()).Interface().(V)
+	}
+	return m, nil
+}
+`))
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGoName(t *testing.T) {
+	for name, want := range map[string]string{
+		"external_ids":      "ExternalIDs",
+		"_uuid":             "UUID",
+		"Open_vSwitch":      "OpenVSwitch",
+		"CT_Timeout_Policy": "CTTimeoutPolicy",
+		"OpenFlow10":        "OpenFlow10",
+		"native-tagged":     "NativeTagged",
+		"flood_vlans":       "FloodVLANs",
+		"":                  "Empty",
+	} {
+		assert.Equal(t, want, goName(name), name)
+	}
+}
+
+func loadSchema(t *testing.T, path string) *schema.DbSchema {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var sch schema.DbSchema
+	require.NoError(t, json.Unmarshal(data, &sch))
+	return &sch
+}
+
+// TestGenerate checks the generated package internal/testmodel is up to date,
+// its behaviour is tested there.
+func TestGenerate(t *testing.T) {
+	code, err := generate(loadSchema(t, "testdata/test.json"), "testmodel", "test.json")
+	require.NoError(t, err)
+	want, err := os.ReadFile(filepath.Join("internal", "testmodel", "model.go"))
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(code), "run go generate ./cmd/ovsdb-modelgen/...")
+}
+
+func TestGenerate_OpenVSwitch(t *testing.T) {
+	code, err := generate(loadSchema(t, "../../db/testdata/Open_vSwitch.json"), "vswitch", "Open_vSwitch.json")
+	require.NoError(t, err)
+	for _, s := range []string{
+		`type Bridge struct {`,
+		`\tExternalIDs +types\.Map\[string, string\] +` + "`" + `ovsdb:"external_ids"` + "`",
+		`\tFailMode +\*BridgeFailMode +` + "`" + `ovsdb:"fail_mode"` + "`",
+		`\tProtocols +\[\]BridgeProtocols +`,
+		`BridgeProtocolsOpenFlow13 +BridgeProtocols = "OpenFlow13"`,
+		`\tTimeouts +map\[CTTimeoutPolicyTimeoutsKey\]int +`,
+		`OpenVSwitchColumnBridges`,
+	} {
+		assert.Regexp(t, s, string(code))
+	}
+}
@@ -0,0 +1,3 @@
+package testmodel
+
+//go:generate go run ../.. -p testmodel -o model.go ../../testdata/test.json
@@ -0,0 +1,102 @@
+package testmodel
+
+import (
+	"encoding/json"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+func testSchema(t *testing.T) *schema.DbSchema {
+	data, err := os.ReadFile("../../testdata/test.json")
+	require.NoError(t, err)
+	var sch schema.DbSchema
+	require.NoError(t, json.Unmarshal(data, &sch))
+	return &sch
+}
+
+func TestBridge_RoundTrip(t *testing.T) {
+	sch := testSchema(t)
+	mode, dpid := BridgeFailModeSecure, "0000aabbccddeeff"
+	b := Bridge{
+		Name:        "br0",
+		FailMode:    &mode,
+		DatapathID:  &dpid,
+		Protocols:   []BridgeProtocols{BridgeProtocolsOpenFlow10, BridgeProtocolsOpenFlow13},
+		Ports:       types.Set[types.UUID]{"1b5e2f5e-0c4b-4f62-9d8e-2f0f4c7f8a01"},
+		FloodVLANs:  types.Set[int]{10, 20},
+		STPEnable:   true,
+		ExternalIDs: types.Map[string, string]{"k": "v"},
+		Timeouts:    map[BridgeTimeoutsKey]int{BridgeTimeoutsKeyUDPFirst: 30},
+	}
+	row, err := b.ToRow(sch)
+	require.NoError(t, err)
+	assert.Equal(t, BridgeTable, row.TableName())
+	assert.Equal(t, types.Set[string]{"secure"}, row.Get(BridgeColumnFailMode))
+	assert.Equal(t, types.Set[string]{"OpenFlow10", "OpenFlow13"}, row.Get(BridgeColumnProtocols))
+	assert.Equal(t, types.Map[string, int]{"udp_first": 30}, row.Get(BridgeColumnTimeouts))
+	_, ok := row.GetE(BridgeColumnUUID)
+	assert.False(t, ok)
+
+	// the row goes over the wire
+	data, err := row.MarshalJSON()
+	require.NoError(t, err)
+	wire := sch.Tables[BridgeTable].NewRow()
+	require.NoError(t, wire.UnmarshalJSON(data))
+	wire.Set(BridgeColumnUUID, types.UUID("2c6f3a6f-1d5c-4a73-8e9f-3a1a5d8a9b02"))
+
+	var got Bridge
+	require.NoError(t, got.FromRow(wire))
+	b.UUID = "2c6f3a6f-1d5c-4a73-8e9f-3a1a5d8a9b02"
+	assert.Equal(t, b, got)
+
+	// the tags of the fields give the same mapping
+	tagged, err := sch.RowFromModel(&b)
+	require.NoError(t, err)
+	taggedData, err := tagged.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, string(data), string(taggedData))
+	var decoded Bridge
+	require.NoError(t, wire.Decode(&decoded))
+	assert.Equal(t, b, decoded)
+
+	// the empty optional and the absent column
+	got.FailMode = nil
+	row, err = got.ToRow(sch, BridgeColumnFailMode)
+	require.NoError(t, err)
+	assert.Equal(t, 1, row.Len())
+	assert.Equal(t, types.Set[string]{}, row.Get(BridgeColumnFailMode))
+	require.NoError(t, got.FromRow(row))
+	assert.Nil(t, got.FailMode)
+	assert.Empty(t, got.Name)
+}
+
+func TestBridge_Errors(t *testing.T) {
+	sch := testSchema(t)
+	mode := BridgeFailMode("bogus")
+	b := Bridge{FailMode: &mode}
+	_, err := b.ToRow(sch)
+	assert.Error(t, err, "value out of enum")
+	_, err = b.ToRow(sch, BridgeColumnUUID)
+	assert.Error(t, err, "_uuid is set")
+	_, err = b.ToRow(sch, "bogus")
+	assert.Error(t, err, "unknown column")
+
+	var p Port
+	assert.Error(t, p.FromRow(sch.Tables[BridgeTable].NewRow()), "row of another table")
+}
+
+func TestPort(t *testing.T) {
+	sch := testSchema(t)
+	row := sch.Tables[PortTable].NewRow()
+	require.NoError(t, row.UnmarshalJSON([]byte(`{"name": "p0", "tag": 10, "mode": "native-tagged", "weight": 0.5}`)))
+	var p Port
+	require.NoError(t, p.FromRow(row))
+	require.NotNil(t, p.Tag)
+	assert.Equal(t, 10, *p.Tag)
+	assert.Equal(t, PortModeNativeTagged, p.Mode)
+	assert.Equal(t, 0.5, p.Weight)
+}
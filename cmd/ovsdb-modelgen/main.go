@@ -0,0 +1,62 @@
+// Command ovsdb-modelgen generates the Go models of the tables of the OVSDB schema: one struct
+// per table with the fields of the columns typed by types.Set, types.Map and types.UUID,
+// the types and constants of the enums, the constants of the column names and the conversion
+// of the struct to and from schema.Row. The fields are tagged for schema.TableSchema.RowFromStruct,
+// so the models may be given to transact.Transaction and db.Lookup directly.
+//
+// Usage:
+//
+//	ovsdb-modelgen [-p package] [-o output.go] schema.ovsschema
+//
+// The models of one schema are generated into one file, use a package per schema.
+// The file may be generated by go generate, e.g.:
+//
+//	//go:generate go run github.com/kazmanavt/ovsdb/v2/cmd/ovsdb-modelgen -p vswitch -o model.go vswitch.ovsschema
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	pkg := flag.String("p", "model", "name of the package generated")
+	out := flag.String("o", "", "output file, stdout if empty")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-p package] [-o output.go] schema.ovsschema\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Arg(0), *pkg, *out); err != nil {
+		fmt.Fprintf(os.Stderr, "ovsdb-modelgen: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(path, pkg, out string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var sch schema.DbSchema
+	if err := json.Unmarshal(data, &sch); err != nil {
+		return fmt.Errorf("schema %s: %w", path, err)
+	}
+	code, err := generate(&sch, pkg, filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(out, code, 0o644)
+}
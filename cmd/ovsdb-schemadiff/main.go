@@ -0,0 +1,73 @@
+// Command ovsdb-schemadiff reports the changes of the OVSDB schema between two versions
+// (see schema.Diff) classified as backward-compatible or breaking for the clients of the old one.
+//
+// Usage:
+//
+//	ovsdb-schemadiff [-json] [-breaking] old.ovsschema new.ovsschema
+//
+// The exit status is 1 if there are breaking changes, 2 on errors.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"os"
+)
+
+func main() {
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	onlyBreaking := flag.Bool("breaking", false, "report the breaking changes only")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-json] [-breaking] old.ovsschema new.ovsschema\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	from, err := load(flag.Arg(0))
+	if err != nil {
+		fail(err)
+	}
+	to, err := load(flag.Arg(1))
+	if err != nil {
+		fail(err)
+	}
+	d := schema.Diff(from, to)
+	if *onlyBreaking {
+		d.Changes = d.Breaking()
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(d); err != nil {
+			fail(err)
+		}
+	} else {
+		fmt.Print(d)
+	}
+	if len(d.Breaking()) > 0 {
+		os.Exit(1)
+	}
+}
+
+func load(path string) (*schema.DbSchema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var sch schema.DbSchema
+	if err := json.Unmarshal(data, &sch); err != nil {
+		return nil, fmt.Errorf("schema %s: %w", path, err)
+	}
+	return &sch, nil
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "ovsdb-schemadiff: %s\n", err)
+	os.Exit(2)
+}
@@ -43,9 +43,20 @@ type DB interface {
 	// it returns an empty list if no rows match the conditions.
 	FindRecord(tName string, wheres ...[]types.Condition) []string
 
+	// Snapshot returns the copies of the rows of all tables (table name -> row UUID -> row)
+	// taken at once, so they are consistent even if the database is updated meanwhile.
+	Snapshot() map[string]map[string]schema.Row
+
 	// Update2 applies the updates2 received as result of monitor_cond or monitor_cond to current database.
 	Update2(upd2 monitor.RawTableSetUpdate2) error
 
+	// ApplyUpdate2 applies the parsed updates2 as delivered by the client monitors.
+	// If the update is a resync snapshot (see monitor.ResyncTable), the database is cleared first.
+	ApplyUpdate2(upd2 monitor.TableSetUpdate2) error
+
+	// Clear removes all rows from the database.
+	Clear()
+
 	SubscribeUpdates(uId string) <-chan struct{}
 	UnsubscribeUpdates(uId string)
 
@@ -88,6 +99,21 @@ func NewDB(sch *schema.DbSchema) DB {
 	}
 }
 
+// Reset drops the content of d and switches it to the schema sch, e.g. after the db
+// is converted on the server. d must be created by NewDB.
+func Reset(d DB, sch *schema.DbSchema) error {
+	di, ok := d.(*dbImpl)
+	if !ok {
+		return fmt.Errorf("reset %T: not created by NewDB", d)
+	}
+	fresh := NewDB(sch).(*dbImpl)
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	defer di.notify()
+	di.name, di.sch, di.tNames, di.tables = fresh.name, fresh.sch, fresh.tNames, fresh.tables
+	return nil
+}
+
 func (d *dbImpl) RLock() {
 	d.mu.RLock()
 }
@@ -186,20 +212,40 @@ func (d *dbImpl) FindRecord(tName string, wheres ...[]types.Condition) []string
 	return []string{}
 }
 
+func (d *dbImpl) Snapshot() map[string]map[string]schema.Row {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	snap := make(map[string]map[string]schema.Row, len(d.tables))
+	for tName, t := range d.tables {
+		snap[tName] = t.snapshot()
+	}
+	return snap
+}
+
 func (d *dbImpl) Update2(upd2 monitor.RawTableSetUpdate2) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	defer func() {
-		for _, ch := range d.updated {
-			select {
-			case ch <- struct{}{}:
-			default:
+	defer d.notify()
+	for tName, tUpd2 := range upd2 {
+		if t, ok := d.tables[tName]; ok {
+			if err := t.update2(tUpd2); err != nil {
+				return err
 			}
 		}
-	}()
+	}
+	return nil
+}
+
+func (d *dbImpl) ApplyUpdate2(upd2 monitor.TableSetUpdate2) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	defer d.notify()
+	if upd2.IsResync() {
+		d.clear()
+	}
 	for tName, tUpd2 := range upd2 {
 		if t, ok := d.tables[tName]; ok {
-			if err := t.update2(tUpd2); err != nil {
+			if err := t.apply2(tUpd2); err != nil {
 				return err
 			}
 		}
@@ -207,6 +253,31 @@ func (d *dbImpl) Update2(upd2 monitor.RawTableSetUpdate2) error {
 	return nil
 }
 
+func (d *dbImpl) Clear() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	defer d.notify()
+	d.clear()
+}
+
+func (d *dbImpl) clear() {
+	for _, t := range d.tables {
+		t.mu.Lock()
+		t.rows = make(map[string]schema.Row)
+		t.mu.Unlock()
+	}
+}
+
+// notify wakes up the subscribers, d.mu must be held.
+func (d *dbImpl) notify() {
+	for _, ch := range d.updated {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
 func (d *dbImpl) SubscribeUpdates(uId string) <-chan struct{} {
 	d.mu.Lock()
 	defer d.mu.Unlock()
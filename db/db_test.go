@@ -199,3 +199,82 @@ func Test_dbImpl_Update2(t *testing.T) {
 	})
 
 }
+
+func Test_dbImpl_ApplyUpdate2(t *testing.T) {
+	var dSch schema.DbSchema
+	err := json.Unmarshal(ovsSchema, &dSch)
+	require.NoError(t, err, "failed to unmarshal schema")
+
+	var ini, upd1 monitor.RawTableSetUpdate2
+	require.NoError(t, json.Unmarshal(initialC, &ini))
+	require.NoError(t, json.Unmarshal(updatesC1, &upd1))
+	pIni, err := monitor.TableSetUpdateFromRaw2(&dSch, ini)
+	require.NoError(t, err)
+	pUpd1, err := monitor.TableSetUpdateFromRaw2(&dSch, upd1)
+	require.NoError(t, err)
+
+	// parsed updates give the same result as raw ones
+	raw, parsed := NewDB(&dSch), NewDB(&dSch)
+	require.NoError(t, raw.Update2(ini))
+	require.NoError(t, raw.Update2(upd1))
+	require.NoError(t, parsed.ApplyUpdate2(pIni))
+	require.NoError(t, parsed.ApplyUpdate2(pUpd1))
+	uuid := "165f8f88-f073-41bc-8301-864050532dab"
+	require.Equal(t, raw.GetS("Bridge", uuid, "external_ids"), parsed.GetS("Bridge", uuid, "external_ids"))
+	require.Equal(t, raw.TableLen("Bridge"), parsed.TableLen("Bridge"))
+
+	// resync snapshot replaces the content
+	resync := monitor.TableSetUpdate2{monitor.ResyncTable: monitor.TableUpdate2{}}
+	require.NoError(t, parsed.ApplyUpdate2(resync))
+	require.Zero(t, parsed.TableLen("Bridge"))
+
+	require.NoError(t, parsed.ApplyUpdate2(pIni))
+	require.NotZero(t, parsed.TableLen("Bridge"))
+	parsed.Clear()
+	require.Zero(t, parsed.TableLen("Bridge"))
+}
+
+func TestReset(t *testing.T) {
+	var dSch schema.DbSchema
+	require.NoError(t, json.Unmarshal(ovsSchema, &dSch))
+	d := NewDB(&dSch)
+	require.NoError(t, d.ApplyUpdate2(monitor.TableSetUpdate2{"Bridge": monitor.TableUpdate2{
+		"165f8f88-f073-41bc-8301-864050532dab": {Insert: dSch.Tables["Bridge"].NewRow("name", "br0")},
+	}}))
+
+	var next schema.DbSchema
+	require.NoError(t, json.Unmarshal(ovsSchema, &next))
+	delete(next.Tables, "Port")
+	updated := d.SubscribeUpdates("test")
+	require.NoError(t, Reset(d, &next))
+	require.Same(t, &next, d.Schema())
+	require.Zero(t, d.TableLen("Bridge"))
+	require.NotContains(t, d.Schema().Tables, "Port")
+	require.Len(t, updated, 1)
+
+	require.Error(t, Reset(struct{ DB }{d}, &next))
+}
+
+func TestSnapshot(t *testing.T) {
+	var dSch schema.DbSchema
+	require.NoError(t, json.Unmarshal(ovsSchema, &dSch))
+	d := NewDB(&dSch)
+	uuid := "165f8f88-f073-41bc-8301-864050532dab"
+	require.NoError(t, d.ApplyUpdate2(monitor.TableSetUpdate2{"Bridge": monitor.TableUpdate2{
+		uuid: {Insert: dSch.Tables["Bridge"].NewRow("name", "br0", "external_ids", types.Map[string, string]{"a": "1"})},
+	}}))
+
+	snap := d.Snapshot()
+	require.Contains(t, snap, "Port")
+	require.Len(t, snap["Bridge"], 1)
+	row := snap["Bridge"][uuid]
+	require.Equal(t, "br0", row.Get("name"))
+
+	// the snapshot is not changed with the database
+	require.NoError(t, d.ApplyUpdate2(monitor.TableSetUpdate2{"Bridge": monitor.TableUpdate2{
+		uuid: {Modify: dSch.Tables["Bridge"].NewRow("name", "br1", "external_ids", types.Map[string, string]{"b": "2"})},
+	}}))
+	require.Equal(t, "br1", d.GetS("Bridge", uuid, "name"))
+	require.Equal(t, "br0", row.Get("name"))
+	require.Equal(t, types.Map[string, string]{"a": "1"}, row.Get("external_ids"))
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"reflect"
+)
+
+// ErrNotFound is returned by Lookup if the table has no row of the UUID.
+var ErrNotFound = errors.New("row not found")
+
+// Lookup decodes the row of the UUID in the table of the model m into m as schema.Row.Decode does.
+// Lookup and Find read d through its methods, so the reads of View are recorded.
+func Lookup(d DB, uuid types.UUID, m schema.Model) error {
+	row := d.TableRow(m.TableName(), uuid)
+	if row == nil {
+		return fmt.Errorf("table %q: %s: %w", m.TableName(), uuid, ErrNotFound)
+	}
+	return decode(row, uuid, m)
+}
+
+// decode decodes the row into v, the _uuid column is not kept in the row, so it is decoded aside.
+func decode(row schema.Row, uuid types.UUID, v any) error {
+	if err := row.Decode(v); err != nil {
+		return err
+	}
+	return row.TableSchema().NewRow("_uuid", uuid).Decode(v)
+}
+
+// Find decodes the rows of the table matching any of wheres, or all the rows if none is given,
+// into the slice pointed to by ms. The elements of the slice are the structs implementing
+// schema.Model or the pointers to them.
+func Find(d DB, ms any, wheres ...[]types.Condition) error {
+	rv := reflect.ValueOf(ms)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("find into %T, expect pointer to slice", ms)
+	}
+	slice := rv.Elem()
+	eType := slice.Type().Elem()
+	isPtr := eType.Kind() == reflect.Pointer
+	if isPtr {
+		eType = eType.Elem()
+	}
+	m, ok := reflect.New(eType).Interface().(schema.Model)
+	if !ok {
+		return fmt.Errorf("find into %T: %s is not schema.Model", ms, eType)
+	}
+	tName := m.TableName()
+
+	if len(wheres) == 0 {
+		wheres = [][]types.Condition{nil}
+	}
+	seen := make(map[string]bool)
+	res := reflect.MakeSlice(slice.Type(), 0, 0)
+	for _, uuid := range d.FindRecord(tName, wheres...) {
+		if seen[uuid] {
+			continue
+		}
+		seen[uuid] = true
+		row := d.TableRowS(tName, uuid)
+		if row == nil {
+			continue
+		}
+		e := reflect.New(eType)
+		if err := decode(row, types.UUID(uuid), e.Interface()); err != nil {
+			return err
+		}
+		if !isPtr {
+			e = e.Elem()
+		}
+		res = reflect.Append(res, e)
+	}
+	slice.Set(res)
+	return nil
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"encoding/json"
+	"github.com/kazmanavt/ovsdb/v2/monitor"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/transact"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"slices"
+	"testing"
+)
+
+type bridge struct {
+	UUID  types.UUID   `ovsdb:"_uuid"`
+	Name  string       `ovsdb:"name"`
+	Ports []types.UUID `ovsdb:"ports"`
+}
+
+func (bridge) TableName() string {
+	return "Bridge"
+}
+
+func TestLookupFind(t *testing.T) {
+	var dSch schema.DbSchema
+	require.NoError(t, json.Unmarshal(ovsSchema, &dSch))
+	var ini monitor.RawTableSetUpdate2
+	require.NoError(t, json.Unmarshal(initialC, &ini))
+	d := NewDB(&dSch)
+	require.NoError(t, d.Update2(ini))
+
+	const n2 = "165f8f88-f073-41bc-8301-864050532dab"
+	var b bridge
+	require.NoError(t, Lookup(d, n2, &b))
+	assert.Equal(t, types.UUID(n2), b.UUID)
+	assert.Equal(t, "n2", b.Name)
+	assert.Len(t, b.Ports, len(d.GetS("Bridge", n2, "ports").(types.Set[types.UUID])))
+	assert.ErrorIs(t, Lookup(d, "00000000-0000-0000-0000-000000000001", &b), ErrNotFound)
+
+	var all []bridge
+	require.NoError(t, Find(d, &all))
+	require.Len(t, all, 5)
+	var names []string
+	for _, b := range all {
+		names = append(names, b.Name)
+	}
+	slices.Sort(names)
+	assert.Equal(t, []string{"hvssw0", "n1", "n2", "uSwitch0", "uSwitch1"}, names)
+
+	var some []*bridge
+	require.NoError(t, Find(d, &some,
+		[]types.Condition{types.Equal("name", "n1")},
+		[]types.Condition{types.Equal("name", "n2")},
+		[]types.Condition{types.Equal("_uuid", types.UUID(n2))}))
+	assert.Len(t, some, 2, "rows are found once")
+
+	assert.Error(t, Find(d, all), "not a pointer")
+	var notModel []struct{}
+	assert.Error(t, Find(d, &notModel))
+
+	// the reads through View are verified
+	v := NewView(d)
+	require.NoError(t, Lookup(v, n2, &b))
+	tr := v.Verify(transact.NewTransaction(&dSch))
+	assert.Equal(t, 1, tr.Len())
+}
@@ -2,9 +2,11 @@ package db
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/kazmanavt/ovsdb/v2/monitor"
 	"github.com/kazmanavt/ovsdb/v2/schema"
 	"github.com/kazmanavt/ovsdb/v2/types"
+	"reflect"
 	"sync"
 )
 
@@ -28,6 +30,41 @@ func (t *tableImpl) findRecord(where []types.Condition) []string {
 	return result
 }
 
+// snapshot returns the copies of the rows, the values of sets and maps are copied as well.
+func (t *tableImpl) snapshot() map[string]schema.Row {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	rows := make(map[string]schema.Row, len(t.rows))
+	for uuid, row := range t.rows {
+		c := t.sch.NewRow()
+		for _, cName := range t.cNames {
+			if v, ok := row.GetE(cName); ok && v != nil {
+				c.Set(cName, copyValue(v))
+			}
+		}
+		rows[uuid] = c
+	}
+	return rows
+}
+
+// copyValue returns the copy of the set or map value, the other values are returned as they are.
+func copyValue(v any) any {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice:
+		c := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		reflect.Copy(c, rv)
+		return c.Interface()
+	case reflect.Map:
+		c := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		for _, k := range rv.MapKeys() {
+			c.SetMapIndex(k, rv.MapIndex(k))
+		}
+		return c.Interface()
+	}
+	return v
+}
+
 // apply updates
 func (t *tableImpl) update2(upd2 monitor.RawTableUpdate2) error {
 	t.mu.Lock()
@@ -62,3 +99,28 @@ func (t *tableImpl) update2(upd2 monitor.RawTableUpdate2) error {
 	}
 	return nil
 }
+
+// apply parsed updates
+func (t *tableImpl) apply2(upd2 monitor.TableUpdate2) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for uuid, rowUpd2 := range upd2 {
+		switch {
+		case rowUpd2.Initial != nil:
+			t.rows[uuid] = rowUpd2.Initial
+		case rowUpd2.Insert != nil:
+			t.rows[uuid] = rowUpd2.Insert
+		case rowUpd2.Delete != nil:
+			delete(t.rows, uuid)
+		case rowUpd2.Modify != nil:
+			row, ok := t.rows[uuid]
+			if !ok {
+				return fmt.Errorf("modify of unknown row %s in table %q", uuid, t.name)
+			}
+			if err := row.Update2(rowUpd2.Modify); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package db
+
+import (
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/transact"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"slices"
+	"sync"
+)
+
+// View is DB recording the rows and columns read through it, like the verified columns
+// of ovsdb_idl_txn_verify. Verify turns the reads into the wait operations failing
+// the transaction if the server content differs from the one read.
+type View struct {
+	DB
+	mu    sync.Mutex
+	rows  map[string]map[string]*readRow // table -> uuid -> read
+	finds []readFind
+}
+
+// readRow is the snapshot of the read columns of the row.
+type readRow struct {
+	absent bool
+	vals   schema.Row
+}
+
+// readFind is the set of rows matching where.
+type readFind struct {
+	table string
+	where []types.Condition
+	uuids []string
+}
+
+// NewView creates View reading from d.
+func NewView(d DB) *View {
+	return &View{
+		DB:   d,
+		rows: make(map[string]map[string]*readRow),
+	}
+}
+
+func (v *View) TableRow(tName string, uuid types.UUID) schema.Row {
+	row := v.DB.TableRow(tName, uuid)
+	v.read(tName, string(uuid), row)
+	return row
+}
+
+func (v *View) TableRowS(tName, uuid string) schema.Row {
+	return v.TableRow(tName, types.UUID(uuid))
+}
+
+func (v *View) Get(tName string, uuid types.UUID, cName string) any {
+	row := v.DB.TableRow(tName, uuid)
+	v.read(tName, string(uuid), row, cName)
+	return row.Get(cName)
+}
+
+func (v *View) GetS(tName, uuid, cName string) any {
+	return v.Get(tName, types.UUID(uuid), cName)
+}
+
+func (v *View) FindRecord(tName string, wheres ...[]types.Condition) []string {
+	var res []string
+	for _, where := range wheres {
+		uuids := v.DB.FindRecord(tName, where)
+		v.mu.Lock()
+		v.finds = append(v.finds, readFind{table: tName, where: where, uuids: uuids})
+		v.mu.Unlock()
+		res = append(res, uuids...)
+	}
+	if res == nil {
+		return []string{}
+	}
+	return res
+}
+
+func (v *View) TableLen(tName string) int {
+	uuids := v.DB.FindRecord(tName, nil)
+	v.mu.Lock()
+	v.finds = append(v.finds, readFind{table: tName, uuids: uuids})
+	v.mu.Unlock()
+	return len(uuids)
+}
+
+// read records the columns cNames (all columns if none) of the row uuid, nil row is recorded as absent.
+// If the row has _version, it is recorded instead of the columns.
+func (v *View) read(tName, uuid string, row schema.Row, cNames ...string) {
+	tSch, ok := v.Schema().Tables[tName]
+	if !ok {
+		return
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	tRows, ok := v.rows[tName]
+	if !ok {
+		tRows = make(map[string]*readRow)
+		v.rows[tName] = tRows
+	}
+	r, ok := tRows[uuid]
+	if !ok {
+		r = &readRow{absent: row == nil}
+		if row != nil {
+			r.vals = tSch.NewRow()
+		}
+		tRows[uuid] = r
+	}
+	if r.absent || row == nil {
+		return
+	}
+	if ver, ok := row.GetE("_version"); ok {
+		r.vals.Set("_version", ver)
+		return
+	}
+	if len(cNames) == 0 {
+		for cName := range tSch.Columns {
+			if cName != "_uuid" && cName != "_version" {
+				cNames = append(cNames, cName)
+			}
+		}
+	}
+	for _, cName := range cNames {
+		if _, ok := r.vals.GetE(cName); !ok {
+			r.vals.Set(cName, row.Get(cName))
+		}
+	}
+}
+
+// Verify appends to tr the wait operations checking that the rows and columns read through v
+// are not changed on the server, and returns tr. The waits have zero timeout, so the transaction
+// fails with "timed out" error on the first of them not satisfied.
+func (v *View) Verify(tr transact.Transaction) transact.Transaction {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, tName := range sortedKeys(v.rows) {
+		for _, uuid := range sortedKeys(v.rows[tName]) {
+			r := v.rows[tName][uuid]
+			where := []types.Condition{types.Equal("_uuid", types.UUID(uuid))}
+			if r.absent {
+				tr.WaitNow(tName, where, []string{}, "==", []schema.Row{})
+				continue
+			}
+			var cNames []string
+			if _, ok := r.vals.GetE("_version"); ok {
+				cNames = []string{"_version"}
+			} else {
+				for cName := range v.Schema().Tables[tName].Columns {
+					if _, ok := r.vals.GetE(cName); ok {
+						cNames = append(cNames, cName)
+					}
+				}
+				slices.Sort(cNames)
+			}
+			if len(cNames) == 0 {
+				continue
+			}
+			tr.WaitNow(tName, where, cNames, "==", []schema.Row{r.vals})
+		}
+	}
+	for _, f := range v.finds {
+		where := f.where
+		if where == nil {
+			where = []types.Condition{}
+		}
+		tSch := v.Schema().Tables[f.table]
+		rows := make([]schema.Row, 0, len(f.uuids))
+		for _, uuid := range f.uuids {
+			rows = append(rows, tSch.NewRow("_uuid", types.UUID(uuid)))
+		}
+		tr.WaitNow(f.table, where, []string{"_uuid"}, "==", rows)
+	}
+	return tr
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
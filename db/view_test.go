@@ -0,0 +1,57 @@
+package db
+
+import (
+	"encoding/json"
+	"github.com/kazmanavt/ovsdb/v2/monitor"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/transact"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestView_Verify(t *testing.T) {
+	var dSch schema.DbSchema
+	require.NoError(t, json.Unmarshal(ovsSchema, &dSch))
+	var ini monitor.RawTableSetUpdate2
+	require.NoError(t, json.Unmarshal(initialC, &ini))
+	d := NewDB(&dSch)
+	require.NoError(t, d.Update2(ini))
+
+	const bridge = "165f8f88-f073-41bc-8301-864050532dab"
+	const missing = "00000000-0000-0000-0000-000000000001"
+	v := NewView(d)
+	name := v.GetS("Bridge", bridge, "name").(string)
+	assert.Equal(t, d.GetS("Bridge", bridge, "name"), name)
+	_ = v.GetS("Bridge", bridge, "name")
+	assert.Nil(t, v.TableRowS("Bridge", missing))
+	found := v.FindRecord("Bridge", []types.Condition{types.Equal("name", name)})
+	require.Equal(t, []string{bridge}, found)
+
+	tr := v.Verify(transact.NewTransaction(&dSch))
+	require.NoError(t, tr.Validate())
+	data, err := json.Marshal(tr.Operations())
+	require.NoError(t, err)
+	var ops []map[string]any
+	require.NoError(t, json.Unmarshal(data, &ops))
+	require.Len(t, ops, 3)
+
+	// the absent row, rows are ordered by uuid
+	assert.Equal(t, []any{[]any{"_uuid", "==", []any{"uuid", missing}}}, ops[0]["where"])
+	assert.Equal(t, []any{}, ops[0]["rows"])
+
+	// the row read, the column is checked once
+	assert.Equal(t, "wait", ops[1]["op"])
+	assert.Equal(t, "Bridge", ops[1]["table"])
+	assert.EqualValues(t, 0, ops[1]["timeout"])
+	assert.Equal(t, []any{[]any{"_uuid", "==", []any{"uuid", bridge}}}, ops[1]["where"])
+	assert.Equal(t, []any{"name"}, ops[1]["columns"])
+	assert.Equal(t, []any{map[string]any{"name": name}}, ops[1]["rows"])
+	assert.Equal(t, "==", ops[1]["until"])
+
+	// the search
+	assert.Equal(t, []any{[]any{"name", "==", name}}, ops[2]["where"])
+	assert.Equal(t, []any{"_uuid"}, ops[2]["columns"])
+	assert.Equal(t, []any{map[string]any{"_uuid": []any{"uuid", bridge}}}, ops[2]["rows"])
+}
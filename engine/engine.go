@@ -0,0 +1,74 @@
+// Package engine executes OVSDB transactions (RFC 7047 5.2) locally against the content of db.DB,
+// e.g. to test the code building transact.Transaction without a server or to preview the changes
+// of a transaction. The database given is never modified, the changes are returned
+// as monitor.TableSetUpdate2 which may be applied by db.DB.ApplyUpdate2.
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/db"
+	"github.com/kazmanavt/ovsdb/v2/internal/engine"
+	"github.com/kazmanavt/ovsdb/v2/monitor"
+	"github.com/kazmanavt/ovsdb/v2/transact"
+)
+
+// ExecOpt is an option of Execute and Run.
+type ExecOpt func(o *execOpts)
+
+type execOpts struct {
+	locks []string
+}
+
+// WithLocks sets the locks held by the executing client, checked by the assert operations.
+func WithLocks(ids ...string) ExecOpt {
+	return func(o *execOpts) {
+		o.locks = append(o.locks, ids...)
+	}
+}
+
+// Execute executes the operations ops (as params of transact method following the db name)
+// on the content of d. It returns the result of the transaction as the server responds
+// (see transact.Transaction.DecodeResult): the results of the operations, the error object
+// of the failed one followed by nulls, or the extra error object if the commit fails.
+// If the transaction succeeds, the changes are returned as well; the rows inserted or modified
+// hold the columns with non-default or changed values (sets and maps changed are given
+// as the difference to the old value, like in update2 notification) and the new _version,
+// the rows deleted hold the old content. The _version of the row is kept while the changes
+// are applied to d, so it changes only with the row. As nothing else changes d meanwhile, the wait
+// operation fails with "timed out" at once if its condition is not met.
+// The error is returned only if the result can't be encoded.
+func Execute(d db.DB, ops []json.RawMessage, opts ...ExecOpt) (json.RawMessage, monitor.TableSetUpdate2, error) {
+	var o execOpts
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return engine.Execute(d, ops, o.locks)
+}
+
+// Run executes the transaction tr on the content of d (see Execute) and decodes the results into tr.
+// The error is the one of tr.Error() if the transaction fails.
+func Run(d db.DB, tr transact.Transaction, opts ...ExecOpt) (monitor.TableSetUpdate2, error) {
+	if err := tr.Validate(); err != nil {
+		return nil, err
+	}
+	ops := make([]json.RawMessage, 0, tr.Len())
+	for _, op := range tr.Operations() {
+		raw, err := json.Marshal(op)
+		if err != nil {
+			return nil, fmt.Errorf("encode operation %q: %w", op.Name(), err)
+		}
+		ops = append(ops, raw)
+	}
+	res, upd, err := Execute(d, ops, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := tr.DecodeResult(res); err != nil {
+		return nil, err
+	}
+	if err := tr.Error(); err != nil {
+		return nil, err
+	}
+	return upd, nil
+}
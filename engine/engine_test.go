@@ -0,0 +1,303 @@
+package engine
+
+import (
+	"encoding/json"
+	"github.com/kazmanavt/ovsdb/v2/db"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/transact"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const testSchema = `{
+  "name": "Test",
+  "version": "1.0.0",
+  "tables": {
+    "Parent": {
+      "isRoot": true,
+      "maxRows": 3,
+      "indexes": [["name"]],
+      "columns": {
+        "name": {"type": "string"},
+        "kind": {"type": {"key": {"type": "string", "enum": ["set", ["a", "b"]]}, "min": 0, "max": 1}},
+        "level": {"type": {"key": {"type": "integer", "minInteger": 0, "maxInteger": 10}}},
+        "serial": {"type": "integer", "mutable": false},
+        "children": {"type": {"key": {"type": "uuid", "refTable": "Child"}, "min": 0, "max": "unlimited"}},
+        "watch": {"type": {"key": {"type": "uuid", "refTable": "Child", "refType": "weak"}, "min": 0, "max": "unlimited"}},
+        "tags": {"type": {"key": "string", "value": "string", "min": 0, "max": "unlimited"}}
+      }
+    },
+    "Child": {
+      "columns": {
+        "name": {"type": "string"}
+      }
+    }
+  }
+}`
+
+type fixture struct {
+	sch *schema.DbSchema
+	d   db.DB
+}
+
+func newFixture(t *testing.T) *fixture {
+	var sch schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &sch))
+	return &fixture{sch: &sch, d: db.NewDB(&sch)}
+}
+
+// run runs tr and applies the changes to the db.
+func (f *fixture) run(t *testing.T, tr transact.Transaction, opts ...ExecOpt) error {
+	upd, err := Run(f.d, tr, opts...)
+	if err != nil {
+		return err
+	}
+	require.NoError(t, f.d.ApplyUpdate2(upd))
+	return nil
+}
+
+func (f *fixture) parent(name string, children ...types.UUID) schema.Row {
+	row := f.sch.Tables["Parent"].NewRow()
+	row.Set("name", name)
+	row.Set("children", types.Set[types.UUID](children))
+	return row
+}
+
+func (f *fixture) child(name string) schema.Row {
+	row := f.sch.Tables["Child"].NewRow()
+	row.Set("name", name)
+	return row
+}
+
+func byName(name string) []types.Condition {
+	return []types.Condition{types.Equal("name", name)}
+}
+
+func TestRun(t *testing.T) {
+	f := newFixture(t)
+
+	// insert with named UUIDs
+	tr := transact.NewTransaction(f.sch)
+	c1 := tr.InsertRef(f.child("c1"))
+	c2 := tr.InsertRef(f.child("c2"))
+	p1 := f.parent("p1", c1, c2)
+	p1.Set("tags", types.Map[string, string]{"k": "v"})
+	tr.Insert(p1)
+	require.NoError(t, f.run(t, tr))
+	c1UUID, ok := tr.Results().UUID(c1)
+	require.True(t, ok)
+	assert.Equal(t, 2, f.d.TableLen("Child"))
+	assert.Equal(t, 1, f.d.TableLen("Parent"))
+	pUUID := f.d.FindRecord("Parent", byName("p1"))[0]
+	assert.ElementsMatch(t, f.d.GetS("Parent", pUUID, "children"), types.Set[types.UUID]{c1UUID, tr.Results().NamedUUIDs()[c2]})
+
+	// the db is not changed by the engine
+	tr = transact.NewTransaction(f.sch).Delete("Parent", nil)
+	_, err := Run(f.d, tr)
+	require.NoError(t, err)
+	assert.Equal(t, 1, f.d.TableLen("Parent"))
+
+	// select, update and mutate
+	tr = transact.NewTransaction(f.sch).
+		Update(byName("p1"), f.sch.Tables["Parent"].NewRow("level", 5)).
+		Mutate("Parent", byName("p1"), []types.Mutation{
+			types.Add("level", 2),
+			types.Insert("tags", types.Map[string, string]{"k": "other", "k2": "v2"}),
+			types.Delete("children", types.Set[types.UUID]{c1UUID}),
+			types.Insert("watch", types.Set[types.UUID]{c1UUID}),
+		}).
+		Select("Parent", []types.Condition{types.GreaterThan("level", 6)}, []string{"name", "level", "tags"})
+	require.NoError(t, f.run(t, tr))
+	rows, err := tr.Results().Rows(2)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, 7, rows[0].Get("level"))
+	assert.Equal(t, types.Map[string, string]{"k": "v", "k2": "v2"}, rows[0].Get("tags"))
+	assert.Equal(t, types.Map[string, string]{"k": "v", "k2": "v2"}, f.d.GetS("Parent", pUUID, "tags"))
+	// c1 is not referenced by strong references any more, it is collected with the weak reference
+	assert.Nil(t, f.d.TableRowS("Child", string(c1UUID)))
+	assert.Equal(t, 1, f.d.TableLen("Child"))
+	assert.Empty(t, f.d.GetS("Parent", pUUID, "watch"))
+
+	// wait
+	require.NoError(t, f.run(t, transact.NewTransaction(f.sch).
+		Wait("Parent", byName("p1"), []string{"level"}, "==", []schema.Row{f.sch.Tables["Parent"].NewRow("level", 7)}, 0)))
+	err = f.run(t, transact.NewTransaction(f.sch).
+		Wait("Parent", byName("p1"), []string{"level"}, "==", []schema.Row{f.sch.Tables["Parent"].NewRow("level", 1)}, 0))
+	assert.ErrorIs(t, err, transact.ErrTimedOut)
+
+	// delete of the root row collects the children
+	require.NoError(t, f.run(t, transact.NewTransaction(f.sch).Delete("Parent", byName("p1"))))
+	assert.Zero(t, f.d.TableLen("Parent"))
+	assert.Zero(t, f.d.TableLen("Child"))
+}
+
+func TestRun_Errors(t *testing.T) {
+	f := newFixture(t)
+	tr := transact.NewTransaction(f.sch)
+	c1 := tr.InsertRef(f.child("c1"))
+	tr.Insert(f.parent("p1", c1))
+	require.NoError(t, f.run(t, tr))
+	c1UUID := types.UUID(f.d.FindRecord("Child", byName("c1"))[0])
+
+	tests := []struct {
+		name  string
+		tr    transact.Transaction
+		err   error
+		index int
+	}{
+		{
+			name:  "range",
+			tr:    transact.NewTransaction(f.sch).Mutate("Parent", nil, []types.Mutation{types.Add("level", 20)}),
+			err:   transact.ErrConstraintViolation,
+			index: 0,
+		},
+		{
+			name:  "division by zero",
+			tr:    transact.NewTransaction(f.sch).Comment("x").Mutate("Parent", nil, []types.Mutation{types.Divide("level", 0)}),
+			err:   transact.ErrDomain,
+			index: 1,
+		},
+		{
+			name:  "immutable",
+			tr:    transact.NewTransaction(f.sch).Update(nil, f.sch.Tables["Parent"].NewRow("serial", 1)),
+			err:   transact.ErrConstraintViolation,
+			index: 0,
+		},
+		{
+			name:  "index",
+			tr:    transact.NewTransaction(f.sch).Insert(f.parent("p1")),
+			err:   transact.ErrConstraintViolation,
+			index: -1,
+		},
+		{
+			name:  "max rows",
+			tr:    transact.NewTransaction(f.sch).Insert(f.parent("p2")).Insert(f.parent("p3")).Insert(f.parent("p4")),
+			err:   transact.ErrConstraintViolation,
+			index: -1,
+		},
+		{
+			name:  "referenced row",
+			tr:    transact.NewTransaction(f.sch).Delete("Child", nil),
+			err:   transact.ErrReferentialIntegrity,
+			index: -1,
+		},
+		{
+			name: "missing row",
+			tr: transact.NewTransaction(f.sch).Mutate("Parent", nil, []types.Mutation{
+				types.Insert("children", types.Set[types.UUID]{"8cc2eb5c-8e66-4554-af1d-8fa5b9321f99"}),
+			}),
+			err:   transact.ErrReferentialIntegrity,
+			index: -1,
+		},
+		{
+			name:  "abort",
+			tr:    transact.NewTransaction(f.sch).Delete("Child", nil).Abort(),
+			err:   transact.ErrAborted,
+			index: 1,
+		},
+		{
+			name:  "assert",
+			tr:    transact.NewTransaction(f.sch).Assert("lock1"),
+			err:   transact.ErrNotOwner,
+			index: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := f.run(t, tt.tr)
+			require.ErrorIs(t, err, tt.err)
+			var opErr *transact.OpError
+			require.ErrorAs(t, err, &opErr)
+			assert.Equal(t, tt.index, opErr.Index)
+		})
+	}
+
+	// nothing is changed by the failed transactions
+	assert.Equal(t, 1, f.d.TableLen("Parent"))
+	assert.Equal(t, 1, f.d.TableLen("Child"))
+	assert.Equal(t, types.Set[types.UUID]{c1UUID}, f.d.GetS("Parent", f.d.FindRecord("Parent", nil)[0], "children"))
+
+	require.NoError(t, f.run(t, transact.NewTransaction(f.sch).Assert("lock1"), WithLocks("lock1")))
+}
+
+func TestExecute(t *testing.T) {
+	f := newFixture(t)
+	ops := []json.RawMessage{
+		json.RawMessage(`{"op": "insert", "table": "Parent", "row": {"name": "p1", "kind": "c"}}`),
+		json.RawMessage(`{"op": "comment", "comment": "not executed"}`),
+	}
+	res, upd, err := Execute(f.d, ops)
+	require.NoError(t, err)
+	assert.Nil(t, upd)
+	var results []json.RawMessage
+	require.NoError(t, json.Unmarshal(res, &results))
+	require.Len(t, results, 2)
+	assert.Contains(t, string(results[0]), `"error":"constraint violation"`)
+	assert.JSONEq(t, `null`, string(results[1]))
+
+	ops = []json.RawMessage{
+		json.RawMessage(`{"op": "insert", "table": "Parent", "row": {"name": "p1", "watch": ["named-uuid", "c"]}}`),
+		json.RawMessage(`{"op": "insert", "table": "Child", "row": {"name": "c"}, "uuid-name": "c"}`),
+		json.RawMessage(`{"op": "select", "table": "Parent", "where": [["name", "==", "p1"]], "columns": ["watch"]}`),
+	}
+	res, upd, err = Execute(f.d, ops)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(res, &results))
+	require.Len(t, results, 3)
+	var ins struct {
+		UUID types.UUID `json:"uuid"`
+	}
+	require.NoError(t, json.Unmarshal(results[1], &ins))
+	assert.JSONEq(t, `{"rows": [{"watch": ["uuid", "`+string(ins.UUID)+`"]}]}`, string(results[2]))
+	// the unreferenced child is collected
+	assert.NotContains(t, upd, "Child")
+	require.Len(t, upd["Parent"], 1)
+	for _, rowUpd := range upd["Parent"] {
+		require.NotNil(t, rowUpd.Insert)
+		assert.Empty(t, rowUpd.Insert.Get("watch"))
+	}
+}
+
+func TestExecute_StableVersion(t *testing.T) {
+	f := newFixture(t)
+	require.NoError(t, f.run(t, transact.NewTransaction(f.sch).Insert(f.parent("p1"))))
+
+	selectVersion := func() string {
+		t.Helper()
+		ops := []json.RawMessage{
+			json.RawMessage(`{"op": "select", "table": "Parent", "where": [["name", "==", "p1"]], "columns": ["_version"]}`),
+		}
+		res, upd, err := Execute(f.d, ops)
+		require.NoError(t, err)
+		require.NoError(t, f.d.ApplyUpdate2(upd))
+		var results []struct {
+			Rows []struct {
+				Version []string `json:"_version"`
+			} `json:"rows"`
+		}
+		require.NoError(t, json.Unmarshal(res, &results))
+		require.Len(t, results, 1)
+		require.Len(t, results[0].Rows, 1)
+		return results[0].Rows[0].Version[1]
+	}
+
+	// the version of the unchanged row is the same in every transaction
+	v1 := selectVersion()
+	assert.Equal(t, v1, selectVersion())
+	ops := []json.RawMessage{
+		json.RawMessage(`{"op": "wait", "table": "Parent", "where": [["name", "==", "p1"]], "columns": ["_version"],
+			"until": "==", "rows": [{"_version": ["uuid", "` + v1 + `"]}], "timeout": 0}`),
+	}
+	res, _, err := Execute(f.d, ops)
+	require.NoError(t, err)
+	assert.NotContains(t, string(res), "error")
+
+	// and it changes with the row
+	require.NoError(t, f.run(t, transact.NewTransaction(f.sch).Update(byName("p1"), f.sch.Tables["Parent"].NewRow("level", 1))))
+	v2 := selectVersion()
+	assert.NotEqual(t, v1, v2)
+	assert.Equal(t, v2, selectVersion())
+}
@@ -0,0 +1,445 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/monitor"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ref is the reference column of the table.
+type ref struct {
+	column string
+	table  string // referenced table
+	strong bool
+}
+
+// refColumns returns the reference columns of the table.
+func refColumns(tSch *schema.TableSchema) []ref {
+	var refs []ref
+	for cName, cSch := range tSch.Columns {
+		for _, bt := range []*schema.BaseType{&cSch.Type.Key, cSch.Type.Value} {
+			if bt == nil || bt.Type != "uuid" || bt.RefTable == nil {
+				continue
+			}
+			if len(refs) > 0 && refs[len(refs)-1].column == cName && refs[len(refs)-1].table == *bt.RefTable {
+				// map of the references to the same table
+				continue
+			}
+			refs = append(refs, ref{
+				column: cName,
+				table:  *bt.RefTable,
+				strong: bt.RefType == nil || *bt.RefType == "strong",
+			})
+		}
+	}
+	return refs
+}
+
+// uuids returns the UUIDs held by the value.
+func uuids(v any) []types.UUID {
+	var res []types.UUID
+	add := func(rv reflect.Value) {
+		if u, ok := rv.Interface().(types.UUID); ok && u != "" {
+			res = append(res, u)
+		}
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice:
+		for i := 0; i < rv.Len(); i++ {
+			add(rv.Index(i))
+		}
+	case reflect.Map:
+		for _, k := range rv.MapKeys() {
+			add(k)
+			add(rv.MapIndex(k))
+		}
+	default:
+		add(rv)
+	}
+	return res
+}
+
+// refCounts counts the strong references to the rows of tables.
+func refCounts(sch *schema.DbSchema, tables map[string]map[types.UUID]rowData) map[types.UUID]int {
+	counts := make(map[types.UUID]int)
+	for tName, tSch := range sch.Tables {
+		refs := refColumns(tSch)
+		for _, row := range tables[tName] {
+			for _, r := range refs {
+				if !r.strong {
+					continue
+				}
+				for _, u := range refUUIDs(row[r.column], tSch.Columns[r.column], r) {
+					counts[u]++
+				}
+			}
+		}
+	}
+	return counts
+}
+
+// refUUIDs returns the UUIDs of value v of the column cSch held by the part of the column referring by r.
+func refUUIDs(v any, cSch *schema.ColumnSchema, r ref) []types.UUID {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map {
+		return uuids(v)
+	}
+	keyRef := cSch.Type.Key.RefTable != nil && *cSch.Type.Key.RefTable == r.table
+	valRef := cSch.Type.Value != nil && cSch.Type.Value.RefTable != nil && *cSch.Type.Value.RefTable == r.table
+	var res []types.UUID
+	for _, k := range rv.MapKeys() {
+		if u, ok := k.Interface().(types.UUID); ok && keyRef {
+			res = append(res, u)
+		}
+		if u, ok := rv.MapIndex(k).Interface().(types.UUID); ok && valRef {
+			res = append(res, u)
+		}
+	}
+	return res
+}
+
+// hasRoots reports whether any table of the schema is a root one. If none is,
+// all tables are root ones for compatibility with the schemas older than isRoot.
+func hasRoots(sch *schema.DbSchema) bool {
+	for _, tSch := range sch.Tables {
+		if tSch.IsRoot {
+			return true
+		}
+	}
+	return false
+}
+
+// commit checks the result of the operations and completes it,
+// like ovsdb-server does on commit: garbage collection, weak references, referential
+// integrity, maxRows and indexes.
+func (t *txn) commit() *opError {
+	t.collectGarbage()
+	if err := t.checkStrongRefs(); err != nil {
+		return err
+	}
+	if err := t.dropWeakRefs(); err != nil {
+		return err
+	}
+	for tName, tSch := range t.sch.Tables {
+		if !t.changed(tName) {
+			continue
+		}
+		if tSch.MaxRows > 0 && len(t.tables[tName]) > tSch.MaxRows {
+			return errorf(tagConstraint, "transaction causes %q table to contain %d rows, greater than the schema-defined limit of %d row(s)",
+				tName, len(t.tables[tName]), tSch.MaxRows)
+		}
+		if err := t.checkIndexes(tName, tSch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// changed reports whether the rows of the table are changed by the transaction.
+func (t *txn) changed(tName string) bool {
+	orig, cur := t.orig[tName], t.tables[tName]
+	if len(orig) != len(cur) {
+		return true
+	}
+	for u, row := range cur {
+		o, ok := orig[u]
+		if !ok || o["_version"] != row["_version"] {
+			return true
+		}
+	}
+	return false
+}
+
+// rowChanged reports whether the row is inserted or modified by the transaction.
+func (t *txn) rowChanged(tName string, u types.UUID) bool {
+	o, ok := t.orig[tName][u]
+	return !ok || o["_version"] != t.tables[tName][u]["_version"]
+}
+
+// collectGarbage deletes the rows of the non-root tables not referenced by strong references,
+// if they are inserted or lost the references in the transaction.
+func (t *txn) collectGarbage() {
+	if !hasRoots(t.sch) {
+		return
+	}
+	before := refCounts(t.sch, t.orig)
+	for {
+		after := refCounts(t.sch, t.tables)
+		collected := false
+		for tName, tSch := range t.sch.Tables {
+			if tSch.IsRoot {
+				continue
+			}
+			for u := range t.tables[tName] {
+				if after[u] == 0 && (before[u] > 0 || t.inserted[u]) {
+					delete(t.tables[tName], u)
+					collected = true
+				}
+			}
+		}
+		if !collected {
+			return
+		}
+	}
+}
+
+// checkStrongRefs fails if the changed rows refer to the missing rows,
+// or the deleted rows are still referred.
+func (t *txn) checkStrongRefs() *opError {
+	deleted := make(map[types.UUID]string)
+	for tName, orig := range t.orig {
+		for u := range orig {
+			if _, ok := t.tables[tName][u]; !ok {
+				deleted[u] = tName
+			}
+		}
+	}
+	for tName, tSch := range t.sch.Tables {
+		for _, r := range refColumns(tSch) {
+			if !r.strong {
+				continue
+			}
+			for u, row := range t.tables[tName] {
+				changed := t.rowChanged(tName, u)
+				for _, ru := range refUUIDs(row[r.column], tSch.Columns[r.column], r) {
+					if _, ok := t.tables[r.table][ru]; ok {
+						continue
+					}
+					if dTable, ok := deleted[ru]; ok {
+						return errorf(tagReferential, "cannot delete %s row %s because of remaining reference from column %q of %s row %s",
+							dTable, ru, r.column, tName, u)
+					}
+					if changed {
+						return errorf(tagReferential, "table %s column %s row %s references nonexistent row %s in table %s",
+							tName, r.column, u, ru, r.table)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// dropWeakRefs removes the weak references to the rows deleted, and to the missing rows
+// from the changed rows, and checks the min number of elements after it.
+func (t *txn) dropWeakRefs() *opError {
+	for tName, tSch := range t.sch.Tables {
+		for _, r := range refColumns(tSch) {
+			if r.strong {
+				continue
+			}
+			cSch := tSch.Columns[r.column]
+			for u, row := range t.tables[tName] {
+				changed := t.rowChanged(tName, u)
+				var missing []types.UUID
+				for _, ru := range refUUIDs(row[r.column], cSch, r) {
+					if _, ok := t.tables[r.table][ru]; ok {
+						continue
+					}
+					if _, deleted := t.orig[r.table][ru]; deleted || changed {
+						missing = append(missing, ru)
+					}
+				}
+				if len(missing) == 0 {
+					continue
+				}
+				v := dropUUIDs(row[r.column], cSch, r, missing)
+				if err := cSch.ValidateValue(v); err != nil {
+					return errorf(tagConstraint, "table %s column %s row %s: removing weak references: %s", tName, r.column, u, err)
+				}
+				row[r.column] = v
+				row["_version"] = newUUID()
+			}
+		}
+	}
+	return nil
+}
+
+// dropUUIDs returns v without the elements or pairs referring missing.
+func dropUUIDs(v any, cSch *schema.ColumnSchema, r ref, missing []types.UUID) any {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice:
+		res := reflect.MakeSlice(rv.Type(), 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			if !slices.Contains(missing, rv.Index(i).Interface().(types.UUID)) {
+				res = reflect.Append(res, rv.Index(i))
+			}
+		}
+		return res.Interface()
+	case reflect.Map:
+		res := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		for _, k := range rv.MapKeys() {
+			e := rv.MapIndex(k)
+			pair := reflect.MakeMapWithSize(rv.Type(), 1)
+			pair.SetMapIndex(k, e)
+			drop := false
+			for _, u := range refUUIDs(pair.Interface(), cSch, r) {
+				if slices.Contains(missing, u) {
+					drop = true
+				}
+			}
+			if !drop {
+				res.SetMapIndex(k, e)
+			}
+		}
+		return res.Interface()
+	}
+	return cSch.GetDefaultValue()
+}
+
+// checkIndexes fails if the changed rows of the table duplicate the indexed columns of other rows.
+func (t *txn) checkIndexes(tName string, tSch *schema.TableSchema) *opError {
+	for _, index := range tSch.Indexes {
+		seen := make(map[string]types.UUID)
+		rows := t.tables[tName]
+		keys := make([]types.UUID, 0, len(rows))
+		for u := range rows {
+			keys = append(keys, u)
+		}
+		slices.Sort(keys)
+		for _, u := range keys {
+			key := canonRow(rows[u], index)
+			other, ok := seen[key]
+			if !ok {
+				seen[key] = u
+				continue
+			}
+			if t.rowChanged(tName, u) || t.rowChanged(tName, other) {
+				return errorf(tagConstraint, "transaction causes %s rows %s and %s to have identical values for index on columns %s",
+					tName, other, u, strings.Join(index, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// diff returns the changes of the transaction. The rows inserted and modified hold the new _version,
+// so it is kept by db.DB the changes are applied to and stays the same until the row is changed.
+func (t *txn) diff() (monitor.TableSetUpdate2, error) {
+	upd := make(monitor.TableSetUpdate2)
+	for tName, tSch := range t.sch.Tables {
+		tUpd := make(monitor.TableUpdate2)
+		orig, cur := t.orig[tName], t.tables[tName]
+		for u, o := range orig {
+			if _, ok := cur[u]; !ok {
+				row, err := toRow(tSch, columnsOf(o, tSch, func(string, any) bool { return true }))
+				if err != nil {
+					return nil, err
+				}
+				tUpd[string(u)] = monitor.RowUpdate2{Delete: row}
+			}
+		}
+		for u, row := range cur {
+			o, ok := orig[u]
+			if !ok {
+				vals := columnsOf(row, tSch, func(cName string, v any) bool {
+					return canon(v) != canon(tSch.Columns[cName].GetDefaultValue())
+				})
+				vals["_version"] = row["_version"]
+				r, err := toRow(tSch, vals)
+				if err != nil {
+					return nil, err
+				}
+				tUpd[string(u)] = monitor.RowUpdate2{Insert: r}
+				continue
+			}
+			if o["_version"] == row["_version"] {
+				continue
+			}
+			vals := modifications(tSch, o, row)
+			if len(vals) == 0 {
+				continue
+			}
+			vals["_version"] = row["_version"]
+			r, err := toRow(tSch, vals)
+			if err != nil {
+				return nil, err
+			}
+			tUpd[string(u)] = monitor.RowUpdate2{Modify: r}
+		}
+		if len(tUpd) > 0 {
+			upd[tName] = tUpd
+		}
+	}
+	return upd, nil
+}
+
+// columnsOf returns the values of the real columns of the row accepted by keep.
+func columnsOf(row rowData, tSch *schema.TableSchema, keep func(cName string, v any) bool) map[string]any {
+	vals := make(map[string]any)
+	for cName := range tSch.Columns {
+		if cName == "_uuid" || cName == "_version" || !keep(cName, row[cName]) {
+			continue
+		}
+		vals[cName] = row[cName]
+	}
+	return vals
+}
+
+// modifications returns the values of the real columns changed from old to cur, see modification.
+func modifications(tSch *schema.TableSchema, old, cur rowData) map[string]any {
+	vals := make(map[string]any)
+	for cName, cSch := range tSch.Columns {
+		if cName == "_uuid" || cName == "_version" || canon(old[cName]) == canon(cur[cName]) {
+			continue
+		}
+		vals[cName] = modification(cSch, old[cName], cur[cName])
+	}
+	return vals
+}
+
+// modification returns the value of the column in update2 "modify": the new value of the atomic
+// and optional columns, the elements to toggle for sets and the pairs to add, change or remove for maps.
+func modification(cSch *schema.ColumnSchema, old, cur any) any {
+	if (!isSetColumn(cSch) && !isMapColumn(cSch)) || isOptional(cSch) {
+		return cur
+	}
+	ro, rc := reflect.ValueOf(old), reflect.ValueOf(cur)
+	if rc.Kind() == reflect.Map {
+		res := reflect.MakeMap(rc.Type())
+		for _, k := range ro.MapKeys() {
+			if !rc.MapIndex(k).IsValid() {
+				res.SetMapIndex(k, ro.MapIndex(k))
+			}
+		}
+		for _, k := range rc.MapKeys() {
+			if e := ro.MapIndex(k); !e.IsValid() || e.Interface() != rc.MapIndex(k).Interface() {
+				res.SetMapIndex(k, rc.MapIndex(k))
+			}
+		}
+		return res.Interface()
+	}
+	res := reflect.MakeSlice(rc.Type(), 0, ro.Len()+rc.Len())
+	for i := 0; i < ro.Len(); i++ {
+		if !hasElem(rc, ro.Index(i).Interface()) {
+			res = reflect.Append(res, ro.Index(i))
+		}
+	}
+	for i := 0; i < rc.Len(); i++ {
+		if !hasElem(ro, rc.Index(i).Interface()) {
+			res = reflect.Append(res, rc.Index(i))
+		}
+	}
+	return res.Interface()
+}
+
+// toRow builds the row of the table from the values, without the checks of the column constraints
+// the differences of sets and maps may violate.
+func toRow(tSch *schema.TableSchema, vals map[string]any) (schema.Row, error) {
+	data, err := json.Marshal(vals)
+	if err != nil {
+		return nil, err
+	}
+	row := tSch.NewRow()
+	if err := row.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("table %q: %w", tSch.Name, err)
+	}
+	return row, nil
+}
@@ -0,0 +1,221 @@
+// Package engine executes OVSDB transactions against the content of db.DB for the public
+// engine package, the in-memory server of ovsdbtest and storage. It also provides the row
+// matching and diffing they need, which are not part of the public API.
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/db"
+	"github.com/kazmanavt/ovsdb/v2/monitor"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"time"
+)
+
+// rowData is the content of the row: the values of all columns including _uuid and _version.
+type rowData map[string]any
+
+// txn is the transaction being executed.
+type txn struct {
+	sch      *schema.DbSchema
+	orig     map[string]map[types.UUID]rowData // content before the transaction
+	tables   map[string]map[types.UUID]rowData // content being changed
+	inserted map[types.UUID]bool
+	named    map[string]types.UUID // uuid-name -> UUID of the inserted row
+	used     map[string]bool       // uuid-names of the executed inserts
+	locks    map[string]bool
+	blocked  *Blocked // set by the wait operation which condition is not met
+}
+
+// opError is the error of the operation or of the commit.
+type opError struct {
+	tag     string
+	details string
+}
+
+func (e *opError) Error() string {
+	if e.details == "" {
+		return e.tag
+	}
+	return fmt.Sprintf("%s: %s", e.tag, e.details)
+}
+
+func (e *opError) result() map[string]any {
+	res := map[string]any{"error": e.tag}
+	if e.details != "" {
+		res["details"] = e.details
+	}
+	return res
+}
+
+func errorf(tag, format string, args ...any) *opError {
+	return &opError{tag: tag, details: fmt.Sprintf(format, args...)}
+}
+
+const (
+	tagSyntax      = "syntax error"
+	tagConstraint  = "constraint violation"
+	tagReferential = "referential integrity violation"
+	tagDomain      = "domain error"
+	tagRange       = "range error"
+	tagTimedOut    = "timed out"
+	tagDuplicate   = "duplicate uuid-name"
+	tagAborted     = "aborted"
+	tagNotOwner    = "not owner"
+)
+
+// Blocked tells that the transaction failed only because the condition of the wait
+// operation is not met yet, and the operation allows to wait for it, see ExecuteBlocking.
+type Blocked struct {
+	Timeout time.Duration // the time to wait, 0 if it is not limited
+}
+
+// Execute executes the operations ops on the content of d by the client holding the locks,
+// see engine.Execute of the public package. The wait operations fail at once if their
+// conditions are not met.
+func Execute(d db.DB, ops []json.RawMessage, locks []string) (json.RawMessage, monitor.TableSetUpdate2, error) {
+	res, upd, _, err := ExecuteBlocking(d, ops, locks)
+	return res, upd, err
+}
+
+// ExecuteBlocking executes the operations like Execute. If the transaction fails on the wait
+// operation, which timeout is not 0, Blocked is returned with the result: the server is to
+// execute the transaction again when the content of d changes, until the timeout expires,
+// and to respond with the result then.
+func ExecuteBlocking(d db.DB, ops []json.RawMessage, locks []string) (json.RawMessage, monitor.TableSetUpdate2, *Blocked, error) {
+	t := newTxn(d)
+	for _, id := range locks {
+		t.locks[id] = true
+	}
+	t.scanNames(ops)
+
+	results := make([]any, len(ops))
+	var failed *opError
+	for i, raw := range ops {
+		res, err := t.execute(raw)
+		if err != nil {
+			results[i] = err.result()
+			failed = err
+			break
+		}
+		results[i] = res
+	}
+	if failed == nil {
+		if err := t.commit(); err != nil {
+			results = append(results, err.result())
+			failed = err
+		}
+	}
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("encode results: %w", err)
+	}
+	if failed != nil {
+		return data, nil, t.blocked, nil
+	}
+	upd, err := t.diff()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("build changes: %w", err)
+	}
+	return data, upd, nil, nil
+}
+
+// Match reports whether the row matches all the conditions given like "where" of the operations.
+// The conditions on _uuid are met only if the row holds it.
+func Match(row schema.Row, where []json.RawMessage) (bool, error) {
+	t := txn{named: make(map[string]types.UUID)}
+	conds, err := t.parseWhere(row.TableSchema(), where)
+	if err != nil {
+		return false, err
+	}
+	return matchAll(valuesOf(row.TableSchema(), row), conds), nil
+}
+
+// RowDiff returns the columns of cur differing from old in the form of update2 "modify"
+// (see Execute), or nil if the rows are equal. The synthetic columns are not compared.
+func RowDiff(old, cur schema.Row) (schema.Row, error) {
+	tSch := cur.TableSchema()
+	vals := modifications(tSch, valuesOf(tSch, old), valuesOf(tSch, cur))
+	if len(vals) == 0 {
+		return nil, nil
+	}
+	return toRow(tSch, vals)
+}
+
+func newTxn(d db.DB) *txn {
+	sch := d.Schema()
+	t := txn{
+		sch:      sch,
+		orig:     make(map[string]map[types.UUID]rowData, len(sch.Tables)),
+		tables:   make(map[string]map[types.UUID]rowData, len(sch.Tables)),
+		inserted: make(map[types.UUID]bool),
+		named:    make(map[string]types.UUID),
+		used:     make(map[string]bool),
+		locks:    make(map[string]bool),
+	}
+	// the rows of all tables are taken at once, not to mix the content before and after an update
+	snap := d.Snapshot()
+	for tName, tSch := range sch.Tables {
+		orig := make(map[types.UUID]rowData)
+		rows := make(map[types.UUID]rowData)
+		for u, row := range snap[tName] {
+			data := valuesOf(tSch, row)
+			data["_uuid"] = types.UUID(u)
+			if data["_version"] == types.UUID("") {
+				// the row is not stored from the changes of Execute, its version must be
+				// the same in every transaction until it is changed
+				data["_version"] = types.UUID(u)
+			}
+			orig[types.UUID(u)] = data
+			rows[types.UUID(u)] = data.clone()
+		}
+		t.orig[tName] = orig
+		t.tables[tName] = rows
+	}
+	return &t
+}
+
+// valuesOf returns the values of the columns of the row, the default ones for the columns not set.
+func valuesOf(tSch *schema.TableSchema, row schema.Row) rowData {
+	data := make(rowData, len(tSch.Columns))
+	for cName, cSch := range tSch.Columns {
+		if v, ok := row.GetE(cName); ok && v != nil {
+			data[cName] = copyValue(v)
+		} else if cName != "_uuid" {
+			data[cName] = cSch.GetDefaultValue()
+		}
+	}
+	return data
+}
+
+func (r rowData) clone() rowData {
+	c := make(rowData, len(r))
+	for k, v := range r {
+		c[k] = copyValue(v)
+	}
+	return c
+}
+
+// scanNames assigns UUIDs to the uuid-names of the inserts, so they may be referred before the insert.
+func (t *txn) scanNames(ops []json.RawMessage) {
+	for _, raw := range ops {
+		var op struct {
+			Op       string      `json:"op"`
+			UUIDName string      `json:"uuid-name"`
+			UUID     *types.UUID `json:"uuid"`
+		}
+		if err := json.Unmarshal(raw, &op); err != nil || op.Op != "insert" || op.UUIDName == "" {
+			continue
+		}
+		if _, ok := t.named[op.UUIDName]; ok {
+			continue
+		}
+		if op.UUID != nil && uuidRe.MatchString(string(*op.UUID)) {
+			t.named[op.UUIDName] = *op.UUID
+		} else {
+			t.named[op.UUIDName] = newUUID()
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"encoding/json"
+	"github.com/kazmanavt/ovsdb/v2/db"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const testSchema = `{
+  "name": "Test",
+  "version": "1.0.0",
+  "tables": {
+    "Parent": {
+      "columns": {
+        "name": {"type": "string"},
+        "level": {"type": "integer"},
+        "children": {"type": {"key": {"type": "uuid", "refTable": "Child"}, "min": 0, "max": "unlimited"}},
+        "tags": {"type": {"key": "string", "value": "string", "min": 0, "max": "unlimited"}}
+      }
+    },
+    "Child": {
+      "columns": {
+        "name": {"type": "string"}
+      }
+    }
+  }
+}`
+
+func TestMatchAndRowDiff(t *testing.T) {
+	var sch schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &sch))
+	tSch := sch.Tables["Parent"]
+	old := tSch.NewRow("name", "p1")
+	old.Set("children", types.Set[types.UUID]{"0c8e1d04-3f38-4ba4-a2f7-2c4e5f0f3a11"})
+	old.Set("tags", types.Map[string, string]{"a": "1", "b": "2"})
+	cur := tSch.NewRow("name", "p1", "level", 5)
+	cur.Set("children", types.Set[types.UUID]{"0c8e1d04-3f38-4ba4-a2f7-2c4e5f0f3a11", "5b0c8a3e-7a2d-4c55-9e0b-1a6f2f9d8c22"})
+	cur.Set("tags", types.Map[string, string]{"a": "1", "b": "3"})
+
+	ok, err := Match(cur, []json.RawMessage{
+		json.RawMessage(`["level", ">", 4]`),
+		json.RawMessage(`["children", "includes", ["uuid", "5b0c8a3e-7a2d-4c55-9e0b-1a6f2f9d8c22"]]`),
+	})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = Match(old, []json.RawMessage{json.RawMessage(`["level", ">", 4]`)})
+	require.NoError(t, err)
+	assert.False(t, ok)
+	_, err = Match(old, []json.RawMessage{json.RawMessage(`["nope", "==", 1]`)})
+	assert.Error(t, err)
+
+	diff, err := RowDiff(old, cur)
+	require.NoError(t, err)
+	data, err := diff.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"level": 5,
+		"children": ["uuid", "5b0c8a3e-7a2d-4c55-9e0b-1a6f2f9d8c22"],
+		"tags": ["map", [["b", "3"]]]
+	}`, string(data))
+
+	diff, err = RowDiff(cur, cur)
+	require.NoError(t, err)
+	assert.Nil(t, diff)
+}
+
+func TestExecuteBlocking(t *testing.T) {
+	var sch schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &sch))
+	d := db.NewDB(&sch)
+	wait := func(timeout string) []json.RawMessage {
+		return []json.RawMessage{json.RawMessage(`{"op": "wait", "table": "Parent", "where": [], "columns": ["name"],
+			"until": "==", "rows": [{"name": "p1"}]` + timeout + `}`)}
+	}
+
+	res, _, blocked, err := ExecuteBlocking(d, wait(""), nil)
+	require.NoError(t, err)
+	assert.Contains(t, string(res), "timed out")
+	assert.Equal(t, &Blocked{}, blocked)
+
+	_, _, blocked, err = ExecuteBlocking(d, wait(`, "timeout": 200`), nil)
+	require.NoError(t, err)
+	assert.Equal(t, &Blocked{Timeout: 200 * time.Millisecond}, blocked)
+
+	// timeout 0 fails at once
+	res, _, blocked, err = ExecuteBlocking(d, wait(`, "timeout": 0`), nil)
+	require.NoError(t, err)
+	assert.Contains(t, string(res), "timed out")
+	assert.Nil(t, blocked)
+
+	_, upd, err := Execute(d, []json.RawMessage{json.RawMessage(`{"op": "insert", "table": "Parent", "row": {"name": "p1"}}`)}, nil)
+	require.NoError(t, err)
+	require.NoError(t, d.ApplyUpdate2(upd))
+	res, _, blocked, err = ExecuteBlocking(d, wait(""), nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{}]`, string(res))
+	assert.Nil(t, blocked)
+}
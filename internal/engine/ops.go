@@ -0,0 +1,327 @@
+package engine
+
+import (
+	"encoding/json"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"slices"
+	"time"
+)
+
+// operation is the operation as received in transact request.
+type operation struct {
+	Op        string            `json:"op"`
+	Table     string            `json:"table"`
+	Row       json.RawMessage   `json:"row"`
+	Rows      []json.RawMessage `json:"rows"`
+	UUIDName  string            `json:"uuid-name"`
+	UUID      *types.UUID       `json:"uuid"`
+	Where     []json.RawMessage `json:"where"`
+	Columns   *[]string         `json:"columns"`
+	Mutations []json.RawMessage `json:"mutations"`
+	Until     string            `json:"until"`
+	Timeout   *int              `json:"timeout"`
+	Durable   bool              `json:"durable"`
+	Comment   string            `json:"comment"`
+	Lock      string            `json:"lock"`
+}
+
+func (t *txn) execute(raw json.RawMessage) (map[string]any, *opError) {
+	var op operation
+	if err := json.Unmarshal(raw, &op); err != nil {
+		return nil, errorf(tagSyntax, "invalid operation %s: %s", raw, err)
+	}
+	switch op.Op {
+	case "insert":
+		return t.insert(op)
+	case "select":
+		return t.selectRows(op)
+	case "update":
+		return t.update(op)
+	case "mutate":
+		return t.mutate(op)
+	case "delete":
+		return t.delete(op)
+	case "wait":
+		return t.wait(op)
+	case "commit", "comment":
+		return map[string]any{}, nil
+	case "abort":
+		return nil, &opError{tag: tagAborted}
+	case "assert":
+		if !t.locks[op.Lock] {
+			return nil, errorf(tagNotOwner, "lock %q is not held", op.Lock)
+		}
+		return map[string]any{}, nil
+	}
+	return nil, errorf(tagSyntax, "unknown operation %q", op.Op)
+}
+
+func (t *txn) table(name string) (*schema.TableSchema, map[types.UUID]rowData, *opError) {
+	tSch, ok := t.sch.Tables[name]
+	if !ok {
+		return nil, nil, errorf(tagSyntax, "unknown table %q", name)
+	}
+	return tSch, t.tables[name], nil
+}
+
+// find returns UUIDs of the rows of the table matching where, in stable order.
+func (t *txn) find(tSch *schema.TableSchema, rows map[types.UUID]rowData, where []json.RawMessage) ([]types.UUID, *opError) {
+	conds, err := t.parseWhere(tSch, where)
+	if err != nil {
+		return nil, err
+	}
+	var res []types.UUID
+	for u, row := range rows {
+		if matchAll(row, conds) {
+			res = append(res, u)
+		}
+	}
+	slices.Sort(res)
+	return res, nil
+}
+
+func (t *txn) insert(op operation) (map[string]any, *opError) {
+	tSch, rows, err := t.table(op.Table)
+	if err != nil {
+		return nil, err
+	}
+	vals, err := t.parseRow(tSch, op.Row)
+	if err != nil {
+		return nil, err
+	}
+	var u types.UUID
+	switch {
+	case op.UUIDName != "":
+		if t.used[op.UUIDName] {
+			return nil, errorf(tagDuplicate, "%q", op.UUIDName)
+		}
+		t.used[op.UUIDName] = true
+		u = t.named[op.UUIDName]
+	case op.UUID != nil:
+		if !uuidRe.MatchString(string(*op.UUID)) {
+			return nil, errorf(tagSyntax, "invalid uuid %q", *op.UUID)
+		}
+		u = *op.UUID
+	default:
+		u = newUUID()
+	}
+	for _, tRows := range t.tables {
+		if _, ok := tRows[u]; ok {
+			return nil, errorf(tagConstraint, "duplicate uuid %s", u)
+		}
+	}
+
+	row := rowData{"_uuid": u, "_version": newUUID()}
+	for cName, cSch := range tSch.Columns {
+		if cName == "_uuid" || cName == "_version" {
+			continue
+		}
+		if v, ok := vals[cName]; ok {
+			row[cName] = v
+		} else {
+			row[cName] = cSch.GetDefaultValue()
+		}
+	}
+	rows[u] = row
+	t.inserted[u] = true
+	return map[string]any{"uuid": u}, nil
+}
+
+func (t *txn) selectRows(op operation) (map[string]any, *opError) {
+	tSch, rows, err := t.table(op.Table)
+	if err != nil {
+		return nil, err
+	}
+	cNames, err := columns(tSch, op.Columns)
+	if err != nil {
+		return nil, err
+	}
+	uuids, err := t.find(tSch, rows, op.Where)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]map[string]any, 0, len(uuids))
+	for _, u := range uuids {
+		res = append(res, project(rows[u], cNames))
+	}
+	return map[string]any{"rows": res}, nil
+}
+
+func (t *txn) update(op operation) (map[string]any, *opError) {
+	tSch, rows, err := t.table(op.Table)
+	if err != nil {
+		return nil, err
+	}
+	vals, err := t.parseRow(tSch, op.Row)
+	if err != nil {
+		return nil, err
+	}
+	for cName := range vals {
+		if !tSch.Columns[cName].Mutable {
+			return nil, errorf(tagConstraint, "table %q: cannot update immutable column %q", tSch.Name, cName)
+		}
+	}
+	uuids, err := t.find(tSch, rows, op.Where)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range uuids {
+		row := rows[u]
+		changed := false
+		for cName, v := range vals {
+			if canon(row[cName]) != canon(v) {
+				row[cName] = copyValue(v)
+				changed = true
+			}
+		}
+		if changed {
+			row["_version"] = newUUID()
+		}
+	}
+	return map[string]any{"count": len(uuids)}, nil
+}
+
+func (t *txn) mutate(op operation) (map[string]any, *opError) {
+	tSch, rows, err := t.table(op.Table)
+	if err != nil {
+		return nil, err
+	}
+	mutations, err := parseMutations(tSch, op.Mutations)
+	if err != nil {
+		return nil, err
+	}
+	uuids, err := t.find(tSch, rows, op.Where)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range uuids {
+		row := rows[u]
+		changed := false
+		for _, m := range mutations {
+			v, err := t.applyMutation(m, row[m.column])
+			if err != nil {
+				return nil, err
+			}
+			if err := m.cSch.ValidateValue(v); err != nil {
+				return nil, errorf(tagConstraint, "table %q: column %q: %s", tSch.Name, m.column, err)
+			}
+			if canon(row[m.column]) != canon(v) {
+				row[m.column] = v
+				changed = true
+			}
+		}
+		if changed {
+			row["_version"] = newUUID()
+		}
+	}
+	return map[string]any{"count": len(uuids)}, nil
+}
+
+func (t *txn) delete(op operation) (map[string]any, *opError) {
+	tSch, rows, err := t.table(op.Table)
+	if err != nil {
+		return nil, err
+	}
+	uuids, err := t.find(tSch, rows, op.Where)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range uuids {
+		delete(rows, u)
+	}
+	return map[string]any{"count": len(uuids)}, nil
+}
+
+// wait checks the condition at once. If it is not met, the operation fails with "timed out",
+// and the transaction is marked as blocked unless the timeout is 0, see ExecuteBlocking.
+func (t *txn) wait(op operation) (map[string]any, *opError) {
+	tSch, rows, err := t.table(op.Table)
+	if err != nil {
+		return nil, err
+	}
+	if op.Until != "==" && op.Until != "!=" {
+		return nil, errorf(tagSyntax, "invalid until %q", op.Until)
+	}
+	if op.Columns == nil {
+		return nil, errorf(tagSyntax, "wait: columns are required")
+	}
+	cNames, err := columns(tSch, op.Columns)
+	if err != nil {
+		return nil, err
+	}
+	uuids, err := t.find(tSch, rows, op.Where)
+	if err != nil {
+		return nil, err
+	}
+	actual := make([]string, 0, len(uuids))
+	for _, u := range uuids {
+		actual = append(actual, canonRow(rows[u], cNames))
+	}
+	expected := make([]string, 0, len(op.Rows))
+	for _, raw := range op.Rows {
+		var cols map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &cols); err != nil {
+			return nil, errorf(tagSyntax, "invalid row %s: %s", raw, err)
+		}
+		row := make(rowData, len(cols))
+		for _, cName := range cNames {
+			cRaw, ok := cols[cName]
+			if !ok {
+				return nil, errorf(tagSyntax, "wait: row lacks column %q", cName)
+			}
+			v, err := t.parseValue(tSch.Columns[cName], cRaw)
+			if err != nil {
+				return nil, err
+			}
+			row[cName] = v
+		}
+		expected = append(expected, canonRow(row, cNames))
+	}
+	slices.Sort(actual)
+	slices.Sort(expected)
+	if slices.Equal(actual, expected) != (op.Until == "==") {
+		switch {
+		case op.Timeout == nil:
+			t.blocked = &Blocked{}
+		case *op.Timeout > 0:
+			t.blocked = &Blocked{Timeout: time.Duration(*op.Timeout) * time.Millisecond}
+		}
+		return nil, errorf(tagTimedOut, "wait on table %q: condition %q is not met", tSch.Name, op.Until)
+	}
+	return map[string]any{}, nil
+}
+
+// columns returns the columns cNames after validation, all columns of the table if cNames is nil.
+func columns(tSch *schema.TableSchema, cNames *[]string) ([]string, *opError) {
+	if cNames == nil {
+		all := make([]string, 0, len(tSch.Columns))
+		for cName := range tSch.Columns {
+			all = append(all, cName)
+		}
+		slices.Sort(all)
+		return all, nil
+	}
+	for _, cName := range *cNames {
+		if _, ok := tSch.Columns[cName]; !ok {
+			return nil, errorf(tagSyntax, "table %q: unknown column %q", tSch.Name, cName)
+		}
+	}
+	return *cNames, nil
+}
+
+func project(row rowData, cNames []string) map[string]any {
+	res := make(map[string]any, len(cNames))
+	for _, cName := range cNames {
+		res[cName] = row[cName]
+	}
+	return res
+}
+
+func canonRow(row rowData, cNames []string) string {
+	var s string
+	for _, cName := range cNames {
+		s += cName + "=" + canon(row[cName]) + ";"
+	}
+	return s
+}
@@ -0,0 +1,558 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var uuidRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// newUUID returns the random UUIDv4.
+func newUUID() types.UUID {
+	var b [16]byte
+	for i := range b {
+		b[i] = byte(rand.UintN(256))
+	}
+	b[6] = (b[6] & 0x0f) | (4 << 4)
+	b[8] = (b[8] & 0x3f) | 0x80
+	return types.UUID(fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]))
+}
+
+// copyValue returns the copy of the value not sharing the sets and maps with v.
+func copyValue(v any) any {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice:
+		c := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		reflect.Copy(c, rv)
+		return c.Interface()
+	case reflect.Map:
+		c := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		for _, k := range rv.MapKeys() {
+			c.SetMapIndex(k, rv.MapIndex(k))
+		}
+		return c.Interface()
+	}
+	return v
+}
+
+// canon returns the canonical form of the value, equal for the equal values.
+func canon(v any) string {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice:
+		elems := make([]string, rv.Len())
+		for i := range elems {
+			elems[i] = canonAtom(rv.Index(i).Interface())
+		}
+		slices.Sort(elems)
+		return "set[" + strings.Join(slices.Compact(elems), ",") + "]"
+	case reflect.Map:
+		pairs := make([]string, 0, rv.Len())
+		for _, k := range rv.MapKeys() {
+			pairs = append(pairs, canonAtom(k.Interface())+":"+canonAtom(rv.MapIndex(k).Interface()))
+		}
+		slices.Sort(pairs)
+		return "map[" + strings.Join(pairs, ",") + "]"
+	}
+	return canonAtom(v)
+}
+
+func canonAtom(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// isOptional reports whether the column is the set of zero or one element.
+func isOptional(cSch *schema.ColumnSchema) bool {
+	return *cSch.Type.Min == 0 && *cSch.Type.Max.(*int) == 1
+}
+
+func isSetColumn(cSch *schema.ColumnSchema) bool {
+	return strings.HasPrefix(cSch.Type.GetKind(), "Set[")
+}
+
+func isMapColumn(cSch *schema.ColumnSchema) bool {
+	return strings.HasPrefix(cSch.Type.GetKind(), "Map[")
+}
+
+// newAtom returns the pointer to the zero value of the atomic type typ.
+func newAtom(typ string) any {
+	switch typ {
+	case "integer":
+		return new(int)
+	case "real":
+		return new(float64)
+	case "boolean":
+		return new(bool)
+	case "string":
+		return new(string)
+	case "uuid":
+		return new(types.UUID)
+	}
+	return nil
+}
+
+// newSet returns the pointer to the empty set of the atomic type typ.
+func newSet(typ string) any {
+	switch typ {
+	case "integer":
+		return &types.Set[int]{}
+	case "real":
+		return &types.Set[float64]{}
+	case "boolean":
+		return &types.Set[bool]{}
+	case "string":
+		return &types.Set[string]{}
+	case "uuid":
+		return &types.Set[types.UUID]{}
+	}
+	return nil
+}
+
+// parseInto decodes raw into ptr and resolves the named UUIDs of the value.
+func (t *txn) parseInto(raw json.RawMessage, ptr any) (any, *opError) {
+	if ptr == nil {
+		return nil, errorf(tagSyntax, "unsupported type")
+	}
+	if err := json.Unmarshal(raw, ptr); err != nil {
+		return nil, errorf(tagSyntax, "invalid value %s: %s", raw, err)
+	}
+	return t.resolve(reflect.ValueOf(ptr).Elem().Interface())
+}
+
+// parseValue decodes the value of the column cSch.
+func (t *txn) parseValue(cSch *schema.ColumnSchema, raw json.RawMessage) (any, *opError) {
+	def := cSch.GetDefaultValue()
+	if def == nil {
+		return nil, errorf(tagSyntax, "column %q: unsupported type %s", cSch.Name, cSch.Type.GetKind())
+	}
+	v, err := t.parseInto(raw, reflect.New(reflect.TypeOf(def)).Interface())
+	if err != nil {
+		return nil, errorf(err.tag, "column %q: %s", cSch.Name, err.details)
+	}
+	return v, nil
+}
+
+// parseRow decodes the row of the table tSch, the synthetic columns are rejected.
+func (t *txn) parseRow(tSch *schema.TableSchema, raw json.RawMessage) (map[string]any, *opError) {
+	var cols map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &cols); err != nil {
+		return nil, errorf(tagSyntax, "invalid row %s: %s", raw, err)
+	}
+	row := make(map[string]any, len(cols))
+	for cName, cRaw := range cols {
+		cSch, ok := tSch.Columns[cName]
+		if !ok || cName == "_uuid" || cName == "_version" {
+			return nil, errorf(tagSyntax, "table %q: unknown column %q", tSch.Name, cName)
+		}
+		v, err := t.parseValue(cSch, cRaw)
+		if err != nil {
+			return nil, err
+		}
+		if err := cSch.ValidateValue(v); err != nil {
+			return nil, errorf(tagConstraint, "table %q: column %q: %s", tSch.Name, cName, err)
+		}
+		row[cName] = v
+	}
+	return row, nil
+}
+
+// resolve replaces the named UUIDs in v by the UUIDs of the rows inserted.
+func (t *txn) resolve(v any) (any, *opError) {
+	var err *opError
+	fix := func(rv reflect.Value) reflect.Value {
+		u, ok := rv.Interface().(types.UUID)
+		if !ok {
+			return rv
+		}
+		if real, ok := t.named[string(u)]; ok {
+			return reflect.ValueOf(real)
+		}
+		if !uuidRe.MatchString(string(u)) && err == nil {
+			err = errorf(tagSyntax, "unknown named-uuid %q", u)
+		}
+		return rv
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice:
+		c := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			c.Index(i).Set(fix(rv.Index(i)))
+		}
+		v = c.Interface()
+	case reflect.Map:
+		c := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		for _, k := range rv.MapKeys() {
+			c.SetMapIndex(fix(k), fix(rv.MapIndex(k)))
+		}
+		v = c.Interface()
+	default:
+		v = fix(rv).Interface()
+	}
+	return v, err
+}
+
+// condition is the parsed condition of where clause.
+type condition struct {
+	column string
+	fn     string
+	value  any
+	cSch   *schema.ColumnSchema
+}
+
+func (t *txn) parseWhere(tSch *schema.TableSchema, where []json.RawMessage) ([]condition, *opError) {
+	conds := make([]condition, 0, len(where))
+	for _, raw := range where {
+		var parts []json.RawMessage
+		if err := json.Unmarshal(raw, &parts); err != nil || len(parts) != 3 {
+			return nil, errorf(tagSyntax, "invalid condition %s", raw)
+		}
+		var c condition
+		if err := json.Unmarshal(parts[0], &c.column); err != nil {
+			return nil, errorf(tagSyntax, "invalid condition %s", raw)
+		}
+		if err := json.Unmarshal(parts[1], &c.fn); err != nil {
+			return nil, errorf(tagSyntax, "invalid condition %s", raw)
+		}
+		cSch, ok := tSch.Columns[c.column]
+		if !ok {
+			return nil, errorf(tagSyntax, "table %q: unknown column %q", tSch.Name, c.column)
+		}
+		c.cSch = cSch
+		switch c.fn {
+		case "==", "!=", "includes", "excludes":
+		case "<", "<=", ">", ">=":
+			typ := cSch.Type.Key.Type
+			if (typ != "integer" && typ != "real") || isMapColumn(cSch) || (isSetColumn(cSch) && !isOptional(cSch)) {
+				return nil, errorf(tagSyntax, "column %q: function %q is not applicable", c.column, c.fn)
+			}
+		default:
+			return nil, errorf(tagSyntax, "unknown function %q", c.fn)
+		}
+		v, err := t.parseValue(cSch, parts[2])
+		if err != nil {
+			return nil, err
+		}
+		c.value = v
+		conds = append(conds, c)
+	}
+	return conds, nil
+}
+
+func matchAll(row rowData, conds []condition) bool {
+	for _, c := range conds {
+		if !c.match(row[c.column]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c condition) match(v any) bool {
+	switch c.fn {
+	case "==":
+		return canon(v) == canon(c.value)
+	case "!=":
+		return canon(v) != canon(c.value)
+	case "includes":
+		if isSetColumn(c.cSch) || isMapColumn(c.cSch) {
+			return includes(v, c.value)
+		}
+		return canon(v) == canon(c.value)
+	case "excludes":
+		if isSetColumn(c.cSch) || isMapColumn(c.cSch) {
+			return excludes(v, c.value)
+		}
+		return canon(v) != canon(c.value)
+	}
+	a, ok1 := number(v)
+	b, ok2 := number(c.value)
+	if !ok1 || !ok2 {
+		return false
+	}
+	switch c.fn {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+// number returns the numeric value of the number or of the set of one number.
+func number(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice {
+		if rv.Len() != 1 {
+			return 0, false
+		}
+		rv = rv.Index(0)
+	}
+	switch rv.Kind() {
+	case reflect.Int:
+		return float64(rv.Int()), true
+	case reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// includes reports whether set or map v includes all elements or pairs of sub.
+func includes(v, sub any) bool {
+	rv, rs := reflect.ValueOf(v), reflect.ValueOf(sub)
+	if rv.Kind() == reflect.Map {
+		for _, k := range rs.MapKeys() {
+			e := rv.MapIndex(k)
+			if !e.IsValid() || e.Interface() != rs.MapIndex(k).Interface() {
+				return false
+			}
+		}
+		return true
+	}
+	for i := 0; i < rs.Len(); i++ {
+		if !hasElem(rv, rs.Index(i).Interface()) {
+			return false
+		}
+	}
+	return true
+}
+
+// excludes reports whether set or map v has none of the elements or pairs of sub.
+func excludes(v, sub any) bool {
+	rv, rs := reflect.ValueOf(v), reflect.ValueOf(sub)
+	if rv.Kind() == reflect.Map {
+		for _, k := range rs.MapKeys() {
+			e := rv.MapIndex(k)
+			if e.IsValid() && e.Interface() == rs.MapIndex(k).Interface() {
+				return false
+			}
+		}
+		return true
+	}
+	for i := 0; i < rs.Len(); i++ {
+		if hasElem(rv, rs.Index(i).Interface()) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasElem(set reflect.Value, e any) bool {
+	for i := 0; i < set.Len(); i++ {
+		if set.Index(i).Interface() == e {
+			return true
+		}
+	}
+	return false
+}
+
+// mutation is the parsed mutation.
+type mutation struct {
+	column  string
+	mutator string
+	raw     json.RawMessage
+	cSch    *schema.ColumnSchema
+}
+
+func parseMutations(tSch *schema.TableSchema, mutations []json.RawMessage) ([]mutation, *opError) {
+	res := make([]mutation, 0, len(mutations))
+	for _, raw := range mutations {
+		var parts []json.RawMessage
+		if err := json.Unmarshal(raw, &parts); err != nil || len(parts) != 3 {
+			return nil, errorf(tagSyntax, "invalid mutation %s", raw)
+		}
+		var m mutation
+		if err := json.Unmarshal(parts[0], &m.column); err != nil {
+			return nil, errorf(tagSyntax, "invalid mutation %s", raw)
+		}
+		if err := json.Unmarshal(parts[1], &m.mutator); err != nil {
+			return nil, errorf(tagSyntax, "invalid mutation %s", raw)
+		}
+		cSch, ok := tSch.Columns[m.column]
+		if !ok {
+			return nil, errorf(tagSyntax, "table %q: unknown column %q", tSch.Name, m.column)
+		}
+		if m.column == "_uuid" || m.column == "_version" || !cSch.Mutable {
+			return nil, errorf(tagConstraint, "table %q: cannot mutate immutable column %q", tSch.Name, m.column)
+		}
+		m.cSch = cSch
+		m.raw = parts[2]
+		res = append(res, m)
+	}
+	return res, nil
+}
+
+// applyMutation returns the value v mutated by m.
+func (t *txn) applyMutation(m mutation, v any) (any, *opError) {
+	keyType := m.cSch.Type.Key.Type
+	switch m.mutator {
+	case "+=", "-=", "*=", "/=", "%=":
+		if isMapColumn(m.cSch) || (keyType != "integer" && keyType != "real") {
+			return nil, errorf(tagSyntax, "column %q: mutator %q is not applicable", m.column, m.mutator)
+		}
+		if keyType == "real" && m.mutator == "%=" {
+			return nil, errorf(tagSyntax, "column %q: mutator %q is not applicable", m.column, m.mutator)
+		}
+		arg, err := t.parseInto(m.raw, newAtom(keyType))
+		if err != nil {
+			return nil, err
+		}
+		return arithmetic(m, v, arg)
+	case "insert", "delete":
+		if !isSetColumn(m.cSch) && !isMapColumn(m.cSch) {
+			return nil, errorf(tagSyntax, "column %q: mutator %q is not applicable", m.column, m.mutator)
+		}
+		arg, err := t.parseValue(m.cSch, m.raw)
+		if err != nil && m.mutator == "delete" && isMapColumn(m.cSch) {
+			// delete from map by the set of keys
+			arg, err = t.parseInto(m.raw, newSet(keyType))
+		}
+		if err != nil {
+			return nil, err
+		}
+		if m.mutator == "insert" {
+			return insertElems(v, arg), nil
+		}
+		return deleteElems(v, arg), nil
+	}
+	return nil, errorf(tagSyntax, "unknown mutator %q", m.mutator)
+}
+
+func arithmetic(m mutation, v, arg any) (any, *opError) {
+	apply := func(x reflect.Value) (reflect.Value, *opError) {
+		switch a := arg.(type) {
+		case int:
+			n := x.Int()
+			switch m.mutator {
+			case "+=":
+				if (a > 0 && n > math.MaxInt64-int64(a)) || (a < 0 && n < math.MinInt64-int64(a)) {
+					return x, errorf(tagRange, "column %q: integer overflow", m.column)
+				}
+				n += int64(a)
+			case "-=":
+				if (a < 0 && n > math.MaxInt64+int64(a)) || (a > 0 && n < math.MinInt64+int64(a)) {
+					return x, errorf(tagRange, "column %q: integer overflow", m.column)
+				}
+				n -= int64(a)
+			case "*=":
+				r := n * int64(a)
+				if n != 0 && (r/n != int64(a) || (n == -1 && int64(a) == math.MinInt64)) {
+					return x, errorf(tagRange, "column %q: integer overflow", m.column)
+				}
+				n = r
+			case "/=", "%=":
+				if a == 0 {
+					return x, errorf(tagDomain, "column %q: division by zero", m.column)
+				}
+				if m.mutator == "/=" {
+					n /= int64(a)
+				} else {
+					n %= int64(a)
+				}
+			}
+			return reflect.ValueOf(int(n)), nil
+		case float64:
+			f := x.Float()
+			switch m.mutator {
+			case "+=":
+				f += a
+			case "-=":
+				f -= a
+			case "*=":
+				f *= a
+			case "/=":
+				if a == 0 {
+					return x, errorf(tagDomain, "column %q: division by zero", m.column)
+				}
+				f /= a
+			}
+			if math.IsInf(f, 0) || math.IsNaN(f) {
+				return x, errorf(tagRange, "column %q: result out of range", m.column)
+			}
+			return reflect.ValueOf(f), nil
+		}
+		return x, errorf(tagSyntax, "column %q: invalid argument", m.column)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		res, err := apply(rv)
+		if err != nil {
+			return nil, err
+		}
+		return res.Interface(), nil
+	}
+	res := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		e, err := apply(rv.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		if hasElem(res.Slice(0, i), e.Interface()) {
+			return nil, errorf(tagConstraint, "column %q: mutation results in duplicate set elements", m.column)
+		}
+		res.Index(i).Set(e)
+	}
+	return res.Interface(), nil
+}
+
+// insertElems returns set v with the elements of set arg added,
+// or map v with the pairs of map arg whose keys are not in v.
+func insertElems(v, arg any) any {
+	rv, ra := reflect.ValueOf(copyValue(v)), reflect.ValueOf(arg)
+	if rv.Kind() == reflect.Map {
+		for _, k := range ra.MapKeys() {
+			if !rv.MapIndex(k).IsValid() {
+				rv.SetMapIndex(k, ra.MapIndex(k))
+			}
+		}
+		return rv.Interface()
+	}
+	for i := 0; i < ra.Len(); i++ {
+		if !hasElem(rv, ra.Index(i).Interface()) {
+			rv = reflect.Append(rv, ra.Index(i))
+		}
+	}
+	return rv.Interface()
+}
+
+// deleteElems returns set v without the elements of set arg,
+// or map v without the pairs of map arg, or without the keys of set arg.
+func deleteElems(v, arg any) any {
+	rv, ra := reflect.ValueOf(copyValue(v)), reflect.ValueOf(arg)
+	if rv.Kind() == reflect.Map {
+		if ra.Kind() == reflect.Map {
+			for _, k := range ra.MapKeys() {
+				if e := rv.MapIndex(k); e.IsValid() && e.Interface() == ra.MapIndex(k).Interface() {
+					rv.SetMapIndex(k, reflect.Value{})
+				}
+			}
+		} else {
+			for i := 0; i < ra.Len(); i++ {
+				rv.SetMapIndex(ra.Index(i), reflect.Value{})
+			}
+		}
+		return rv.Interface()
+	}
+	res := reflect.MakeSlice(rv.Type(), 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		if !hasElem(ra, rv.Index(i).Interface()) {
+			res = reflect.Append(res, rv.Index(i))
+		}
+	}
+	return res.Interface()
+}
@@ -3,8 +3,31 @@ package monitor
 import (
 	"fmt"
 	"github.com/kazmanavt/ovsdb/v2/schema"
+	"maps"
 )
 
+// Rebind returns the copy of the requests reqs for the schema sch validated against it,
+// e.g. after the db is converted on the server.
+func Rebind(reqs GenericMonReqSet, sch *schema.DbSchema) (GenericMonReqSet, error) {
+	var out GenericMonReqSet
+	switch r := reqs.(type) {
+	case *monReqSet:
+		clone := *r
+		clone.sch, clone.reqs = sch, maps.Clone(r.reqs)
+		out = &clone
+	case *monCondReqSet:
+		clone := *r
+		clone.sch, clone.reqs = sch, maps.Clone(r.reqs)
+		out = &clone
+	default:
+		return nil, fmt.Errorf("unknown monitor requests %T", reqs)
+	}
+	if err := out.Validate(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 type req interface {
 	GetColumns() []string
 	GetSelect() *Select
@@ -17,4 +17,7 @@ type MonReqSet interface {
 type MonCondReqSet interface {
 	GenericMonReqSet
 	Add(table string, req ...MonCondReq) MonCondReqSet
+	// ChangeRequests returns the requests in form of <monitor-cond-update-requests>
+	// suitable for monitor_cond_change method.
+	ChangeRequests() map[string][]MonCondChangeReq
 }
@@ -44,3 +44,10 @@ func (mr *MonCondReq) withoutInitial() MonCondReq {
 	clone.Select = &sel
 	return clone
 }
+
+// MonCondChangeReq is a request to change the condition of an existing conditional
+// monitor (<monitor-cond-update-request>). The server does not accept the change
+// of the columns, see CheckChange.
+type MonCondChangeReq struct {
+	Where []types.Condition `json:"where"`
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/kazmanavt/ovsdb/v2/schema"
 	"github.com/kazmanavt/ovsdb/v2/types"
+	"slices"
 )
 
 func NewMonCondReqSet(ds *schema.DbSchema) MonCondReqSet {
@@ -55,6 +56,71 @@ func (mm *monCondReqSet) WithoutInitial() GenericMonReqSet {
 	return mm
 }
 
+func (mm *monCondReqSet) ChangeRequests() map[string][]MonCondChangeReq {
+	res := make(map[string][]MonCondChangeReq, len(mm.reqs))
+	for tName, tReqs := range mm.reqs {
+		for _, r := range tReqs {
+			where := r.Where
+			if where == nil {
+				where = []types.Condition{}
+			}
+			res[tName] = append(res[tName], MonCondChangeReq{Where: where})
+		}
+	}
+	return res
+}
+
+// CheckChange returns an error if next differs from cur in anything but the conditions
+// (tables, number of requests, columns or select), as monitor_cond_change changes only them.
+func CheckChange(cur GenericMonReqSet, next MonCondReqSet) error {
+	c, ok := cur.(*monCondReqSet)
+	if !ok {
+		return fmt.Errorf("%T are not monitor cond requests", cur)
+	}
+	n, ok := next.(*monCondReqSet)
+	if !ok {
+		return fmt.Errorf("%T are not monitor cond requests", next)
+	}
+	for tName := range n.reqs {
+		if _, ok := c.reqs[tName]; !ok {
+			return fmt.Errorf("table %q is not monitored", tName)
+		}
+	}
+	for tName, cReqs := range c.reqs {
+		nReqs, ok := n.reqs[tName]
+		if !ok {
+			return fmt.Errorf("table %q can't be removed", tName)
+		}
+		if len(nReqs) != len(cReqs) {
+			return fmt.Errorf("table %q: %d requests instead of %d", tName, len(nReqs), len(cReqs))
+		}
+		for i := range cReqs {
+			if !sameColumns(cReqs[i].Columns, nReqs[i].Columns) {
+				return fmt.Errorf("table %q: req #%d: columns can't be changed", tName, i)
+			}
+			if selectOf(cReqs[i].Select) != selectOf(nReqs[i].Select) {
+				return fmt.Errorf("table %q: req #%d: select can't be changed", tName, i)
+			}
+		}
+	}
+	return nil
+}
+
+func sameColumns(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
+
+// selectOf returns the select of the request, all updates if not set.
+func selectOf(sel *Select) Select {
+	if sel == nil {
+		return Select{Initial: true, Insert: true, Delete: true, Modify: true}
+	}
+	return *sel
+}
+
 func (mm *monCondReqSet) Validate() error {
 	if mm == nil {
 		return fmt.Errorf("nil monitor cond requests")
@@ -1,6 +1,7 @@
 package monitor
 
 import (
+	"encoding/json"
 	"github.com/kazmanavt/ovsdb/v2/schema"
 	"github.com/kazmanavt/ovsdb/v2/types"
 	"github.com/stretchr/testify/assert"
@@ -164,3 +165,80 @@ func Test_monCondReqs_MarshalJSON(t *testing.T) {
 
 	//{"columns":["name","ports"],"where":[{"op":"==","column":"name","value":"br0"}]}`, string(b))
 }
+
+func Test_monCondReqs_ChangeRequests(t *testing.T) {
+	var sch schema.DbSchema
+	err := sch.UnmarshalJSON(ovsSchema)
+	require.NoError(t, err, "fail to load schema")
+
+	reqs := NewMonCondReqSet(&sch)
+	reqs.Add("Bridge",
+		MonCondReq{Columns: []string{"name", "ports"}, Where: []types.Condition{types.Equal("name", "br0")}, Select: &Select{Modify: true}},
+		MonCondReq{Columns: []string{"other_config"}, Select: &Select{Initial: true}},
+	)
+	err = reqs.Validate()
+	require.NoError(t, err, "fail to add request")
+
+	b, err := json.Marshal(reqs.ChangeRequests())
+	require.NoError(t, err, "fail to marshal change requests")
+	assert.JSONEq(t, `{
+			"Bridge":[
+				{
+					"where":[["name", "==","br0"]]
+				},
+				{
+					"where":[]
+				}
+			]
+		}`, string(b))
+}
+
+func TestCheckChange(t *testing.T) {
+	var sch schema.DbSchema
+	require.NoError(t, sch.UnmarshalJSON(ovsSchema))
+	cur := NewMonCondReqSet(&sch).Add("Bridge",
+		MonCondReq{Columns: []string{"name", "ports"}, Where: []types.Condition{types.Equal("name", "br0")}})
+
+	// the condition and the order of the columns may change
+	assert.NoError(t, CheckChange(cur, NewMonCondReqSet(&sch).Add("Bridge",
+		MonCondReq{Columns: []string{"ports", "name"}, Where: []types.Condition{types.Equal("name", "br1")}})))
+
+	for name, next := range map[string]MonCondReqSet{
+		"columns": NewMonCondReqSet(&sch).Add("Bridge", MonCondReq{Columns: []string{"name"}}),
+		"select": NewMonCondReqSet(&sch).Add("Bridge",
+			MonCondReq{Columns: []string{"name", "ports"}, Select: &Select{Insert: true}}),
+		"requests": NewMonCondReqSet(&sch).Add("Bridge",
+			MonCondReq{Columns: []string{"name", "ports"}}, MonCondReq{Columns: []string{"name", "ports"}}),
+		"added table": NewMonCondReqSet(&sch).Add("Bridge", MonCondReq{Columns: []string{"name", "ports"}}).
+			Add("Port", MonCondReq{}),
+		"removed table": NewMonCondReqSet(&sch).Add("Port", MonCondReq{}),
+	} {
+		assert.Error(t, CheckChange(cur, next), name)
+	}
+	assert.Error(t, CheckChange(NewMonReqSet(&sch), cur))
+}
+
+func TestRebind(t *testing.T) {
+	var sch schema.DbSchema
+	require.NoError(t, sch.UnmarshalJSON(ovsSchema))
+	reqs := NewMonCondReqSet(&sch).Add("Bridge", MonCondReq{Columns: []string{"name", "other_config"}})
+
+	var next schema.DbSchema
+	require.NoError(t, next.UnmarshalJSON(ovsSchema))
+	rebound, err := Rebind(reqs, &next)
+	require.NoError(t, err)
+	want, err := json.Marshal(reqs)
+	require.NoError(t, err)
+	got, err := json.Marshal(rebound)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(want), string(got))
+
+	// the column is removed by the new schema
+	delete(next.Tables["Bridge"].Columns, "other_config")
+	_, err = Rebind(reqs, &next)
+	assert.Error(t, err)
+	assert.NoError(t, reqs.Validate(), "the requests are not changed")
+
+	_, err = Rebind(NewMonReqSet(&sch).Add("NoSuchTable", MonReq{}), &next)
+	assert.Error(t, err)
+}
@@ -12,3 +12,9 @@ type RowUpdate struct {
 type TableUpdate map[string]RowUpdate
 
 type TableSetUpdate map[string]TableUpdate
+
+// IsResync reports whether the update is a resync snapshot, see ResyncTable.
+func (u TableSetUpdate) IsResync() bool {
+	_, ok := u[ResyncTable]
+	return ok
+}
@@ -13,3 +13,15 @@ type RowUpdate2 struct {
 type TableUpdate2 map[string]RowUpdate2
 
 type TableSetUpdate2 map[string]TableUpdate2
+
+// ResyncTable is the name of the pseudo table marking the update as a resync snapshot.
+// Such update holds the full current content of the monitored tables, the consumer
+// must drop its state and rebuild it from the update. The pseudo table has no rows.
+// The client sets it only for the monitors asking for it (client.WithResyncMarker).
+const ResyncTable = "_resync"
+
+// IsResync reports whether the update is a resync snapshot, see ResyncTable.
+func (u TableSetUpdate2) IsResync() bool {
+	_, ok := u[ResyncTable]
+	return ok
+}
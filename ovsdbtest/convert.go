@@ -0,0 +1,100 @@
+package ovsdbtest
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/kazmanavt/ovsdb/v2/db"
+	"github.com/kazmanavt/ovsdb/v2/monitor"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+)
+
+// convert replaces the schema of the database, the rows are kept with the columns of the new
+// schema. The monitors of the database are canceled: the clients aware of the changes
+// (set_db_change_aware) get monitor_canceled, the others are disconnected like by ovsdb-server.
+func (sess *session) convert(params []json.RawMessage) (any, error) {
+	var sch schema.DbSchema
+	if err := param(params, 1, &sch); err != nil {
+		return nil, err
+	}
+	s := sess.srv
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, err := sess.database(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	if sch.Name != d.sch.Name || sch.Validate() != nil {
+		return nil, errors.New("syntax error")
+	}
+	data, err := convertData(d.data, &sch)
+	if err != nil {
+		return nil, err
+	}
+	d.sch, d.data = &sch, data
+	d.txns, d.lastTxn = nil, newTxnID()
+	d.commit()
+	s.cancelMonitors(d)
+	return struct{}{}, nil
+}
+
+// convertData copies the rows of the tables kept by sch, the columns not in sch are dropped.
+func convertData(old db.DB, sch *schema.DbSchema) (db.DB, error) {
+	upd := make(monitor.TableSetUpdate2)
+	for tName, tSch := range sch.Tables {
+		if _, ok := old.Schema().Tables[tName]; !ok {
+			continue
+		}
+		tUpd := make(monitor.TableUpdate2)
+		for _, uuid := range old.FindRecord(tName, nil) {
+			raw, err := old.TableRowS(tName, uuid).MarshalJSON()
+			if err != nil {
+				return nil, err
+			}
+			var cols map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &cols); err != nil {
+				return nil, err
+			}
+			for cName := range cols {
+				if _, ok := tSch.Columns[cName]; !ok {
+					delete(cols, cName)
+				}
+			}
+			if raw, err = json.Marshal(cols); err != nil {
+				return nil, err
+			}
+			row := tSch.NewRow()
+			if row.UnmarshalJSON(raw) != nil {
+				return nil, errors.New("constraint violation")
+			}
+			for cName := range cols {
+				if tSch.Columns[cName].ValidateValue(row.Get(cName)) != nil {
+					return nil, errors.New("constraint violation")
+				}
+			}
+			tUpd[uuid] = monitor.RowUpdate2{Initial: row}
+		}
+		upd[tName] = tUpd
+	}
+	data := db.NewDB(sch)
+	if err := data.ApplyUpdate2(upd); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// cancelMonitors cancels the monitors of the database d, Server.mu must be held.
+func (s *Server) cancelMonitors(d *database) {
+	for sess := range s.sessions {
+		for key, m := range sess.monitors {
+			if m.db != d {
+				continue
+			}
+			if !sess.dbChangeAware {
+				_ = sess.conn.Close()
+				break
+			}
+			delete(sess.monitors, key)
+			sess.notify("monitor_canceled", m.id)
+		}
+	}
+}
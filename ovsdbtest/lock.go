@@ -0,0 +1,104 @@
+package ovsdbtest
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// lockState is the owner of the lock and the sessions waiting for it.
+type lockState struct {
+	owner   *session
+	waiters []*session
+}
+
+func (l *lockState) removeWaiter(sess *session) {
+	l.waiters = slices.DeleteFunc(l.waiters, func(w *session) bool { return w == sess })
+}
+
+func lockID(params []json.RawMessage) (string, error) {
+	var id string
+	if err := param(params, 0, &id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func (sess *session) lock(params []json.RawMessage) (any, error) {
+	id, err := lockID(params)
+	if err != nil {
+		return nil, err
+	}
+	s := sess.srv
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.locks[id]
+	if !ok {
+		l = &lockState{}
+		s.locks[id] = l
+	}
+	switch {
+	case l.owner == nil:
+		l.owner = sess
+		sess.locks[id] = true
+	case l.owner != sess && !slices.Contains(l.waiters, sess):
+		l.waiters = append(l.waiters, sess)
+	}
+	return map[string]bool{"locked": l.owner == sess}, nil
+}
+
+func (sess *session) steal(params []json.RawMessage) (any, error) {
+	id, err := lockID(params)
+	if err != nil {
+		return nil, err
+	}
+	s := sess.srv
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.locks[id]
+	if !ok {
+		l = &lockState{}
+		s.locks[id] = l
+	}
+	l.removeWaiter(sess)
+	if prev := l.owner; prev != nil && prev != sess {
+		// the previous owner waits for the lock back like ovsdb-server does
+		delete(prev.locks, id)
+		l.waiters = slices.Insert(l.waiters, 0, prev)
+		prev.notify("stolen", id)
+	}
+	l.owner = sess
+	sess.locks[id] = true
+	return map[string]bool{"locked": true}, nil
+}
+
+func (sess *session) unlockReq(params []json.RawMessage) (any, error) {
+	id, err := lockID(params)
+	if err != nil {
+		return nil, err
+	}
+	s := sess.srv
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unlock(sess, id)
+	if l, ok := s.locks[id]; ok {
+		l.removeWaiter(sess)
+	}
+	return struct{}{}, nil
+}
+
+// unlock releases the lock id owned by sess and passes it to the first waiter. Server.mu must be held.
+func (s *Server) unlock(sess *session, id string) {
+	l, ok := s.locks[id]
+	if !ok || l.owner != sess {
+		return
+	}
+	delete(sess.locks, id)
+	l.owner = nil
+	if len(l.waiters) == 0 {
+		delete(s.locks, id)
+		return
+	}
+	l.owner, l.waiters = l.waiters[0], l.waiters[1:]
+	l.owner.locks[id] = true
+	l.owner.notify("locked", id)
+}
@@ -0,0 +1,442 @@
+package ovsdbtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/db"
+	"github.com/kazmanavt/ovsdb/v2/internal/engine"
+	"github.com/kazmanavt/ovsdb/v2/monitor"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"slices"
+)
+
+// notification methods of the monitors
+const (
+	methodUpdate  = "update"  // monitor
+	methodUpdate2 = "update2" // monitor_cond
+	methodUpdate3 = "update3" // monitor_cond_since
+)
+
+// change is the row before and after the transaction, nil if the row is inserted or deleted.
+type change struct {
+	before, after schema.Row
+}
+
+// changeSet is the changes of the transaction: table -> row UUID -> change.
+type changeSet map[string]map[string]change
+
+// txnRecord is the transaction remembered for monitor_cond_since.
+type txnRecord struct {
+	id      string
+	changes changeSet
+}
+
+// monReq is the monitor request of the table, <monitor-request> or <monitor-cond-request>.
+type monReq struct {
+	Columns []string          `json:"columns"`
+	Where   []json.RawMessage `json:"where"`
+	Select  *monitor.Select   `json:"select"`
+}
+
+// dbMonitor is the monitor set up by the client.
+type dbMonitor struct {
+	id     json.RawMessage
+	db     *database
+	method string // notification method
+	reqs   map[string][]monReq
+}
+
+// rowUpdate is <row-update> or <row-update2>.
+type rowUpdate map[string]any
+
+// tableUpdates is <table-updates> or <table-updates2>: table -> row UUID -> update.
+type tableUpdates map[string]map[string]rowUpdate
+
+func (u tableUpdates) add(tName, uuid string, upd rowUpdate) {
+	if upd == nil {
+		return
+	}
+	if u[tName] == nil {
+		u[tName] = make(map[string]rowUpdate)
+	}
+	prev, ok := u[tName][uuid]
+	if !ok {
+		u[tName][uuid] = upd
+		return
+	}
+	// requests of the same table monitor distinct columns
+	for kind, cols := range upd {
+		pCols, _ := prev[kind].(map[string]any)
+		nCols, _ := cols.(map[string]any)
+		if pCols == nil || nCols == nil {
+			continue
+		}
+		for cName, v := range nCols {
+			pCols[cName] = v
+		}
+	}
+}
+
+// monitor returns the handler of the monitor method notifying by the method notify.
+func (sess *session) monitor(notify string) func(params []json.RawMessage) (any, error) {
+	return func(params []json.RawMessage) (any, error) {
+		s := sess.srv
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		d, err := sess.database(params, 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(params) < 3 {
+			return nil, errors.New("missing monitor requests")
+		}
+		id := params[1]
+		if _, ok := sess.monitors[string(id)]; ok {
+			return nil, errors.New("duplicate monitor ID")
+		}
+		m := &dbMonitor{id: id, db: d, method: notify}
+		if err := m.parseRequests(params[2]); err != nil {
+			return nil, err
+		}
+
+		var res any
+		if notify == methodUpdate3 {
+			var since string
+			if len(params) > 3 {
+				if err := param(params, 3, &since); err != nil {
+					return nil, err
+				}
+			}
+			changes, found := d.since(since)
+			var upd tableUpdates
+			if found {
+				upd, err = m.changes(changes)
+			} else {
+				upd, err = m.initial()
+			}
+			if err != nil {
+				return nil, err
+			}
+			res = []any{found, d.lastTxn, upd}
+		} else {
+			if res, err = m.initial(); err != nil {
+				return nil, err
+			}
+		}
+		sess.monitors[string(id)] = m
+		return res, nil
+	}
+}
+
+func (sess *session) monitorCancel(params []json.RawMessage) (any, error) {
+	if len(params) < 1 {
+		return nil, errors.New("missing monitor ID")
+	}
+	s := sess.srv
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := sess.monitors[string(params[0])]; !ok {
+		return nil, errors.New("unknown monitor")
+	}
+	delete(sess.monitors, string(params[0]))
+	return struct{}{}, nil
+}
+
+// monitorCondChange replaces the conditions of the monitor, the rows entering or leaving
+// the conditions are notified as inserted or deleted.
+func (sess *session) monitorCondChange(params []json.RawMessage) (any, error) {
+	if len(params) < 3 {
+		return nil, errors.New("missing params")
+	}
+	var tReqs map[string][]struct {
+		Columns json.RawMessage   `json:"columns"`
+		Where   []json.RawMessage `json:"where"`
+	}
+	if err := param(params, 2, &tReqs); err != nil {
+		return nil, err
+	}
+	s := sess.srv
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := sess.monitors[string(params[0])]
+	if !ok {
+		return nil, errors.New("unknown monitor")
+	}
+	if m.method == methodUpdate {
+		return nil, errors.New("not a conditional monitor")
+	}
+	if newID := string(params[1]); newID != string(params[0]) {
+		if _, ok := sess.monitors[newID]; ok {
+			return nil, errors.New("duplicate monitor ID")
+		}
+	}
+	reqs := make(map[string][]monReq, len(m.reqs))
+	for tName, tr := range m.reqs {
+		reqs[tName] = slices.Clone(tr)
+	}
+	for tName, changes := range tReqs {
+		if len(changes) != len(reqs[tName]) {
+			return nil, fmt.Errorf("invalid condition change of table %s", tName)
+		}
+		for i, c := range changes {
+			if c.Columns != nil {
+				return nil, errors.New("syntax error")
+			}
+			reqs[tName][i].Where = c.Where
+		}
+	}
+
+	upd := make(tableUpdates)
+	for tName := range tReqs {
+		for _, u := range m.db.data.FindRecord(tName, nil) {
+			row, err := rowCopy(m.db.data, tName, u)
+			if err != nil {
+				return nil, err
+			}
+			var before, after bool
+			for i, r := range reqs[tName] {
+				was, err := m.reqs[tName][i].matches(row)
+				if err != nil {
+					return nil, err
+				}
+				is, err := r.matches(row)
+				if err != nil {
+					return nil, err
+				}
+				before, after = before || was, after || is
+				if !was && is {
+					upd.add(tName, u, rowUpdate{"insert": columns(row, r.Columns)})
+				}
+			}
+			if before && !after {
+				upd.add(tName, u, rowUpdate{"delete": nil})
+			}
+		}
+	}
+	delete(sess.monitors, string(params[0]))
+	m.id, m.reqs = params[1], reqs
+	sess.monitors[string(m.id)] = m
+	if len(upd) > 0 {
+		if m.method == methodUpdate3 {
+			sess.notify(m.method, m.id, m.db.lastTxn, upd)
+		} else {
+			sess.notify(m.method, m.id, upd)
+		}
+	}
+	return struct{}{}, nil
+}
+
+// parseRequests decodes <monitor-requests>, the request of the table is a single one or an array.
+func (m *dbMonitor) parseRequests(raw json.RawMessage) error {
+	var tReqs map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &tReqs); err != nil {
+		return fmt.Errorf("invalid monitor requests: %w", err)
+	}
+	m.reqs = make(map[string][]monReq, len(tReqs))
+	for tName, tRaw := range tReqs {
+		tSch, ok := m.db.sch.Tables[tName]
+		if !ok {
+			return fmt.Errorf("unknown table %s", tName)
+		}
+		var reqs []monReq
+		if err := json.Unmarshal(tRaw, &reqs); err != nil {
+			var r monReq
+			if err := json.Unmarshal(tRaw, &r); err != nil {
+				return fmt.Errorf("invalid monitor request of table %s: %w", tName, err)
+			}
+			reqs = []monReq{r}
+		}
+		for i := range reqs {
+			r := &reqs[i]
+			if r.Columns == nil {
+				// all columns except _uuid (RFC 7047 4.1.5)
+				for cName := range tSch.Columns {
+					if cName != "_uuid" {
+						r.Columns = append(r.Columns, cName)
+					}
+				}
+				slices.Sort(r.Columns)
+			}
+			for _, cName := range r.Columns {
+				if _, ok := tSch.Columns[cName]; !ok {
+					return fmt.Errorf("unknown column %s of table %s", cName, tName)
+				}
+			}
+			if r.Select == nil {
+				r.Select = &monitor.Select{Initial: true, Insert: true, Delete: true, Modify: true}
+			}
+		}
+		m.reqs[tName] = reqs
+	}
+	return nil
+}
+
+// initial returns the current content of the monitored tables selected as initial.
+func (m *dbMonitor) initial() (tableUpdates, error) {
+	upd := make(tableUpdates)
+	kind := "initial"
+	if m.method == methodUpdate {
+		kind = "new"
+	}
+	for tName, reqs := range m.reqs {
+		for _, u := range m.db.data.FindRecord(tName, nil) {
+			row, err := rowCopy(m.db.data, tName, u)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range reqs {
+				if !r.Select.Initial {
+					continue
+				}
+				ok, err := r.matches(row)
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					upd.add(tName, u, rowUpdate{kind: columns(row, r.Columns)})
+				}
+			}
+		}
+	}
+	return upd, nil
+}
+
+// changes returns the updates of the rows changed as seen by the monitor.
+func (m *dbMonitor) changes(changes changeSet) (tableUpdates, error) {
+	upd := make(tableUpdates)
+	for tName, reqs := range m.reqs {
+		for u, c := range changes[tName] {
+			for _, r := range reqs {
+				rUpd, err := r.update(m.method, c)
+				if err != nil {
+					return nil, err
+				}
+				upd.add(tName, u, rUpd)
+			}
+		}
+	}
+	return upd, nil
+}
+
+// matches reports whether the row exists and satisfies the condition of the request.
+func (r *monReq) matches(row schema.Row) (bool, error) {
+	if row == nil {
+		return false, nil
+	}
+	return engine.Match(row, r.Where)
+}
+
+// update returns the update of the row changed as seen by the request, nil if it is not seen.
+// The row entering or leaving the condition is seen as inserted or deleted.
+func (r *monReq) update(method string, c change) (rowUpdate, error) {
+	before, err := r.matches(c.before)
+	if err != nil {
+		return nil, err
+	}
+	after, err := r.matches(c.after)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case !before && after && r.Select.Insert:
+		if method == methodUpdate {
+			return rowUpdate{"new": columns(c.after, r.Columns)}, nil
+		}
+		return rowUpdate{"insert": columns(c.after, r.Columns)}, nil
+	case before && !after && r.Select.Delete:
+		if method == methodUpdate {
+			return rowUpdate{"old": columns(c.before, r.Columns)}, nil
+		}
+		return rowUpdate{"delete": nil}, nil
+	case before && after && r.Select.Modify:
+		diff, err := engine.RowDiff(c.before, c.after)
+		if err != nil {
+			return nil, err
+		}
+		// RowDiff skips the synthetic columns, the new _version is sent as is
+		if ver := c.after.Get("_version"); ver != c.before.Get("_version") {
+			if diff == nil {
+				diff = c.after.TableSchema().NewRow()
+			}
+			diff.Set("_version", ver)
+		}
+		if diff == nil {
+			return nil, nil
+		}
+		var changed []string
+		for _, cName := range r.Columns {
+			if _, ok := diff.GetE(cName); ok {
+				changed = append(changed, cName)
+			}
+		}
+		if len(changed) == 0 {
+			return nil, nil
+		}
+		if method == methodUpdate {
+			return rowUpdate{"old": columns(c.before, changed), "new": columns(c.after, r.Columns)}, nil
+		}
+		return rowUpdate{"modify": columns(diff, changed)}, nil
+	}
+	return nil, nil
+}
+
+// since returns the changes made after the transaction txnID merged by the rows,
+// false if the transaction is not remembered.
+func (d *database) since(txnID string) (changeSet, bool) {
+	if txnID == d.lastTxn {
+		return changeSet{}, true
+	}
+	idx := slices.IndexFunc(d.txns, func(r txnRecord) bool { return r.id == txnID })
+	if idx < 0 {
+		return nil, false
+	}
+	merged := make(changeSet)
+	for _, rec := range d.txns[idx+1:] {
+		for tName, tChanges := range rec.changes {
+			if merged[tName] == nil {
+				merged[tName] = make(map[string]change)
+			}
+			for u, c := range tChanges {
+				if prev, ok := merged[tName][u]; ok {
+					c.before = prev.before
+				}
+				merged[tName][u] = c
+			}
+		}
+	}
+	return merged, true
+}
+
+// columns returns the values of the columns of the row, the default ones for the columns not set.
+func columns(row schema.Row, cNames []string) map[string]any {
+	tSch := row.TableSchema()
+	vals := make(map[string]any, len(cNames))
+	for _, cName := range cNames {
+		if v, ok := row.GetE(cName); ok && v != nil {
+			vals[cName] = v
+		} else {
+			vals[cName] = tSch.Columns[cName].GetDefaultValue()
+		}
+	}
+	return vals
+}
+
+// rowCopy returns the copy of the row of the table holding its _uuid, nil if there is no such row.
+func rowCopy(d db.DB, tName, uuid string) (schema.Row, error) {
+	row := d.TableRowS(tName, uuid)
+	if row == nil {
+		return nil, nil
+	}
+	data, err := row.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("table %s: row %s: %w", tName, uuid, err)
+	}
+	c := d.TableSchema(tName).NewRow()
+	if err := c.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("table %s: row %s: %w", tName, uuid, err)
+	}
+	c.Set("_uuid", types.UUID(uuid))
+	return c, nil
+}
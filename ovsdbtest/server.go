@@ -0,0 +1,308 @@
+// Package ovsdbtest provides the in-memory OVSDB server to test the clients end to end.
+// The server serves JSON-RPC connections over net.Pipe (see Server.Dial) or a listener,
+// e.g. unix socket (see Server.Serve). The transactions are executed by engine package,
+// the ones blocked by the wait operation are executed again on each commit until the wait
+// is met, times out or the transaction is canceled by cancel notification. The monitors
+// (monitor, monitor_cond and monitor_cond_since) are notified of the changes and canceled
+// on convert. The _version of the row changes only when the row is changed, it is served
+// by select and wait, and by the monitors of all columns as ovsdb-server does.
+// Hooks allow to inject the errors into the requests and Disconnect drops the connections,
+// so the recovery of the clients may be tested.
+package ovsdbtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/db"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/transact"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHistory       = 100
+	defaultNotifyTimeout = 5 * time.Second
+)
+
+// ErrServerClosed is returned by Serve and Dial after Close.
+var ErrServerClosed = errors.New("ovsdbtest: server closed")
+
+// Hook is called before the request of the method is served with the raw params of the request.
+// If it returns the error, the request fails with it (the cancel notification is ignored).
+// The hook may also delay the request or call Server.Disconnect.
+type Hook func(method string, params []json.RawMessage) error
+
+// ServerOpt is an option of NewServer.
+type ServerOpt func(s *Server)
+
+// WithDatabase adds one more database to serve.
+func WithDatabase(sch *schema.DbSchema) ServerOpt {
+	return func(s *Server) {
+		s.addDatabase(sch)
+	}
+}
+
+// WithHistory sets the number of the transactions remembered for monitor_cond_since, 100 by default.
+func WithHistory(n int) ServerOpt {
+	return func(s *Server) {
+		s.history = n
+	}
+}
+
+// WithLogger sets the logger of the server and its JSON-RPC connections.
+func WithLogger(log *slog.Logger) ServerOpt {
+	return func(s *Server) {
+		s.log = log
+	}
+}
+
+// WithHook sets the hook called before serving each request, see Hook.
+func WithHook(h Hook) ServerOpt {
+	return func(s *Server) {
+		s.hook = h
+	}
+}
+
+// Server is the in-memory OVSDB server.
+type Server struct {
+	log     *slog.Logger
+	history int
+	id      string // reported by get_server_id
+
+	mu        sync.Mutex
+	names     []string
+	dbs       map[string]*database
+	sessions  map[*session]struct{}
+	locks     map[string]*lockState
+	listeners []net.Listener
+	hook      Hook
+	closed    bool
+}
+
+// database is the served database with the history of its transactions.
+type database struct {
+	sch     *schema.DbSchema
+	data    db.DB
+	txns    []txnRecord // the latest is the last
+	lastTxn string
+	changed chan struct{} // closed on the change of the content, see commit
+}
+
+// commit wakes up the transactions blocked on the content of the database. Server.mu must be held.
+func (d *database) commit() {
+	close(d.changed)
+	d.changed = make(chan struct{})
+}
+
+// NewServer creates the Server of the empty database of schema sch.
+func NewServer(sch *schema.DbSchema, opts ...ServerOpt) *Server {
+	s := Server{
+		log:      slog.Default(),
+		history:  defaultHistory,
+		id:       newTxnID(),
+		dbs:      make(map[string]*database),
+		sessions: make(map[*session]struct{}),
+		locks:    make(map[string]*lockState),
+	}
+	s.addDatabase(sch)
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return &s
+}
+
+func (s *Server) addDatabase(sch *schema.DbSchema) {
+	if _, ok := s.dbs[sch.Name]; !ok {
+		s.names = append(s.names, sch.Name)
+	}
+	s.dbs[sch.Name] = &database{sch: sch, data: db.NewDB(sch), lastTxn: newTxnID(), changed: make(chan struct{})}
+}
+
+// SetHook replaces the hook called before serving each request, nil removes it.
+func (s *Server) SetHook(h Hook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hook = h
+}
+
+func (s *Server) getHook() Hook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hook
+}
+
+// Dial connects to the server over net.Pipe, it may be used as client.Dialer.
+func (s *Server) Dial(ctx context.Context) (net.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	cliConn, srvConn := net.Pipe()
+	if err := s.ServeConn(srvConn); err != nil {
+		_ = cliConn.Close()
+		return nil, err
+	}
+	return cliConn, nil
+}
+
+// Serve accepts the connections on ln and serves them until Close is called.
+// It returns ErrServerClosed after Close or the error of Accept.
+func (s *Server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrServerClosed
+	}
+	s.listeners = append(s.listeners, ln)
+	s.mu.Unlock()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+		if err := s.ServeConn(conn); err != nil {
+			return err
+		}
+	}
+}
+
+// ServeConn serves the connection conn in background until it is closed by either side.
+func (s *Server) ServeConn(conn net.Conn) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		_ = conn.Close()
+		return ErrServerClosed
+	}
+	sess, err := newSession(s, conn)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	s.sessions[sess] = struct{}{}
+	go func() {
+		<-sess.jConn.Done()
+		s.drop(sess)
+	}()
+	return nil
+}
+
+// Disconnect drops all the connections, the clients see them closed by the server.
+func (s *Server) Disconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sess := range s.sessions {
+		_ = sess.conn.Close()
+	}
+}
+
+// Close stops the listeners and drops all the connections.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for _, ln := range s.listeners {
+		_ = ln.Close()
+	}
+	s.listeners = nil
+	for sess := range s.sessions {
+		_ = sess.conn.Close()
+	}
+	return nil
+}
+
+// drop forgets the monitors and releases the locks of the closed session.
+func (s *Server) drop(sess *session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, sess)
+	for id := range sess.locks {
+		s.unlock(sess, id)
+	}
+	for _, l := range s.locks {
+		l.removeWaiter(sess)
+	}
+}
+
+// ID returns the server ID reported by get_server_id.
+func (s *Server) ID() string {
+	return s.id
+}
+
+// DB returns the content of the database name, nil if there is no such database.
+// It must not be modified, use Transact to change it.
+func (s *Server) DB(name string) db.DB {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d, ok := s.dbs[name]; ok {
+		return d.data
+	}
+	return nil
+}
+
+// LastTxnID returns the id of the last transaction of the database name, as reported
+// to monitor_cond_since.
+func (s *Server) LastTxnID(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d, ok := s.dbs[name]; ok {
+		return d.lastTxn
+	}
+	return ""
+}
+
+// Transact executes the transaction tr on the database dbName as if it was sent by a client,
+// so the monitors are notified of the changes. The results are decoded into tr.
+func (s *Server) Transact(dbName string, tr transact.Transaction) error {
+	if err := tr.Validate(); err != nil {
+		return err
+	}
+	ops := make([]json.RawMessage, 0, tr.Len())
+	for _, op := range tr.Operations() {
+		raw, err := json.Marshal(op)
+		if err != nil {
+			return fmt.Errorf("encode operation %q: %w", op.Name(), err)
+		}
+		ops = append(ops, raw)
+	}
+
+	s.mu.Lock()
+	d, ok := s.dbs[dbName]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("unknown database %q", dbName)
+	}
+	// the blocked transaction fails at once
+	res, _, err := s.execute(d, ops, nil)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := tr.DecodeResult(res); err != nil {
+		return err
+	}
+	return tr.Error()
+}
+
+// newTxnID returns the random UUID identifying the transaction.
+func newTxnID() string {
+	var b [16]byte
+	for i := range b {
+		b[i] = byte(rand.UintN(256))
+	}
+	b[6] = (b[6] & 0x0f) | (4 << 4)
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,383 @@
+package ovsdbtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	jrpc "github.com/kazmanavt/jsonrpc/v2/jrpc1"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/transact"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testSchema = `{
+  "name": "Test",
+  "version": "1.0.0",
+  "tables": {
+    "T": {
+      "columns": {
+        "name": {"type": "string"},
+        "x": {"type": "integer"}
+      }
+    }
+  }
+}`
+
+type update3 struct {
+	id, txnID string
+	upd       json.RawMessage
+}
+
+func newTestServer(t *testing.T, opts ...ServerOpt) (*Server, *schema.DbSchema) {
+	var sch schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &sch))
+	s := NewServer(&sch, opts...)
+	t.Cleanup(func() { _ = s.Close() })
+	return s, &sch
+}
+
+// connect returns the raw JSON-RPC connection to s passing update3 notifications to the channel.
+func connect(t *testing.T, s *Server) (*jrpc.ClientConn, <-chan update3) {
+	conn, err := s.Dial(context.Background())
+	require.NoError(t, err)
+	jc := jrpc.NewConnection(conn, nil)
+	t.Cleanup(func() { _ = jc.Close() })
+	updates := make(chan update3, 10)
+	require.NoError(t, jc.HandleNotification("update3", func(id, txnID string, upd json.RawMessage) {
+		updates <- update3{id: id, txnID: txnID, upd: upd}
+	}))
+	return jc, updates
+}
+
+func call(t *testing.T, jc *jrpc.ClientConn, method string, params ...any) json.RawMessage {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := jc.Call(ctx, method, params...)
+	require.NoError(t, err)
+	require.NoError(t, resp.Error())
+	return resp.GetResult()
+}
+
+func insert(t *testing.T, s *Server, sch *schema.DbSchema, name string, x int) types.UUID {
+	tr := transact.NewTransaction(sch)
+	tr.Insert(sch.Tables["T"].NewRow("name", name, "x", x))
+	require.NoError(t, s.Transact("Test", tr))
+	u, err := tr.Results().Inserted(0)
+	require.NoError(t, err)
+	return u
+}
+
+func TestServer_Basic(t *testing.T) {
+	s, _ := newTestServer(t)
+	jc, _ := connect(t, s)
+
+	assert.JSONEq(t, `["Test"]`, string(call(t, jc, "list_dbs")))
+	var sch schema.DbSchema
+	require.NoError(t, json.Unmarshal(call(t, jc, "get_schema", "Test"), &sch))
+	assert.Contains(t, sch.Tables, "T")
+	assert.JSONEq(t, `["hi"]`, string(call(t, jc, "echo", "hi")))
+
+	res := call(t, jc, "transact", "Test",
+		map[string]any{"op": "insert", "table": "T", "row": map[string]any{"name": "a", "x": 1}},
+		map[string]any{"op": "select", "table": "T", "where": []any{}, "columns": []string{"name", "x"}})
+	var results []json.RawMessage
+	require.NoError(t, json.Unmarshal(res, &results))
+	require.Len(t, results, 2)
+	assert.JSONEq(t, `{"rows":[{"name":"a","x":1}]}`, string(results[1]))
+	assert.Equal(t, 1, s.DB("Test").TableLen("T"))
+}
+
+func TestServer_MonitorCondSince(t *testing.T) {
+	s, sch := newTestServer(t)
+	jc, updates := connect(t, s)
+	first := insert(t, s, sch, "a", 1)
+
+	var res []json.RawMessage
+	require.NoError(t, json.Unmarshal(call(t, jc, "monitor_cond_since", "Test", "m",
+		map[string]any{"T": map[string]any{"columns": []string{"name", "x"}, "where": [][]any{{"x", ">", 0}}}}, types.ZeroUUID), &res))
+	require.Len(t, res, 3)
+	assert.JSONEq(t, `false`, string(res[0]))
+	assert.JSONEq(t, `{"T":{"`+string(first)+`":{"initial":{"name":"a","x":1}}}}`, string(res[2]))
+	var since string
+	require.NoError(t, json.Unmarshal(res[1], &since))
+
+	second := insert(t, s, sch, "b", 2)
+	select {
+	case u := <-updates:
+		assert.Equal(t, "m", u.id)
+		assert.Equal(t, s.LastTxnID("Test"), u.txnID)
+		assert.JSONEq(t, `{"T":{"`+string(second)+`":{"insert":{"name":"b","x":2}}}}`, string(u.upd))
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "update3 is not received")
+	}
+
+	// the row leaving the condition is seen deleted
+	tr := transact.NewTransaction(sch)
+	tr.Update([]types.Condition{types.Equal("name", "a")}, sch.Tables["T"].NewRow("x", 0))
+	tr.Update([]types.Condition{types.Equal("name", "b")}, sch.Tables["T"].NewRow("x", 3))
+	require.NoError(t, s.Transact("Test", tr))
+	select {
+	case u := <-updates:
+		assert.JSONEq(t, `{"T":{"`+string(first)+`":{"delete":null},"`+string(second)+`":{"modify":{"x":3}}}}`, string(u.upd))
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "update3 is not received")
+	}
+
+	// another client resumes from the first transaction seen
+	jc2, _ := connect(t, s)
+	require.NoError(t, json.Unmarshal(call(t, jc2, "monitor_cond_since", "Test", "m",
+		map[string]any{"T": map[string]any{"columns": []string{"name", "x"}, "where": [][]any{{"x", ">", 0}}}}, since), &res))
+	assert.JSONEq(t, `true`, string(res[0]))
+	assert.JSONEq(t, `"`+s.LastTxnID("Test")+`"`, string(res[1]))
+	assert.JSONEq(t, `{"T":{"`+string(first)+`":{"delete":null},"`+string(second)+`":{"insert":{"name":"b","x":3}}}}`, string(res[2]))
+
+	call(t, jc, "monitor_cancel", "m")
+	insert(t, s, sch, "c", 4)
+	select {
+	case u := <-updates:
+		assert.Fail(t, "update of canceled monitor", "%s", u.upd)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestServer_Version(t *testing.T) {
+	s, sch := newTestServer(t)
+	jc, updates := connect(t, s)
+	u := insert(t, s, sch, "a", 1)
+
+	selectVersion := func() string {
+		t.Helper()
+		var res []struct {
+			Rows []struct {
+				Version []string `json:"_version"`
+			} `json:"rows"`
+		}
+		require.NoError(t, json.Unmarshal(call(t, jc, "transact", "Test",
+			map[string]any{"op": "select", "table": "T", "where": []any{}, "columns": []string{"_version"}}), &res))
+		require.Len(t, res, 1)
+		require.Len(t, res[0].Rows, 1)
+		return res[0].Rows[0].Version[1]
+	}
+	type versions map[string]map[string]map[string]struct {
+		Version []string `json:"_version"`
+	}
+
+	// the monitor without columns gets _version (RFC 7047 4.1.5)
+	var res []json.RawMessage
+	require.NoError(t, json.Unmarshal(call(t, jc, "monitor_cond_since", "Test", "m",
+		map[string]any{"T": map[string]any{}}, types.ZeroUUID), &res))
+	require.Len(t, res, 3)
+	var initial versions
+	require.NoError(t, json.Unmarshal(res[2], &initial))
+	v1 := selectVersion()
+	assert.Equal(t, []string{"uuid", v1}, initial["T"][string(u)]["initial"].Version)
+	assert.Equal(t, v1, selectVersion(), "the version of the unchanged row is stable")
+
+	tr := transact.NewTransaction(sch)
+	tr.Update([]types.Condition{types.Equal("name", "a")}, sch.Tables["T"].NewRow("x", 2))
+	require.NoError(t, s.Transact("Test", tr))
+	v2 := selectVersion()
+	assert.NotEqual(t, v1, v2)
+	select {
+	case upd := <-updates:
+		var modify versions
+		require.NoError(t, json.Unmarshal(upd.upd, &modify))
+		assert.Equal(t, []string{"uuid", v2}, modify["T"][string(u)]["modify"].Version)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "update3 is not received")
+	}
+}
+
+func TestServer_HistoryLimit(t *testing.T) {
+	s, sch := newTestServer(t, WithHistory(1))
+	jc, _ := connect(t, s)
+	insert(t, s, sch, "a", 1)
+	since := s.LastTxnID("Test")
+	insert(t, s, sch, "b", 2)
+	insert(t, s, sch, "c", 3)
+
+	var res []json.RawMessage
+	require.NoError(t, json.Unmarshal(call(t, jc, "monitor_cond_since", "Test", "m", map[string]any{"T": map[string]any{}}, since), &res))
+	assert.JSONEq(t, `false`, string(res[0]))
+	var upd map[string]map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(res[2], &upd))
+	assert.Len(t, upd["T"], 3)
+}
+
+func TestServer_Hook(t *testing.T) {
+	s, _ := newTestServer(t, WithHook(func(method string, _ []json.RawMessage) error {
+		if method == "transact" {
+			return errors.New("injected")
+		}
+		return nil
+	}))
+	jc, _ := connect(t, s)
+
+	resp, err := jc.Call(context.Background(), "transact", "Test")
+	require.NoError(t, err)
+	require.Error(t, resp.Error())
+	assert.Contains(t, resp.Error().Error(), "injected")
+
+	s.SetHook(nil)
+	call(t, jc, "transact", "Test")
+
+	s.Disconnect()
+	select {
+	case <-jc.Done():
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "connection is not dropped")
+	}
+}
+
+func TestServer_Wait(t *testing.T) {
+	s, sch := newTestServer(t)
+	jc, _ := connect(t, s)
+	wait := func(until string, timeout int) map[string]any {
+		return map[string]any{"op": "wait", "table": "T", "where": []any{}, "columns": []string{"name"},
+			"until": until, "rows": []any{map[string]any{"name": "a"}}, "timeout": timeout}
+	}
+
+	// blocked until the condition is met
+	done := make(chan json.RawMessage)
+	go func() {
+		done <- call(t, jc, "transact", "Test", wait("==", 5000))
+	}()
+	select {
+	case <-done:
+		require.FailNow(t, "wait is not blocked")
+	case <-time.After(50 * time.Millisecond):
+	}
+	insert(t, s, sch, "b", 1)
+	select {
+	case <-done:
+		require.FailNow(t, "wait is not blocked by the other change")
+	case <-time.After(50 * time.Millisecond):
+	}
+	tr := transact.NewTransaction(sch)
+	tr.Update([]types.Condition{types.Equal("name", "b")}, sch.Tables["T"].NewRow("name", "a"))
+	require.NoError(t, s.Transact("Test", tr))
+	select {
+	case res := <-done:
+		assert.JSONEq(t, `[{}]`, string(res))
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "wait is not finished")
+	}
+
+	// timed out
+	start := time.Now()
+	res := call(t, jc, "transact", "Test", wait("!=", 0), map[string]any{"op": "comment", "comment": "-"})
+	assert.Contains(t, string(res), "timed out")
+	res = call(t, jc, "transact", "Test", wait("!=", 100), map[string]any{"op": "delete", "table": "T", "where": []any{}})
+	assert.Contains(t, string(res), "timed out")
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	// the delete is not committed
+	assert.Equal(t, 1, s.DB("Test").TableLen("T"))
+}
+
+func TestServer_Cancel(t *testing.T) {
+	s, _ := newTestServer(t)
+	conn, err := s.Dial(context.Background())
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+
+	// the raw requests, as the ids of jrpc requests are not known
+	enc, dec := json.NewEncoder(conn), json.NewDecoder(conn)
+	require.NoError(t, enc.Encode(map[string]any{"id": "txn-1", "method": "transact", "params": []any{"Test",
+		map[string]any{"op": "wait", "table": "T", "where": []any{}, "columns": []string{"name"},
+			"until": "!=", "rows": []any{}}}}))
+	require.NoError(t, enc.Encode(map[string]any{"id": nil, "method": "cancel", "params": []any{"txn-1"}}))
+	var resp struct {
+		ID    string          `json:"id"`
+		Error json.RawMessage `json:"error"`
+	}
+	require.NoError(t, dec.Decode(&resp))
+	assert.Equal(t, "txn-1", resp.ID)
+	assert.JSONEq(t, `"canceled"`, string(resp.Error))
+}
+
+func TestServer_Lock(t *testing.T) {
+	s, _ := newTestServer(t)
+	jc1, _ := connect(t, s)
+	jc2, _ := connect(t, s)
+	locked := make(chan string, 1)
+	require.NoError(t, jc2.HandleNotification("locked", func(id string) { locked <- id }))
+
+	assert.JSONEq(t, `{"locked":true}`, string(call(t, jc1, "lock", "l")))
+	assert.JSONEq(t, `{"locked":false}`, string(call(t, jc2, "lock", "l")))
+	call(t, jc1, "unlock", "l")
+	select {
+	case id := <-locked:
+		assert.Equal(t, "l", id)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "lock is not passed")
+	}
+}
+
+func TestServer_Serve(t *testing.T) {
+	s, _ := newTestServer(t)
+	ln, err := net.Listen("unix", filepath.Join(t.TempDir(), "db.sock"))
+	require.NoError(t, err)
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(ln) }()
+
+	conn, err := net.Dial("unix", ln.Addr().String())
+	require.NoError(t, err)
+	jc := jrpc.NewConnection(conn, nil)
+	defer jc.Close()
+	assert.JSONEq(t, `["Test"]`, string(call(t, jc, "list_dbs")))
+
+	require.NoError(t, s.Close())
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, ErrServerClosed)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "Serve does not return")
+	}
+}
+
+func TestServer_Convert(t *testing.T) {
+	s, sch := newTestServer(t)
+	u := insert(t, s, sch, "a", 1)
+	jc, _ := connect(t, s)
+	canceled := make(chan string, 1)
+	require.NoError(t, jc.HandleNotification("monitor_canceled", func(id string) { canceled <- id }))
+	call(t, jc, "set_db_change_aware", true)
+	call(t, jc, "monitor_cond", "Test", "m", map[string]any{"T": map[string]any{}})
+
+	// the rows violating the new type of the column fail the conversion
+	var bad schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &bad))
+	bad.Tables["T"].Columns["name"].Type.Key.Type = "integer"
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := jc.Call(ctx, "convert", "Test", &bad)
+	require.NoError(t, err)
+	assert.Error(t, resp.Error())
+
+	var next schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &next))
+	next.Version = "1.1.0"
+	delete(next.Tables["T"].Columns, "name")
+	var added schema.TableSchema
+	require.NoError(t, json.Unmarshal([]byte(`{"columns": {"y": {"type": "integer"}}}`), &added))
+	next.Tables["New"] = &added
+	call(t, jc, "convert", "Test", &next)
+	select {
+	case id := <-canceled:
+		assert.Equal(t, "m", id)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "monitor_canceled is not received")
+	}
+	var got schema.DbSchema
+	require.NoError(t, json.Unmarshal(call(t, jc, "get_schema", "Test"), &got))
+	assert.Equal(t, "1.1.0", got.Version)
+	assert.NotContains(t, got.Tables["T"].Columns, "name")
+	assert.Equal(t, 1, s.DB("Test").Get("T", u, "x"))
+}
@@ -0,0 +1,345 @@
+package ovsdbtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	jrpc "github.com/kazmanavt/jsonrpc/v2/jrpc1"
+	"github.com/kazmanavt/ovsdb/v2/internal/engine"
+	"log/slog"
+	"net"
+	"slices"
+	"time"
+)
+
+// session is the connection of a client.
+type session struct {
+	srv   *Server
+	conn  net.Conn
+	jConn *jrpc.ClientConn
+	// monitors, locks, pending and dbChangeAware are guarded by Server.mu
+	monitors      map[string]*dbMonitor    // by the JSON of monitor id
+	locks         map[string]bool          // locks owned
+	pending       map[string]chan struct{} // transactions by the JSON of request id, closed on cancel
+	dbChangeAware bool
+}
+
+// gatedConn holds the reads until the handlers are set up, as the requests without handler
+// are dropped silently. The handlers don't know the request ids, but cancel refers
+// the transaction by id, so the id of transact request is put before its params.
+type gatedConn struct {
+	net.Conn
+	ready chan struct{}
+	dec   *json.Decoder
+	buf   bytes.Buffer
+}
+
+func (c *gatedConn) Read(p []byte) (int, error) {
+	<-c.ready
+	for c.buf.Len() == 0 {
+		var msg map[string]json.RawMessage
+		if err := c.dec.Decode(&msg); err != nil {
+			return 0, err
+		}
+		var params []json.RawMessage
+		if string(msg["method"]) == `"transact"` && json.Unmarshal(msg["params"], &params) == nil {
+			msg["params"], _ = json.Marshal(append([]json.RawMessage{msg["id"]}, params...))
+		}
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return 0, err
+		}
+		c.buf.Write(data)
+	}
+	return c.buf.Read(p)
+}
+
+func newSession(s *Server, conn net.Conn) (*session, error) {
+	gc := &gatedConn{Conn: conn, ready: make(chan struct{}), dec: json.NewDecoder(conn)}
+	defer close(gc.ready)
+	sess := &session{
+		srv:      s,
+		conn:     conn,
+		jConn:    jrpc.NewConnection(gc, s.log),
+		monitors: make(map[string]*dbMonitor),
+		locks:    make(map[string]bool),
+		pending:  make(map[string]chan struct{}),
+	}
+	handlers := map[string]func(params []json.RawMessage) (any, error){
+		"list_dbs":            sess.listDbs,
+		"get_schema":          sess.getSchema,
+		"transact":            sess.transact,
+		"cancel":              sess.cancel,
+		"monitor":             sess.monitor(methodUpdate),
+		"monitor_cond":        sess.monitor(methodUpdate2),
+		"monitor_cond_since":  sess.monitor(methodUpdate3),
+		"monitor_cancel":      sess.monitorCancel,
+		"monitor_cond_change": sess.monitorCondChange,
+		"lock":                sess.lock,
+		"steal":               sess.steal,
+		"unlock":              sess.unlockReq,
+		"echo":                sess.echo,
+		"get_server_id":       sess.getServerID,
+		"set_db_change_aware": sess.setDbChangeAware,
+		"convert":             sess.convert,
+	}
+	for method, fn := range handlers {
+		if err := sess.handle(method, fn); err != nil {
+			return nil, fmt.Errorf("setup %q handler: %w", method, err)
+		}
+	}
+	// cancel is the notification
+	if err := sess.jConn.HandleNotification("cancel", func(params ...json.RawMessage) {
+		if hook := sess.srv.getHook(); hook != nil && hook("cancel", params) != nil {
+			return
+		}
+		_, _ = sess.cancel(params)
+	}); err != nil {
+		return nil, fmt.Errorf("setup %q handler: %w", "cancel", err)
+	}
+	return sess, nil
+}
+
+// handle sets up the handler of the method calling the hook before fn.
+func (sess *session) handle(method string, fn func(params []json.RawMessage) (any, error)) error {
+	return sess.jConn.HandleCall(method, func(params ...json.RawMessage) (json.RawMessage, error) {
+		hookParams := params
+		if method == "transact" && len(params) > 0 {
+			// without the request id put by gatedConn
+			hookParams = params[1:]
+		}
+		if hook := sess.srv.getHook(); hook != nil {
+			if err := hook(method, hookParams); err != nil {
+				return nil, err
+			}
+		}
+		res, err := fn(params)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(res)
+	})
+}
+
+// notify sends the notification to the client, Server.mu must be held to keep the order.
+func (sess *session) notify(method string, params ...any) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultNotifyTimeout)
+	defer cancel()
+	select {
+	case <-sess.jConn.Done():
+		return
+	default:
+	}
+	if err := sess.jConn.Notify(ctx, method, params...); err != nil {
+		sess.srv.log.Warn("ovsdbtest: fail to notify client", slog.String("method", method), slog.Any("error", err))
+	}
+}
+
+// param decodes the i-th param into v.
+func param(params []json.RawMessage, i int, v any) error {
+	if i >= len(params) {
+		return fmt.Errorf("missing param #%d", i)
+	}
+	if err := json.Unmarshal(params[i], v); err != nil {
+		return fmt.Errorf("invalid param #%d: %w", i, err)
+	}
+	return nil
+}
+
+// database returns the database named by the i-th param, Server.mu must be held.
+func (sess *session) database(params []json.RawMessage, i int) (*database, error) {
+	var name string
+	if err := param(params, i, &name); err != nil {
+		return nil, err
+	}
+	d, ok := sess.srv.dbs[name]
+	if !ok {
+		return nil, errors.New("unknown database")
+	}
+	return d, nil
+}
+
+func (sess *session) listDbs([]json.RawMessage) (any, error) {
+	sess.srv.mu.Lock()
+	defer sess.srv.mu.Unlock()
+	return slices.Clone(sess.srv.names), nil
+}
+
+func (sess *session) getSchema(params []json.RawMessage) (any, error) {
+	sess.srv.mu.Lock()
+	defer sess.srv.mu.Unlock()
+	d, err := sess.database(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	return d.sch, nil
+}
+
+// transact executes the transaction. If it is blocked by the wait operation (see engine.ExecuteBlocking),
+// it is executed again on each commit to the database until it is not blocked, the timeout
+// of the wait expires, it is canceled or the session is closed.
+func (sess *session) transact(params []json.RawMessage) (any, error) {
+	// the request id is put by gatedConn
+	id, params := string(compact(params[0])), params[1:]
+	s := sess.srv
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, err := sess.database(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	canceled, ok := sess.pending[id]
+	if !ok {
+		canceled = make(chan struct{})
+		sess.pending[id] = canceled
+	}
+	defer delete(sess.pending, id)
+
+	var timeout <-chan time.Time
+	for {
+		locks := make([]string, 0, len(sess.locks))
+		for lock := range sess.locks {
+			locks = append(locks, lock)
+		}
+		res, blocked, err := s.execute(d, params[1:], locks)
+		if err != nil || blocked == nil {
+			return res, err
+		}
+		if timeout == nil && blocked.Timeout > 0 {
+			timeout = time.After(blocked.Timeout)
+		}
+		changed := d.changed
+		s.mu.Unlock()
+		select {
+		case <-changed:
+			s.mu.Lock()
+		case <-timeout:
+			s.mu.Lock()
+			return res, nil
+		case <-canceled:
+			s.mu.Lock()
+			return nil, errors.New("canceled")
+		case <-sess.jConn.Done():
+			s.mu.Lock()
+			return nil, ErrServerClosed
+		}
+	}
+}
+
+// cancel aborts the blocked transaction with the request id given (RFC 7047 4.1.4),
+// it responds with "canceled" error. The transactions executed already are not affected.
+// The handlers are called concurrently, so the cancel may come before its transaction.
+func (sess *session) cancel(params []json.RawMessage) (any, error) {
+	if len(params) == 0 {
+		return nil, errors.New("missing param #0")
+	}
+	id := string(compact(params[0]))
+	sess.srv.mu.Lock()
+	defer sess.srv.mu.Unlock()
+	ch, ok := sess.pending[id]
+	if !ok {
+		ch = make(chan struct{})
+		sess.pending[id] = ch
+	}
+	select {
+	case <-ch:
+	default:
+		close(ch)
+	}
+	return struct{}{}, nil
+}
+
+// compact returns the JSON value without insignificant spaces, so the ids may be compared.
+func compact(raw json.RawMessage) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return raw
+	}
+	return buf.Bytes()
+}
+
+func (sess *session) echo(params []json.RawMessage) (any, error) {
+	return params, nil
+}
+
+func (sess *session) getServerID([]json.RawMessage) (any, error) {
+	return sess.srv.id, nil
+}
+
+// setDbChangeAware sets whether the monitors are canceled (true) or the session is
+// disconnected (false) on convert.
+func (sess *session) setDbChangeAware(params []json.RawMessage) (any, error) {
+	var aware bool
+	if err := param(params, 0, &aware); err != nil {
+		return nil, err
+	}
+	sess.srv.mu.Lock()
+	defer sess.srv.mu.Unlock()
+	sess.dbChangeAware = aware
+	return struct{}{}, nil
+}
+
+// execute executes the operations on the database d by the client holding the locks, commits
+// the changes and notifies the monitors and the blocked transactions. Server.mu must be held.
+func (s *Server) execute(d *database, ops []json.RawMessage, locks []string) (json.RawMessage, *engine.Blocked, error) {
+	res, upd, blocked, err := engine.ExecuteBlocking(d.data, ops, locks)
+	if err != nil || len(upd) == 0 {
+		return res, blocked, err
+	}
+
+	changes := make(changeSet, len(upd))
+	for tName, tUpd := range upd {
+		changes[tName] = make(map[string]change, len(tUpd))
+		for u := range tUpd {
+			before, err := rowCopy(d.data, tName, u)
+			if err != nil {
+				return nil, nil, err
+			}
+			changes[tName][u] = change{before: before}
+		}
+	}
+	if err := d.data.ApplyUpdate2(upd); err != nil {
+		return nil, nil, fmt.Errorf("apply changes: %w", err)
+	}
+	for tName, tChanges := range changes {
+		for u, c := range tChanges {
+			after, err := rowCopy(d.data, tName, u)
+			if err != nil {
+				return nil, nil, err
+			}
+			c.after = after
+			tChanges[u] = c
+		}
+	}
+
+	d.commit()
+	d.lastTxn = newTxnID()
+	d.txns = append(d.txns, txnRecord{id: d.lastTxn, changes: changes})
+	if len(d.txns) > s.history {
+		d.txns = slices.Delete(d.txns, 0, len(d.txns)-s.history)
+	}
+
+	for sess := range s.sessions {
+		for _, m := range sess.monitors {
+			if m.db != d {
+				continue
+			}
+			tUpd, err := m.changes(changes)
+			if err != nil {
+				s.log.Warn("ovsdbtest: fail to build monitor update", slog.Any("error", err))
+				continue
+			}
+			if len(tUpd) == 0 {
+				continue
+			}
+			if m.method == methodUpdate3 {
+				sess.notify(m.method, m.id, d.lastTxn, tUpd)
+			} else {
+				sess.notify(m.method, m.id, tUpd)
+			}
+		}
+	}
+	return res, nil, nil
+}
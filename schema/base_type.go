@@ -40,6 +40,29 @@ func (bt *BaseType) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalJSON implements json.Marshaler, the base type without constraints is written
+// as the atomic type name and the enum is written as the set of Enum values.
+func (bt BaseType) MarshalJSON() ([]byte, error) {
+	if bt.isAtomic() {
+		return json.Marshal(bt.Type)
+	}
+	type BT BaseType
+	out := BT(bt)
+	if bt.Enum != nil {
+		out.EnumRaw = []any{"set", bt.Enum}
+	}
+	return json.Marshal(out)
+}
+
+// isAtomic reports whether the base type is the bare atomic type without constraints.
+func (bt *BaseType) isAtomic() bool {
+	return bt.Enum == nil && bt.EnumRaw == nil &&
+		bt.MinInteger == nil && bt.MaxInteger == nil &&
+		bt.MinReal == nil && bt.MaxReal == nil &&
+		bt.MinLength == nil && bt.MaxLength == nil &&
+		bt.RefTable == nil && bt.RefType == nil
+}
+
 func validateEnumValue[T types.AtomicType](val T, eValues []any) error {
 	for _, e := range eValues {
 		if val == e.(T) {
@@ -0,0 +1,77 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// crcTable is the table of the CRC-32 (polynomial 0x04C11DB7, MSB first) of POSIX cksum.
+var crcTable = func() (tbl [256]uint32) {
+	for i := range tbl {
+		c := uint32(i) << 24
+		for range 8 {
+			if c&0x80000000 != 0 {
+				c = c<<1 ^ 0x04C11DB7
+			} else {
+				c <<= 1
+			}
+		}
+		tbl[i] = c
+	}
+	return tbl
+}()
+
+// Cksum computes the checksum of the schema text the way the OVS build does
+// (build-aux/cksum-schema-check): the output of POSIX cksum, "<crc> <length>",
+// of the text with the lines containing `"cksum":` removed.
+func Cksum(text []byte) string {
+	var data []byte
+	for line := range bytes.Lines(text) {
+		if !bytes.Contains(line, []byte(`"cksum":`)) {
+			data = append(data, line...)
+		}
+	}
+
+	var crc uint32
+	for _, b := range data {
+		crc = crc<<8 ^ crcTable[byte(crc>>24)^b]
+	}
+	for n := len(data); n > 0; n >>= 8 {
+		crc = crc<<8 ^ crcTable[byte(crc>>24)^byte(n)]
+	}
+	return fmt.Sprintf("%d %d", ^crc, len(data))
+}
+
+// VerifyCksum checks that the "cksum" of the schema text matches the text.
+func VerifyCksum(text []byte) error {
+	var doc struct {
+		Cksum string `json:"cksum"`
+	}
+	if err := json.Unmarshal(text, &doc); err != nil {
+		return fmt.Errorf("fail to unmarshal schema: %w", err)
+	}
+	if doc.Cksum == "" {
+		return fmt.Errorf("schema has no cksum")
+	}
+	if sum := Cksum(text); sum != doc.Cksum {
+		return fmt.Errorf("cksum mismatch: schema has %q, computed %q", doc.Cksum, sum)
+	}
+	return nil
+}
+
+// MarshalCksum returns the schema text indented by two spaces with the "cksum" computed
+// over the text, so that VerifyCksum of the result succeeds. ds is not modified.
+func (ds *DbSchema) MarshalCksum() ([]byte, error) {
+	out := *ds
+	out.Cksum = ""
+	text, err := json.MarshalIndent(&out, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	out.Cksum = Cksum(append(text, '\n'))
+	if text, err = json.MarshalIndent(&out, "", "  "); err != nil {
+		return nil, err
+	}
+	return append(text, '\n'), nil
+}
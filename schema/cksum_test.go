@@ -0,0 +1,47 @@
+package schema
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const cksumSchema = `{
+  "name": "Test",
+  "version": "1.0.0",
+  "cksum": "4158805192 125",
+  "tables": {
+    "T": {
+      "columns": {
+        "name": {"type": "string"}}}}}
+`
+
+func TestCksum(t *testing.T) {
+	// values of POSIX cksum
+	assert.Equal(t, "4294967295 0", Cksum(nil))
+	assert.Equal(t, "1219131554 3", Cksum([]byte("abc")))
+	assert.Equal(t, "4158805192 125", Cksum([]byte(cksumSchema)), "cksum line is not summed")
+
+	assert.NoError(t, VerifyCksum([]byte(cksumSchema)))
+	assert.ErrorContains(t, VerifyCksum([]byte(cksumSchema[:len(cksumSchema)-1])), "mismatch")
+	assert.ErrorContains(t, VerifyCksum([]byte(`{"name": "Test"}`)), "no cksum")
+	assert.Error(t, VerifyCksum([]byte(`{`)))
+}
+
+func TestDbSchema_MarshalCksum(t *testing.T) {
+	var db DbSchema
+	require.NoError(t, json.Unmarshal(ovsSchema, &db))
+	cksum := db.Cksum
+
+	text, err := db.MarshalCksum()
+	require.NoError(t, err)
+	assert.NoError(t, VerifyCksum(text))
+	assert.Equal(t, cksum, db.Cksum, "schema is not modified")
+
+	var db2 DbSchema
+	require.NoError(t, json.Unmarshal(text, &db2))
+	assert.Equal(t, Cksum(text), db2.Cksum)
+	db2.Cksum = db.Cksum
+	assert.Equal(t, db, db2)
+}
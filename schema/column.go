@@ -1,6 +1,7 @@
 package schema
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/kazmanavt/ovsdb/v2/types"
 	"reflect"
@@ -17,7 +18,33 @@ type ColumnSchema struct {
 	Name      string     `json:"-"`
 	Type      ColumnType `json:"type"`                // type of the column
 	Ephemeral bool       `json:"ephemeral,omitempty"` // true if the column is ephemeral
-	Mutable   bool       `json:"mutable,omitempty"`   // true if the column is mutable
+	// Mutable is true if the column is mutable. The columns parsed from JSON are mutable unless
+	// "mutable": false is given (RFC 7047 3.2), but the zero ColumnSchema is immutable.
+	Mutable bool `json:"mutable,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler, columns are mutable unless stated otherwise.
+func (cs *ColumnSchema) UnmarshalJSON(data []byte) error {
+	type CS ColumnSchema
+	cs.Mutable = true
+	return json.Unmarshal(data, (*CS)(cs))
+}
+
+// MarshalJSON implements json.Marshaler, "mutable" is written only if false.
+func (cs ColumnSchema) MarshalJSON() ([]byte, error) {
+	type CS struct {
+		Type      ColumnType `json:"type"`
+		Ephemeral bool       `json:"ephemeral,omitempty"`
+		Mutable   *bool      `json:"mutable,omitempty"`
+	}
+	out := CS{
+		Type:      cs.Type,
+		Ephemeral: cs.Ephemeral,
+	}
+	if !cs.Mutable {
+		out.Mutable = new(bool)
+	}
+	return json.Marshal(out)
 }
 
 // ValidateCond validates the condition for a column
@@ -25,7 +52,9 @@ func (cs *ColumnSchema) ValidateCond(op string, value any) (err error) {
 	ops := "includes!==excludes"
 	kind := cs.Type.GetKind()
 	// normalize value for Set[int|float64]
-	if v, ok := value.(int); kind == "Set[integer]" && *cs.Type.Min == 0 && *cs.Type.Max.(*int) == 1 && ok {
+	if kind == "integer" || kind == "real" {
+		ops = "<=>=" + ops
+	} else if v, ok := value.(int); kind == "Set[integer]" && *cs.Type.Min == 0 && *cs.Type.Max.(*int) == 1 && ok {
 		ops = "<=>=" + ops
 		value = types.Set[int]{v}
 	} else if v, ok := value.(float64); kind == "Set[real]" && *cs.Type.Min == 0 && *cs.Type.Max.(*int) == 1 && ok {
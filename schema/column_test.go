@@ -4,6 +4,7 @@ import (
 	_ "embed"
 	"encoding/json"
 	"github.com/kazmanavt/ovsdb/v2/types"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"testing"
 )
@@ -28,3 +29,27 @@ func TestColumnSchema_ValidateValue(t *testing.T) {
 	})
 
 }
+
+func TestColumnSchema_Mutable(t *testing.T) {
+	for _, test := range []struct {
+		in, out string
+		mutable bool
+	}{
+		{`{"type":"string"}`, `{"type":"string"}`, true},
+		{`{"type":"string","mutable":true}`, `{"type":"string"}`, true},
+		{`{"type":"string","mutable":false}`, `{"type":"string","mutable":false}`, false},
+		{`{"type":"string","ephemeral":true,"mutable":false}`, `{"type":"string","ephemeral":true,"mutable":false}`, false},
+	} {
+		var cs ColumnSchema
+		require.NoError(t, json.Unmarshal([]byte(test.in), &cs), test.in)
+		assert.Equal(t, test.mutable, cs.Mutable, test.in)
+		data, err := json.Marshal(cs)
+		require.NoError(t, err)
+		assert.Equal(t, test.out, string(data), test.in)
+	}
+
+	// the column of the parsed table
+	var ts TableSchema
+	require.NoError(t, json.Unmarshal([]byte(`{"columns":{"a":{"type":"integer"}}}`), &ts))
+	assert.True(t, ts.Columns["a"].Mutable)
+}
@@ -6,6 +6,9 @@ import (
 	"reflect"
 )
 
+// unlimited is the value of ColumnType.Max for "unlimited" max
+const unlimited = int(^uint(0) >> 1)
+
 type ColumnType struct {
 	kind  string
 	Key   BaseType  `json:"key"`             // key type of the column
@@ -37,7 +40,7 @@ func (ct *ColumnType) UnmarshalJSON(data []byte) error {
 			}
 		}
 		ct.Max = new(int)
-		*ct.Max.(*int) = int(^uint(0) >> 1)
+		*ct.Max.(*int) = unlimited
 	case nil:
 		ct.Max = new(int)
 		*ct.Max.(*int) = 1
@@ -59,6 +62,52 @@ func (ct *ColumnType) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalJSON implements json.Marshaler in the canonical form of ovsdb-tool: the scalar
+// of the atomic type is written as the type name, "min" and "max" are written only if not 1
+// and unlimited max is written as "unlimited".
+func (ct ColumnType) MarshalJSON() ([]byte, error) {
+	min, max := 1, 1
+	if ct.Min != nil {
+		min = *ct.Min
+	}
+	switch v := ct.Max.(type) {
+	case *int:
+		if v != nil {
+			max = *v
+		}
+	case int:
+		max = v
+	case string:
+		if v != "unlimited" {
+			return nil, fmt.Errorf("invalid max %q", v)
+		}
+		max = unlimited
+	}
+	if min == 1 && max == 1 && ct.Value == nil && ct.Key.isAtomic() {
+		return json.Marshal(ct.Key.Type)
+	}
+
+	out := struct {
+		Key   BaseType  `json:"key"`
+		Value *BaseType `json:"value,omitempty"`
+		Min   *int      `json:"min,omitempty"`
+		Max   any       `json:"max,omitempty"`
+	}{
+		Key:   ct.Key,
+		Value: ct.Value,
+	}
+	if min != 1 {
+		out.Min = &min
+	}
+	switch {
+	case max == unlimited:
+		out.Max = "unlimited"
+	case max != 1:
+		out.Max = max
+	}
+	return json.Marshal(out)
+}
+
 func (ct *ColumnType) GetKind() string {
 	return ct.kind
 }
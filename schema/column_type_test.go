@@ -1,7 +1,9 @@
 package schema
 
 import (
+	"encoding/json"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"testing"
 )
 
@@ -101,3 +103,32 @@ func TestColumnType_UnmarshalJSON(t *testing.T) {
 		})
 	})
 }
+
+func TestColumnType_MarshalJSON(t *testing.T) {
+	for _, typ := range []string{
+		`"string"`,
+		`{"key":{"type":"string","enum":["set",["a","b"]]}}`,
+		`{"key":"integer","min":0}`,
+		`{"key":{"type":"uuid","refTable":"T","refType":"weak"},"min":0,"max":"unlimited"}`,
+		`{"key":"string","value":{"type":"integer","minInteger":0,"maxInteger":4095},"min":0,"max":"unlimited"}`,
+		`{"key":"real","min":2,"max":2}`,
+	} {
+		var ct ColumnType
+		require.NoError(t, json.Unmarshal([]byte(typ), &ct), typ)
+		data, err := json.Marshal(ct)
+		require.NoError(t, err)
+		assert.Equal(t, typ, string(data))
+	}
+
+	// the non-canonical forms are written canonically
+	var ct ColumnType
+	require.NoError(t, json.Unmarshal([]byte(`{"key":{"type":"string"},"min":1,"max":1}`), &ct))
+	data, err := json.Marshal(ct)
+	require.NoError(t, err)
+	assert.Equal(t, `"string"`, string(data))
+
+	ct = ColumnType{Key: BaseType{Type: "integer"}, Max: "unlimited"}
+	data, err = json.Marshal(ct)
+	require.NoError(t, err)
+	assert.Equal(t, `{"key":"integer","max":"unlimited"}`, string(data))
+}
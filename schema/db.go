@@ -6,14 +6,14 @@ import (
 
 // DbSchema is the schema of a database (RFC 7047).
 // "name": <id>                            required
-// "version": <version>                    required
+// "version": <version>                    optional
 // "cksum": <string>                       optional
 // "tables": {<id>: <table-schema>, ...}   required
 type DbSchema struct {
-	Name    string                  `json:"name"`            // Name of the database
-	Version string                  `json:"version"`         // version of the database schema
-	Cksum   string                  `json:"cksum,omitempty"` // checksum of the database schema
-	Tables  map[string]*TableSchema `json:"tables"`          // tables in the database
+	Name    string                  `json:"name"`              // Name of the database
+	Version string                  `json:"version,omitempty"` // version of the database schema
+	Cksum   string                  `json:"cksum,omitempty"`   // checksum of the database schema
+	Tables  map[string]*TableSchema `json:"tables"`            // tables in the database
 }
 
 func (ds *DbSchema) UnmarshalJSON(data []byte) error {
@@ -0,0 +1,325 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind is the kind of the difference of the schemas found by Diff.
+type ChangeKind string
+
+const (
+	TableAdded        ChangeKind = "table added"
+	TableRemoved      ChangeKind = "table removed"
+	TableChanged      ChangeKind = "table changed" // maxRows or isRoot
+	ColumnAdded       ChangeKind = "column added"
+	ColumnRemoved     ChangeKind = "column removed"
+	TypeChanged       ChangeKind = "type changed" // base type of key or value, or scalar/set/map
+	BoundsChanged     ChangeKind = "min/max changed"
+	EnumChanged       ChangeKind = "enum changed"
+	ConstraintChanged ChangeKind = "constraint changed" // minInteger, maxLength etc.
+	RefChanged        ChangeKind = "reference changed"  // refTable or refType
+	MutableChanged    ChangeKind = "mutability changed"
+	EphemeralChanged  ChangeKind = "ephemeral changed"
+	IndexAdded        ChangeKind = "index added"
+	IndexRemoved      ChangeKind = "index removed"
+)
+
+// Change is the difference of the table or the column of the schemas. It is breaking if the client
+// of the old schema may fail with the new one, e.g. a column it uses is removed, a value it writes
+// is not allowed anymore or a value it reads is of other type.
+type Change struct {
+	Kind     ChangeKind `json:"kind"`
+	Table    string     `json:"table"`
+	Column   string     `json:"column,omitempty"`
+	Detail   string     `json:"detail,omitempty"`
+	Breaking bool       `json:"breaking"`
+}
+
+func (c Change) String() string {
+	var b strings.Builder
+	if c.Breaking {
+		b.WriteString("breaking   ")
+	} else {
+		b.WriteString("compatible ")
+	}
+	b.WriteString(c.Table)
+	if c.Column != "" {
+		b.WriteString(".")
+		b.WriteString(c.Column)
+	}
+	b.WriteString(": ")
+	b.WriteString(string(c.Kind))
+	if c.Detail != "" {
+		b.WriteString(": ")
+		b.WriteString(c.Detail)
+	}
+	return b.String()
+}
+
+// SchemaDiff is the report of Diff.
+type SchemaDiff struct {
+	Name       string   `json:"name"`
+	OldVersion string   `json:"old_version"`
+	NewVersion string   `json:"new_version"`
+	Changes    []Change `json:"changes"`
+}
+
+// Breaking returns the breaking changes.
+func (d *SchemaDiff) Breaking() []Change {
+	var res []Change
+	for _, c := range d.Changes {
+		if c.Breaking {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+func (d *SchemaDiff) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s -> %s: %d changes, %d breaking\n",
+		d.Name, d.OldVersion, d.NewVersion, len(d.Changes), len(d.Breaking()))
+	for _, c := range d.Changes {
+		b.WriteString("  ")
+		b.WriteString(c.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Diff returns the changes of the schema from the old version to the new one, ordered by table and column.
+func Diff(from, to *DbSchema) *SchemaDiff {
+	d := &SchemaDiff{Name: to.Name, OldVersion: from.Version, NewVersion: to.Version}
+	for _, tName := range unionKeys(from.Tables, to.Tables) {
+		oldT, inOld := from.Tables[tName]
+		newT, inNew := to.Tables[tName]
+		switch {
+		case !inOld:
+			d.add(TableAdded, tName, "", "", false)
+		case !inNew:
+			d.add(TableRemoved, tName, "", "", true)
+		default:
+			d.diffTable(tName, oldT, newT)
+		}
+	}
+	return d
+}
+
+func (d *SchemaDiff) add(kind ChangeKind, table, column, detail string, breaking bool) {
+	d.Changes = append(d.Changes, Change{Kind: kind, Table: table, Column: column, Detail: detail, Breaking: breaking})
+}
+
+func (d *SchemaDiff) diffTable(tName string, oldT, newT *TableSchema) {
+	if oldT.MaxRows != newT.MaxRows {
+		// the limit added or lowered may reject the inserts
+		breaking := newT.MaxRows != 0 && (oldT.MaxRows == 0 || newT.MaxRows < oldT.MaxRows)
+		d.add(TableChanged, tName, "", fmt.Sprintf("maxRows %s -> %s", maxRows(oldT.MaxRows), maxRows(newT.MaxRows)), breaking)
+	}
+	if oldT.IsRoot != newT.IsRoot {
+		// the rows of the non-root table are removed if not referenced
+		d.add(TableChanged, tName, "", fmt.Sprintf("isRoot %t -> %t", oldT.IsRoot, newT.IsRoot), oldT.IsRoot)
+	}
+
+	for _, cName := range unionKeys(oldT.Columns, newT.Columns) {
+		if isSynthetic(cName) {
+			continue
+		}
+		oldC, inOld := oldT.Columns[cName]
+		newC, inNew := newT.Columns[cName]
+		switch {
+		case !inOld:
+			d.add(ColumnAdded, tName, cName, newC.Type.GetKind(), false)
+		case !inNew:
+			d.add(ColumnRemoved, tName, cName, "", true)
+		default:
+			d.diffColumn(tName, cName, oldC, newC)
+		}
+	}
+
+	oldIdx, newIdx := indexes(oldT), indexes(newT)
+	for _, idx := range newIdx {
+		if !slices.Contains(oldIdx, idx) {
+			// the rows of the client may violate the uniqueness
+			d.add(IndexAdded, tName, "", "["+idx+"]", true)
+		}
+	}
+	for _, idx := range oldIdx {
+		if !slices.Contains(newIdx, idx) {
+			d.add(IndexRemoved, tName, "", "["+idx+"]", false)
+		}
+	}
+}
+
+func (d *SchemaDiff) diffColumn(tName, cName string, oldC, newC *ColumnSchema) {
+	oldT, newT := &oldC.Type, &newC.Type
+	if oldC.Mutable != newC.Mutable {
+		d.add(MutableChanged, tName, cName, fmt.Sprintf("mutable %t -> %t", oldC.Mutable, newC.Mutable), oldC.Mutable)
+	}
+	if oldC.Ephemeral != newC.Ephemeral {
+		d.add(EphemeralChanged, tName, cName, fmt.Sprintf("ephemeral %t -> %t", oldC.Ephemeral, newC.Ephemeral), false)
+	}
+
+	if oldT.GetKind() != newT.GetKind() {
+		// the values are of the other Go type, e.g. string -> Set[string]
+		d.add(TypeChanged, tName, cName, oldT.GetKind()+" -> "+newT.GetKind(), true)
+		return
+	}
+	oldMin, newMin := *oldT.Min, *newT.Min
+	oldMax, newMax := *oldT.Max.(*int), *newT.Max.(*int)
+	if oldMin != newMin || oldMax != newMax {
+		d.add(BoundsChanged, tName, cName,
+			fmt.Sprintf("[%d, %s] -> [%d, %s]", oldMin, bound(oldMax), newMin, bound(newMax)),
+			newMin > oldMin || newMax < oldMax)
+	}
+	d.diffBase(tName, cName, "key", &oldT.Key, &newT.Key)
+	if oldT.Value != nil && newT.Value != nil {
+		d.diffBase(tName, cName, "value", oldT.Value, newT.Value)
+	}
+}
+
+// diffBase compares the base types of the key or the value of the column, the base type itself is
+// compared by the kind of the column.
+func (d *SchemaDiff) diffBase(tName, cName, part string, oldB, newB *BaseType) {
+	oldE, newE := enumValues(oldB), enumValues(newB)
+	switch {
+	case oldE == nil && newE != nil:
+		d.add(EnumChanged, tName, cName, fmt.Sprintf("%s restricted to %v", part, newE), true)
+	case oldE != nil && newE == nil:
+		d.add(EnumChanged, tName, cName, fmt.Sprintf("%s not restricted to %v", part, oldE), false)
+	case oldE != nil:
+		var added, removed []string
+		for _, v := range newE {
+			if !slices.Contains(oldE, v) {
+				added = append(added, v)
+			}
+		}
+		for _, v := range oldE {
+			if !slices.Contains(newE, v) {
+				removed = append(removed, v)
+			}
+		}
+		if len(added) > 0 {
+			d.add(EnumChanged, tName, cName, fmt.Sprintf("%s values added %v", part, added), false)
+		}
+		if len(removed) > 0 {
+			d.add(EnumChanged, tName, cName, fmt.Sprintf("%s values removed %v", part, removed), true)
+		}
+	}
+
+	oldRef, newRef := ref(oldB), ref(newB)
+	if oldRef != newRef {
+		// the reference added or changed breaks the client, the one dropped makes the column plain uuid
+		d.add(RefChanged, tName, cName, fmt.Sprintf("%s %s -> %s", part, refString(oldRef), refString(newRef)), newRef != "")
+	}
+
+	d.diffRange(tName, cName, part+" minInteger", oldB.MinInteger, newB.MinInteger, true)
+	d.diffRange(tName, cName, part+" maxInteger", oldB.MaxInteger, newB.MaxInteger, false)
+	d.diffRange(tName, cName, part+" minLength", oldB.MinLength, newB.MinLength, true)
+	d.diffRange(tName, cName, part+" maxLength", oldB.MaxLength, newB.MaxLength, false)
+	d.diffRangeReal(tName, cName, part+" minReal", oldB.MinReal, newB.MinReal, true)
+	d.diffRangeReal(tName, cName, part+" maxReal", oldB.MaxReal, newB.MaxReal, false)
+}
+
+// diffRange compares the lower (isMin) or upper limits of the values, the limit added or narrowed is breaking.
+func (d *SchemaDiff) diffRange(tName, cName, name string, oldL, newL *int, isMin bool) {
+	switch {
+	case oldL == nil && newL == nil:
+	case oldL == nil:
+		d.add(ConstraintChanged, tName, cName, fmt.Sprintf("%s added %d", name, *newL), true)
+	case newL == nil:
+		d.add(ConstraintChanged, tName, cName, fmt.Sprintf("%s removed %d", name, *oldL), false)
+	case *oldL != *newL:
+		d.add(ConstraintChanged, tName, cName, fmt.Sprintf("%s %d -> %d", name, *oldL, *newL),
+			isMin == (*newL > *oldL))
+	}
+}
+
+func (d *SchemaDiff) diffRangeReal(tName, cName, name string, oldL, newL *float64, isMin bool) {
+	format := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+	switch {
+	case oldL == nil && newL == nil:
+	case oldL == nil:
+		d.add(ConstraintChanged, tName, cName, fmt.Sprintf("%s added %s", name, format(*newL)), true)
+	case newL == nil:
+		d.add(ConstraintChanged, tName, cName, fmt.Sprintf("%s removed %s", name, format(*oldL)), false)
+	case *oldL != *newL:
+		d.add(ConstraintChanged, tName, cName, fmt.Sprintf("%s %s -> %s", name, format(*oldL), format(*newL)),
+			isMin == (*newL > *oldL))
+	}
+}
+
+// enumValues returns the enum of the base type as the sorted JSON texts of the values, nil if there is no enum.
+func enumValues(bt *BaseType) []string {
+	if bt.Enum == nil {
+		return nil
+	}
+	values := make([]string, 0, len(bt.Enum))
+	for _, v := range bt.Enum {
+		data, _ := json.Marshal(v)
+		values = append(values, string(data))
+	}
+	slices.Sort(values)
+	return values
+}
+
+// ref returns "<table> <refType>" of the reference, or empty string if the base type is not a reference.
+func ref(bt *BaseType) string {
+	if bt.RefTable == nil {
+		return ""
+	}
+	refType := "strong"
+	if bt.RefType != nil {
+		refType = *bt.RefType
+	}
+	return *bt.RefTable + " " + refType
+}
+
+func refString(ref string) string {
+	if ref == "" {
+		return "no reference"
+	}
+	return "refTable " + ref
+}
+
+// indexes returns the indexes of the table as the sorted lists of the columns.
+func indexes(ts *TableSchema) []string {
+	res := make([]string, 0, len(ts.Indexes))
+	for _, idx := range ts.Indexes {
+		cols := slices.Clone(idx)
+		slices.Sort(cols)
+		res = append(res, strings.Join(cols, ", "))
+	}
+	return res
+}
+
+func bound(max int) string {
+	if max == unlimited {
+		return "unlimited"
+	}
+	return strconv.Itoa(max)
+}
+
+func maxRows(n int) string {
+	if n == 0 {
+		return "unlimited"
+	}
+	return strconv.Itoa(n)
+}
+
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,109 @@
+package schema
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const diffOld = `{
+  "name": "Test",
+  "version": "1.0.0",
+  "tables": {
+    "Bridge": {
+      "columns": {
+        "name": {"type": "string", "mutable": false},
+        "mode": {"type": {"key": {"type": "string", "enum": ["set", ["a", "b"]]}, "min": 0, "max": 1}},
+        "ports": {"type": {"key": {"type": "uuid", "refTable": "Port"}, "min": 0, "max": "unlimited"}},
+        "vlans": {"type": {"key": {"type": "integer", "minInteger": 0, "maxInteger": 4095}, "min": 0, "max": 4096}},
+        "other": {"type": {"key": "string", "value": "string", "min": 0, "max": "unlimited"}},
+        "status": {"type": "string"},
+        "legacy": {"type": "integer"}
+      },
+      "indexes": [["name"]],
+      "isRoot": true
+    },
+    "Port": {
+      "columns": {
+        "name": {"type": "string"}
+      }
+    },
+    "Old": {
+      "columns": {
+        "x": {"type": "integer"}
+      }
+    }
+  }
+}`
+
+const diffNew = `{
+  "name": "Test",
+  "version": "1.1.0",
+  "tables": {
+    "Bridge": {
+      "columns": {
+        "name": {"type": "string"},
+        "mode": {"type": {"key": {"type": "string", "enum": ["set", ["b", "c"]]}, "min": 0, "max": 1}},
+        "ports": {"type": {"key": {"type": "uuid", "refTable": "Port", "refType": "weak"}, "min": 0, "max": "unlimited"}},
+        "vlans": {"type": {"key": {"type": "integer", "minInteger": 1, "maxInteger": 4095}, "min": 0, "max": "unlimited"}},
+        "other": {"type": {"key": "string", "value": "integer", "min": 0, "max": "unlimited"}},
+        "status": {"type": {"key": "string", "min": 0, "max": 1}},
+        "fresh": {"type": "boolean"}
+      },
+      "indexes": [["status"]],
+      "isRoot": true,
+      "maxRows": 10
+    },
+    "Port": {
+      "columns": {
+        "name": {"type": "string", "ephemeral": true}
+      }
+    },
+    "New": {
+      "columns": {
+        "y": {"type": "integer"}
+      }
+    }
+  }
+}`
+
+func TestDiff(t *testing.T) {
+	var from, to DbSchema
+	require.NoError(t, json.Unmarshal([]byte(diffOld), &from))
+	require.NoError(t, json.Unmarshal([]byte(diffNew), &to))
+
+	d := Diff(&from, &to)
+	assert.Equal(t, "1.0.0", d.OldVersion)
+	assert.Equal(t, "1.1.0", d.NewVersion)
+	assert.Equal(t, []Change{
+		{Kind: TableChanged, Table: "Bridge", Detail: "maxRows unlimited -> 10", Breaking: true},
+		{Kind: ColumnAdded, Table: "Bridge", Column: "fresh", Detail: "boolean"},
+		{Kind: ColumnRemoved, Table: "Bridge", Column: "legacy", Breaking: true},
+		{Kind: EnumChanged, Table: "Bridge", Column: "mode", Detail: `key values added ["c"]`},
+		{Kind: EnumChanged, Table: "Bridge", Column: "mode", Detail: `key values removed ["a"]`, Breaking: true},
+		{Kind: MutableChanged, Table: "Bridge", Column: "name", Detail: "mutable false -> true"},
+		{Kind: TypeChanged, Table: "Bridge", Column: "other", Detail: "Map[string, string] -> Map[string, integer]", Breaking: true},
+		{Kind: RefChanged, Table: "Bridge", Column: "ports", Detail: "key refTable Port strong -> refTable Port weak", Breaking: true},
+		{Kind: TypeChanged, Table: "Bridge", Column: "status", Detail: "string -> Set[string]", Breaking: true},
+		{Kind: BoundsChanged, Table: "Bridge", Column: "vlans", Detail: "[0, 4096] -> [0, unlimited]"},
+		{Kind: ConstraintChanged, Table: "Bridge", Column: "vlans", Detail: "key minInteger 0 -> 1", Breaking: true},
+		{Kind: IndexAdded, Table: "Bridge", Detail: "[status]", Breaking: true},
+		{Kind: IndexRemoved, Table: "Bridge", Detail: "[name]"},
+		{Kind: TableAdded, Table: "New"},
+		{Kind: TableRemoved, Table: "Old", Breaking: true},
+		{Kind: EphemeralChanged, Table: "Port", Column: "name", Detail: "ephemeral false -> true"},
+	}, d.Changes)
+	assert.Len(t, d.Breaking(), 9)
+	assert.Contains(t, d.String(), "Test 1.0.0 -> 1.1.0: 16 changes, 9 breaking\n")
+	assert.Contains(t, d.String(), "  breaking   Old: table removed\n")
+	assert.Contains(t, d.String(), "  compatible Bridge.fresh: column added: boolean\n")
+
+	// the reverse upgrade
+	back := Diff(&to, &from)
+	assert.Len(t, back.Changes, 16)
+
+	var ovs DbSchema
+	require.NoError(t, json.Unmarshal(ovsSchema, &ovs))
+	assert.Empty(t, Diff(&ovs, &ovs).Changes)
+}
@@ -0,0 +1,322 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Model is the struct mapped to the rows of the table by the tags of its fields,
+// see TableSchema.RowFromStruct. It names the table of the rows.
+type Model interface {
+	TableName() string
+}
+
+// fieldMap maps the field of the struct to the column.
+type fieldMap struct {
+	name      string
+	index     []int
+	cSch      *ColumnSchema
+	omitEmpty bool
+}
+
+type structKey struct {
+	typ  reflect.Type
+	tSch *TableSchema
+}
+
+// structMaps caches the mappings of the struct types to the tables, structKey -> []fieldMap
+var structMaps sync.Map
+
+// structMap returns the mapping of the fields of the struct type typ tagged `ovsdb:"column[,omitempty]"`
+// to the columns of the table. The untagged fields and the fields tagged `ovsdb:"-"` are skipped.
+func (ts *TableSchema) structMap(typ reflect.Type) ([]fieldMap, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("table %q: %s is not a struct", ts.Name, typ)
+	}
+	key := structKey{typ: typ, tSch: ts}
+	if fm, ok := structMaps.Load(key); ok {
+		return fm.([]fieldMap), nil
+	}
+
+	var fields []fieldMap
+	seen := make(map[string]string)
+	for _, f := range reflect.VisibleFields(typ) {
+		tag, ok := f.Tag.Lookup("ovsdb")
+		if !ok || tag == "-" || !f.IsExported() {
+			continue
+		}
+		cName, opts, _ := strings.Cut(tag, ",")
+		cSch, ok := ts.Columns[cName]
+		if !ok {
+			return nil, fmt.Errorf("table %q: field %s: column %q not in table", ts.Name, f.Name, cName)
+		}
+		if other, ok := seen[cName]; ok {
+			return nil, fmt.Errorf("table %q: fields %s and %s: same column %q", ts.Name, other, f.Name, cName)
+		}
+		seen[cName] = f.Name
+		if !cSch.fits(f.Type) {
+			return nil, fmt.Errorf("table %q: field %s: type %s does not fit column %q of %s",
+				ts.Name, f.Name, f.Type, cName, cSch.Type.GetKind())
+		}
+		fields = append(fields, fieldMap{
+			name:      f.Name,
+			index:     f.Index,
+			cSch:      cSch,
+			omitEmpty: opts == "omitempty",
+		})
+	}
+	structMaps.Store(key, fields)
+	return fields, nil
+}
+
+// fits reports whether the values of the column can be held by the field of type ft:
+// the scalar of the base type (e.g. string or the type derived from it for the uuid or enum columns),
+// the pointer to it for the columns of 0 or 1 value, the slice of them for the sets,
+// the map of them for the maps.
+func (cs *ColumnSchema) fits(ft reflect.Type) bool {
+	ct := &cs.Type
+	switch kind := ct.GetKind(); {
+	case strings.HasPrefix(kind, "Map["):
+		return ft.Kind() == reflect.Map && fitsBase(ft.Key(), &ct.Key) && fitsBase(ft.Elem(), ct.Value)
+	case strings.HasPrefix(kind, "Set["):
+		if ft.Kind() == reflect.Pointer && *ct.Min == 0 && *ct.Max.(*int) == 1 {
+			return fitsBase(ft.Elem(), &ct.Key)
+		}
+		return ft.Kind() == reflect.Slice && fitsBase(ft.Elem(), &ct.Key)
+	case kind != "":
+		return fitsBase(ft, &ct.Key)
+	}
+	return false
+}
+
+func fitsBase(ft reflect.Type, bt *BaseType) bool {
+	switch bt.Type {
+	case "integer":
+		return ft.Kind() >= reflect.Int && ft.Kind() <= reflect.Int64
+	case "real":
+		return ft.Kind() == reflect.Float64 || ft.Kind() == reflect.Float32
+	case "string", "uuid":
+		return ft.Kind() == reflect.String
+	case "boolean":
+		return ft.Kind() == reflect.Bool
+	}
+	return false
+}
+
+// RowFromStruct creates the row of the table from the struct v (or the pointer to it) holding the values
+// of the columns in the fields tagged `ovsdb:"column"`, e.g.
+//
+//	type Bridge struct {
+//		UUID     types.UUID        `ovsdb:"_uuid"`
+//		Name     string            `ovsdb:"name"`
+//		FailMode *string           `ovsdb:"fail_mode"`
+//		Ports    []types.UUID      `ovsdb:"ports"`
+//		IDs      map[string]string `ovsdb:"external_ids,omitempty"`
+//	}
+//
+// The columns of 0 or 1 value may be held by the pointers, nil for no value. The fields of
+// the types derived from the column types (e.g. typed enums) are converted. The fields tagged
+// with omitempty are skipped if they are empty, the _uuid and _version columns are never set.
+// If columns are given, only they are set. The tags not fitting the columns and the values
+// violating the schema are reported as errors.
+func (ts *TableSchema) RowFromStruct(v any, columns ...string) (Row, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || rv.Kind() == reflect.Pointer {
+		return nil, fmt.Errorf("table %q: nil struct", ts.Name)
+	}
+	fields, err := ts.structMap(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	r := ts.NewRow().(*rowImpl)
+	for _, f := range fields {
+		cName := f.cSch.Name
+		if isSynthetic(cName) {
+			continue
+		}
+		fv, err := rv.FieldByIndexErr(f.index)
+		if err != nil {
+			// promoted through the nil embedded pointer, the field has no value
+			continue
+		}
+		if len(columns) > 0 {
+			if !slices.Contains(columns, cName) {
+				continue
+			}
+		} else if f.omitEmpty && isEmpty(fv) {
+			continue
+		}
+		value := f.cSch.encode(fv)
+		if err := f.cSch.ValidateValue(value); err != nil {
+			return nil, fmt.Errorf("table %q: field %s: column %q: %w", ts.Name, f.name, cName, err)
+		}
+		r.row[cName] = value
+	}
+	for _, cName := range columns {
+		if _, ok := r.row[cName]; !ok {
+			return nil, fmt.Errorf("table %q: column %q is not mapped by %s", ts.Name, cName, rv.Type())
+		}
+	}
+	return r, nil
+}
+
+// RowFromModel creates the row of the table of the model m, see TableSchema.RowFromStruct.
+func (ds *DbSchema) RowFromModel(m Model, columns ...string) (Row, error) {
+	tSch, ok := ds.Tables[m.TableName()]
+	if !ok {
+		return nil, fmt.Errorf("table %q not in database %q", m.TableName(), ds.Name)
+	}
+	return tSch.RowFromStruct(m, columns...)
+}
+
+// Decode stores the values of the columns of the row in the tagged fields of the struct
+// pointed to by v, see TableSchema.RowFromStruct. The fields of the columns not set in the row
+// are left unchanged.
+func (ts *TableSchema) Decode(row Row, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("table %q: decode into %T, expect pointer to struct", ts.Name, v)
+	}
+	rv = rv.Elem()
+	fields, err := ts.structMap(rv.Type())
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		value, ok := row.GetE(f.cSch.Name)
+		if !ok {
+			continue
+		}
+		fv, err := fieldByIndexAlloc(rv, f.index)
+		if err == nil {
+			err = decode(value, fv)
+		}
+		if err != nil {
+			return fmt.Errorf("table %q: field %s: column %q: %w", ts.Name, f.name, f.cSch.Name, err)
+		}
+	}
+	return nil
+}
+
+// Decode implements Row.Decode.
+func (r *rowImpl) Decode(v any) error {
+	return r.TableSchema().Decode(r, v)
+}
+
+// encode returns the value of the column held by the field fv converted to the type of the column.
+func (cs *ColumnSchema) encode(fv reflect.Value) any {
+	typ := reflect.TypeOf(cs.GetDefaultValue())
+	switch typ.Kind() {
+	case reflect.Slice:
+		set := reflect.MakeSlice(typ, 0, 1)
+		if fv.Kind() == reflect.Pointer {
+			if !fv.IsNil() {
+				set = reflect.Append(set, fv.Elem().Convert(typ.Elem()))
+			}
+			return set.Interface()
+		}
+		for i := 0; i < fv.Len(); i++ {
+			set = reflect.Append(set, fv.Index(i).Convert(typ.Elem()))
+		}
+		return set.Interface()
+	case reflect.Map:
+		m := reflect.MakeMapWithSize(typ, fv.Len())
+		for it := fv.MapRange(); it.Next(); {
+			m.SetMapIndex(it.Key().Convert(typ.Key()), it.Value().Convert(typ.Elem()))
+		}
+		return m.Interface()
+	default:
+		return fv.Convert(typ).Interface()
+	}
+}
+
+// decode stores the value of the column in the field fv converting it to the type of the field.
+func decode(value any, fv reflect.Value) error {
+	ft := fv.Type()
+	if value == nil {
+		fv.SetZero()
+		return nil
+	}
+	rv := reflect.ValueOf(value)
+	mismatch := fmt.Errorf("value of type %T does not fit %s", value, ft)
+	switch ft.Kind() {
+	case reflect.Pointer:
+		if rv.Kind() != reflect.Slice {
+			return mismatch
+		}
+		if rv.Len() == 0 {
+			fv.SetZero()
+			return nil
+		}
+		if !rv.Index(0).CanConvert(ft.Elem()) {
+			return mismatch
+		}
+		p := reflect.New(ft.Elem())
+		p.Elem().Set(rv.Index(0).Convert(ft.Elem()))
+		fv.Set(p)
+	case reflect.Slice:
+		if rv.Kind() != reflect.Slice {
+			return mismatch
+		}
+		s := reflect.MakeSlice(ft, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			if !rv.Index(i).CanConvert(ft.Elem()) {
+				return mismatch
+			}
+			s = reflect.Append(s, rv.Index(i).Convert(ft.Elem()))
+		}
+		fv.Set(s)
+	case reflect.Map:
+		if rv.Kind() != reflect.Map {
+			return mismatch
+		}
+		m := reflect.MakeMapWithSize(ft, rv.Len())
+		for it := rv.MapRange(); it.Next(); {
+			if !it.Key().CanConvert(ft.Key()) || !it.Value().CanConvert(ft.Elem()) {
+				return mismatch
+			}
+			m.SetMapIndex(it.Key().Convert(ft.Key()), it.Value().Convert(ft.Elem()))
+		}
+		fv.Set(m)
+	default:
+		if !rv.CanConvert(ft) || rv.Kind() == reflect.Slice || rv.Kind() == reflect.Map {
+			return mismatch
+		}
+		fv.Set(rv.Convert(ft))
+	}
+	return nil
+}
+
+// fieldByIndexAlloc returns the field of the struct v by index allocating the nil embedded
+// pointers on the way to it. The pointers to the unexported structs can't be allocated.
+func fieldByIndexAlloc(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf("can't set embedded pointer to unexported struct %s", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+func isEmpty(fv reflect.Value) bool {
+	switch fv.Kind() {
+	case reflect.Slice, reflect.Map:
+		return fv.Len() == 0
+	default:
+		return fv.IsZero()
+	}
+}
@@ -0,0 +1,155 @@
+package schema
+
+import (
+	"encoding/json"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type failMode string
+
+type testBridge struct {
+	UUID     types.UUID        `ovsdb:"_uuid"`
+	Name     string            `ovsdb:"name"`
+	FailMode *failMode         `ovsdb:"fail_mode"`
+	Ports    []types.UUID      `ovsdb:"ports"`
+	VLANs    []int64           `ovsdb:"flood_vlans,omitempty"`
+	IDs      map[string]string `ovsdb:"external_ids"`
+	STP      bool              `ovsdb:"stp_enable"`
+	Note     string
+}
+
+func (testBridge) TableName() string {
+	return "Bridge"
+}
+
+func TestRowFromStruct(t *testing.T) {
+	var dbs DbSchema
+	require.NoError(t, json.Unmarshal(ovsSchema, &dbs))
+	mode := failMode("secure")
+	b := testBridge{
+		UUID:     "165f8f88-f073-41bc-8301-864050532dab",
+		Name:     "br0",
+		FailMode: &mode,
+		Ports:    []types.UUID{"8cc2eb5c-8e66-4554-af1d-8fa5b9321f99"},
+		IDs:      map[string]string{"k": "v"},
+		Note:     "not mapped",
+	}
+
+	r, err := dbs.RowFromModel(&b)
+	require.NoError(t, err)
+	assert.Equal(t, "Bridge", r.TableName())
+	assert.Equal(t, 5, r.Len(), "_uuid and empty flood_vlans are not set")
+	assert.Equal(t, "br0", r.Get("name"))
+	assert.Equal(t, types.Set[string]{"secure"}, r.Get("fail_mode"))
+	assert.Equal(t, types.Set[types.UUID]{"8cc2eb5c-8e66-4554-af1d-8fa5b9321f99"}, r.Get("ports"))
+	assert.Equal(t, types.Map[string, string]{"k": "v"}, r.Get("external_ids"))
+	assert.Equal(t, false, r.Get("stp_enable"))
+
+	r, err = dbs.Tables["Bridge"].RowFromStruct(b, "fail_mode", "flood_vlans")
+	require.NoError(t, err)
+	assert.Equal(t, 2, r.Len())
+	assert.Equal(t, types.Set[int]{}, r.Get("flood_vlans"))
+
+	// the schema violations are errors
+	bad := failMode("bogus")
+	b.FailMode = &bad
+	_, err = dbs.RowFromModel(b)
+	assert.ErrorContains(t, err, `column "fail_mode"`)
+	_, err = dbs.Tables["Bridge"].RowFromStruct(b, "status")
+	assert.Error(t, err, "column not mapped")
+	_, err = dbs.Tables["Bridge"].RowFromStruct((*testBridge)(nil))
+	assert.Error(t, err)
+
+	var wrongType struct {
+		Name int `ovsdb:"name"`
+	}
+	_, err = dbs.Tables["Bridge"].RowFromStruct(wrongType)
+	assert.ErrorContains(t, err, "does not fit")
+	var wrongColumn struct {
+		Name string `ovsdb:"nmae"`
+	}
+	_, err = dbs.Tables["Bridge"].RowFromStruct(wrongColumn)
+	assert.ErrorContains(t, err, "not in table")
+	var notOptional struct {
+		Ports *types.UUID `ovsdb:"ports"`
+	}
+	_, err = dbs.Tables["Bridge"].RowFromStruct(notOptional)
+	assert.Error(t, err, "pointer for the set column")
+}
+
+func TestRow_Decode(t *testing.T) {
+	var dbs DbSchema
+	require.NoError(t, json.Unmarshal(ovsSchema, &dbs))
+	r := dbs.Tables["Bridge"].NewRow()
+	require.NoError(t, r.UnmarshalJSON([]byte(`{
+		"_uuid": ["uuid", "165f8f88-f073-41bc-8301-864050532dab"],
+		"name": "br0",
+		"fail_mode": "standalone",
+		"ports": ["set", [["uuid", "8cc2eb5c-8e66-4554-af1d-8fa5b9321f99"], ["uuid", "9dd3fc6d-9f77-4665-b02e-9ab6c6a4208a"]]],
+		"flood_vlans": ["set", [10, 20]],
+		"external_ids": ["map", [["k", "v"]]]
+	}`)))
+
+	b := testBridge{STP: true, Note: "kept"}
+	require.NoError(t, r.Decode(&b))
+	require.NotNil(t, b.FailMode)
+	assert.Equal(t, testBridge{
+		UUID:     "165f8f88-f073-41bc-8301-864050532dab",
+		Name:     "br0",
+		FailMode: b.FailMode,
+		Ports:    []types.UUID{"8cc2eb5c-8e66-4554-af1d-8fa5b9321f99", "9dd3fc6d-9f77-4665-b02e-9ab6c6a4208a"},
+		VLANs:    []int64{10, 20},
+		IDs:      map[string]string{"k": "v"},
+		STP:      true, // not in the row
+		Note:     "kept",
+	}, b)
+	assert.Equal(t, failMode("standalone"), *b.FailMode)
+
+	r.Set("fail_mode", types.Set[string]{})
+	require.NoError(t, dbs.Tables["Bridge"].Decode(r, &b))
+	assert.Nil(t, b.FailMode)
+
+	assert.Error(t, r.Decode(b), "not a pointer")
+	var wrongType struct {
+		Ports []int `ovsdb:"ports"`
+	}
+	assert.Error(t, dbs.Tables["Bridge"].Decode(r, &wrongType))
+}
+
+type BridgeIDs struct {
+	IDs map[string]string `ovsdb:"external_ids"`
+}
+
+type bridgeIDs BridgeIDs
+
+type embeddingBridge struct {
+	Name string `ovsdb:"name"`
+	*BridgeIDs
+}
+
+func TestNilEmbeddedPointer(t *testing.T) {
+	var dbs DbSchema
+	require.NoError(t, json.Unmarshal(ovsSchema, &dbs))
+
+	// the fields promoted through the nil pointer are skipped on encode
+	r, err := dbs.Tables["Bridge"].RowFromStruct(embeddingBridge{Name: "br0"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, r.Len())
+	assert.Equal(t, "br0", r.Get("name"))
+
+	// and the pointer is allocated on decode
+	r.Set("external_ids", types.Map[string, string]{"k": "v"})
+	var b embeddingBridge
+	require.NoError(t, r.Decode(&b))
+	require.NotNil(t, b.BridgeIDs)
+	assert.Equal(t, map[string]string{"k": "v"}, b.IDs)
+
+	var unexported struct {
+		Name string `ovsdb:"name"`
+		*bridgeIDs
+	}
+	assert.ErrorContains(t, r.Decode(&unexported), "unexported struct")
+}
@@ -49,6 +49,8 @@ type Row interface {
 	Match(where []types.Condition) bool
 	// Len returns the number of assigned columns in the row.
 	Len() int
+	// Decode stores the values of the row in the struct pointed to by v, see TableSchema.Decode.
+	Decode(v any) error
 }
 
 type rowImpl struct {
@@ -1,6 +1,7 @@
 package schema
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"sync"
@@ -14,13 +15,27 @@ import (
 //	"isRoot": <boolean>                       optional
 //	"indexes": [<column-set>*]                optional
 type TableSchema struct {
-	Name    string
+	Name    string                   `json:"-"`
 	Columns map[string]*ColumnSchema `json:"columns"`           // columns in the table
 	MaxRows int                      `json:"maxRows,omitempty"` // maximum number of rows in the table
 	IsRoot  bool                     `json:"isRoot,omitempty"`  // true if the table rows are part of a root-set (they are not cleaned by garbage collection)
 	Indexes [][]string               `json:"indexes,omitempty"` // indexes in the table
 }
 
+// MarshalJSON implements json.Marshaler, it omits the synthetic _uuid and _version
+// columns added by DbSchema.UnmarshalJSON.
+func (ts TableSchema) MarshalJSON() ([]byte, error) {
+	type TS TableSchema
+	out := TS(ts)
+	out.Columns = make(map[string]*ColumnSchema, len(ts.Columns))
+	for cName, col := range ts.Columns {
+		if !isSynthetic(cName) {
+			out.Columns[cName] = col
+		}
+	}
+	return json.Marshal(out)
+}
+
 // NewRow creates a new row with the given values.
 // The values are given as a list of key-value pairs.
 // The keys are the column names and the values are the column values.
@@ -0,0 +1,142 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+var (
+	idRe      = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	versionRe = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+$`)
+)
+
+var atomicTypes = []string{"integer", "real", "boolean", "string", "uuid"}
+
+// isSynthetic reports whether the column is added to the table by UnmarshalJSON.
+func isSynthetic(cName string) bool {
+	return cName == "_uuid" || cName == "_version"
+}
+
+// Validate checks the schema against the constraints of RFC 7047 and ovsdb-server,
+// so it can be sent to the server (e.g. by convert method).
+func (ds *DbSchema) Validate() error {
+	if !idRe.MatchString(ds.Name) {
+		return fmt.Errorf("invalid db name %q", ds.Name)
+	}
+	if ds.Version != "" && !versionRe.MatchString(ds.Version) {
+		return fmt.Errorf("db %q: invalid version %q", ds.Name, ds.Version)
+	}
+	if len(ds.Tables) == 0 {
+		return fmt.Errorf("db %q: no tables", ds.Name)
+	}
+	for tName, tSch := range ds.Tables {
+		if err := tSch.validate(ds, tName); err != nil {
+			return fmt.Errorf("db %q: %w", ds.Name, err)
+		}
+	}
+	return nil
+}
+
+func (ts *TableSchema) validate(ds *DbSchema, tName string) error {
+	if !idRe.MatchString(tName) || tName[0] == '_' {
+		return fmt.Errorf("invalid table name %q", tName)
+	}
+	if ts.MaxRows < 0 {
+		return fmt.Errorf("table %q: invalid maxRows %d", tName, ts.MaxRows)
+	}
+	nCols := 0
+	for cName, cSch := range ts.Columns {
+		if isSynthetic(cName) {
+			continue
+		}
+		nCols++
+		if !idRe.MatchString(cName) || cName[0] == '_' {
+			return fmt.Errorf("table %q: invalid column name %q", tName, cName)
+		}
+		if err := cSch.Type.validate(ds); err != nil {
+			return fmt.Errorf("table %q: column %q: %w", tName, cName, err)
+		}
+	}
+	if nCols == 0 {
+		return fmt.Errorf("table %q: no columns", tName)
+	}
+	for _, index := range ts.Indexes {
+		if len(index) == 0 {
+			return fmt.Errorf("table %q: empty index", tName)
+		}
+		for i, cName := range index {
+			cSch, ok := ts.Columns[cName]
+			if !ok {
+				return fmt.Errorf("table %q: index column %q not found", tName, cName)
+			}
+			if cSch.Ephemeral {
+				return fmt.Errorf("table %q: ephemeral column %q in index", tName, cName)
+			}
+			if slices.Contains(index[:i], cName) {
+				return fmt.Errorf("table %q: duplicate column %q in index", tName, cName)
+			}
+		}
+	}
+	return nil
+}
+
+func (ct *ColumnType) validate(ds *DbSchema) error {
+	if err := ct.Key.validate(ds); err != nil {
+		return fmt.Errorf("key: %w", err)
+	}
+	if ct.Value != nil {
+		if err := ct.Value.validate(ds); err != nil {
+			return fmt.Errorf("value: %w", err)
+		}
+	}
+	if ct.Min == nil || (*ct.Min != 0 && *ct.Min != 1) {
+		return fmt.Errorf("min must be 0 or 1")
+	}
+	max, ok := ct.Max.(*int)
+	if !ok || max == nil || *max < 1 || *max < *ct.Min {
+		return fmt.Errorf("max must be positive integer not less than min or \"unlimited\"")
+	}
+	return nil
+}
+
+func (bt *BaseType) validate(ds *DbSchema) error {
+	if !slices.Contains(atomicTypes, bt.Type) {
+		return fmt.Errorf("unknown type %q", bt.Type)
+	}
+	if bt.RefTable != nil {
+		if bt.Type != "uuid" {
+			return fmt.Errorf("refTable for non-uuid type %q", bt.Type)
+		}
+		if _, ok := ds.Tables[*bt.RefTable]; !ok {
+			return fmt.Errorf("refTable %q not found", *bt.RefTable)
+		}
+	}
+	if bt.RefType != nil {
+		if bt.RefTable == nil {
+			return fmt.Errorf("refType without refTable")
+		}
+		if *bt.RefType != "strong" && *bt.RefType != "weak" {
+			return fmt.Errorf("invalid refType %q", *bt.RefType)
+		}
+	}
+	if (bt.MinInteger != nil || bt.MaxInteger != nil) && bt.Type != "integer" {
+		return fmt.Errorf("integer constraints for type %q", bt.Type)
+	}
+	if bt.MinInteger != nil && bt.MaxInteger != nil && *bt.MinInteger > *bt.MaxInteger {
+		return fmt.Errorf("minInteger %d > maxInteger %d", *bt.MinInteger, *bt.MaxInteger)
+	}
+	if (bt.MinReal != nil || bt.MaxReal != nil) && bt.Type != "real" {
+		return fmt.Errorf("real constraints for type %q", bt.Type)
+	}
+	if bt.MinReal != nil && bt.MaxReal != nil && *bt.MinReal > *bt.MaxReal {
+		return fmt.Errorf("minReal %f > maxReal %f", *bt.MinReal, *bt.MaxReal)
+	}
+	if (bt.MinLength != nil || bt.MaxLength != nil) && bt.Type != "string" {
+		return fmt.Errorf("length constraints for type %q", bt.Type)
+	}
+	if bt.MinLength != nil && bt.MaxLength != nil && *bt.MinLength > *bt.MaxLength {
+		return fmt.Errorf("minLength %d > maxLength %d", *bt.MinLength, *bt.MaxLength)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package schema
+
+import (
+	_ "embed"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+//go:embed testdata/_Server.json
+var serverSchema []byte
+
+func TestDbSchema_Validate(t *testing.T) {
+	for name, data := range map[string][]byte{"Open_vSwitch": ovsSchema, "_Server": serverSchema} {
+		var db DbSchema
+		require.NoError(t, json.Unmarshal(data, &db), "failed to unmarshal %s", name)
+		assert.NoError(t, db.Validate(), "schema %s should be valid", name)
+	}
+
+	// the version is optional
+	var noVersion DbSchema
+	require.NoError(t, json.Unmarshal(ovsSchema, &noVersion))
+	noVersion.Version = ""
+	assert.NoError(t, noVersion.Validate())
+	data, err := json.Marshal(&noVersion)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"version"`)
+
+	// the map of exactly one pair is accepted by ovsdb-server
+	var singlePair DbSchema
+	require.NoError(t, json.Unmarshal(ovsSchema, &singlePair))
+	one := 1
+	singlePair.Tables["Bridge"].Columns["external_ids"].Type.Min = &one
+	singlePair.Tables["Bridge"].Columns["external_ids"].Type.Max = &one
+	assert.NoError(t, singlePair.Validate())
+
+	failTests := []struct {
+		msg    string
+		mangle func(db *DbSchema)
+	}{
+		{"bad version", func(db *DbSchema) { db.Version = "8.5" }},
+		{"bad db name", func(db *DbSchema) { db.Name = "Open vSwitch" }},
+		{"reserved table name", func(db *DbSchema) { db.Tables["_Bridge"] = db.Tables["Bridge"] }},
+		{"unknown refTable", func(db *DbSchema) {
+			ref := "NoSuchTable"
+			db.Tables["Bridge"].Columns["ports"].Type.Key.RefTable = &ref
+		}},
+		{"bad refType", func(db *DbSchema) {
+			ref := "soft"
+			db.Tables["Bridge"].Columns["ports"].Type.Key.RefType = &ref
+		}},
+		{"unknown atomic type", func(db *DbSchema) { db.Tables["Bridge"].Columns["name"].Type.Key.Type = "text" }},
+		{"min > max", func(db *DbSchema) {
+			m := 2
+			db.Tables["Bridge"].Columns["name"].Type.Min = &m
+		}},
+		{"index on unknown column", func(db *DbSchema) {
+			db.Tables["Bridge"].Indexes = append(db.Tables["Bridge"].Indexes, []string{"no_such_column"})
+		}},
+		{"index on ephemeral column", func(db *DbSchema) {
+			db.Tables["Bridge"].Columns["name"].Ephemeral = true
+		}},
+	}
+	for _, test := range failTests {
+		t.Run(test.msg, func(t *testing.T) {
+			var db DbSchema
+			require.NoError(t, json.Unmarshal(ovsSchema, &db))
+			test.mangle(&db)
+			assert.Error(t, db.Validate())
+		})
+	}
+}
+
+func TestDbSchema_MarshalJSON(t *testing.T) {
+	var db DbSchema
+	require.NoError(t, json.Unmarshal(ovsSchema, &db))
+
+	data, err := json.Marshal(&db)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"_uuid"`)
+	assert.NotContains(t, string(data), `"_version"`)
+	assert.Contains(t, string(data), `"max":"unlimited"`)
+	assert.JSONEq(t, string(ovsSchema), string(data), "marshaled schema should match the original")
+
+	var db2 DbSchema
+	require.NoError(t, json.Unmarshal(data, &db2))
+	assert.Equal(t, db, db2, "schema should survive marshal/unmarshal round trip")
+	assert.False(t, db2.Tables["Bridge"].Columns["name"].Mutable, "Bridge.name is immutable")
+	assert.True(t, db2.Tables["Bridge"].Columns["datapath_id"].Mutable, "columns are mutable by default")
+}
@@ -0,0 +1,353 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/db"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ClusterHeader is the first record of the clustered database file: the identity of the server
+// and the snapshot of the log entries up to PrevIndex, if the log is compacted.
+type ClusterHeader struct {
+	ServerID        string   `json:"server_id"`
+	ClusterID       string   `json:"cluster_id,omitempty"`
+	Name            string   `json:"name"`
+	LocalAddress    string   `json:"local_address"`
+	RemoteAddresses []string `json:"remote_addresses,omitempty"` // set while joining the cluster
+
+	PrevTerm          uint64            `json:"prev_term,omitempty"`
+	PrevIndex         uint64            `json:"prev_index,omitempty"`
+	PrevEID           string            `json:"prev_eid,omitempty"`
+	PrevServers       map[string]string `json:"prev_servers,omitempty"` // server id -> address
+	PrevElectionTimer uint64            `json:"prev_election_timer,omitempty"`
+	PrevData          json.RawMessage   `json:"prev_data,omitempty"` // [schema, content] of the snapshot
+}
+
+// record types of ClusterRecord
+const (
+	RecordEntry       = "entry"
+	RecordTerm        = "term"
+	RecordVote        = "vote"
+	RecordNote        = "note"
+	RecordCommitIndex = "commit_index"
+	RecordLeader      = "leader"
+)
+
+// ClusterRecord is the record of RAFT log following the header. The fields set depend on Type.
+type ClusterRecord struct {
+	Type          string            `json:"-"`
+	Offset        int64             `json:"-"`
+	Term          uint64            `json:"term,omitempty"`
+	Index         uint64            `json:"index,omitempty"`
+	EID           string            `json:"eid,omitempty"`
+	Servers       map[string]string `json:"servers,omitempty"`
+	ElectionTimer uint64            `json:"election_timer,omitempty"`
+	Data          json.RawMessage   `json:"data,omitempty"` // [schema or null, transaction or null]
+	Vote          string            `json:"vote,omitempty"`
+	Leader        string            `json:"leader,omitempty"`
+	Note          string            `json:"note,omitempty"`
+	CommitIndex   uint64            `json:"commit_index,omitempty"`
+}
+
+// ClusterLog is the metadata of the clustered database file.
+type ClusterLog struct {
+	Header  ClusterHeader
+	Records []ClusterRecord
+}
+
+// ReadCluster replays the clustered database file from r: the snapshot of the header
+// and the data of the entries of the log in index order. The entries are applied up to the end
+// of the log, the ones not committed yet (see RecordCommitIndex) included. The database is nil
+// if the log holds no schema yet, e.g. the server has not joined the cluster.
+// If a record is broken, the content and the log read before it are returned along with
+// *RecordError.
+func ReadCluster(r io.Reader) (db.DB, *ClusterLog, error) {
+	log := newLogReader(r)
+	magic, data, err := log.next()
+	if err == io.EOF {
+		return nil, nil, errors.New("storage: empty file")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	switch magic {
+	case magicClustered:
+	case magicStandalone:
+		return nil, nil, errors.New("storage: standalone database, use Read")
+	default:
+		return nil, nil, &RecordError{Offset: 0, Err: fmt.Errorf("%w: unknown magic %q", ErrCorrupted, magic)}
+	}
+	cl := &ClusterLog{}
+	if err := json.Unmarshal(data, &cl.Header); err != nil {
+		return nil, nil, &RecordError{Offset: 0, Err: fmt.Errorf("header: %w", err)}
+	}
+
+	var readErr error
+	for {
+		off := log.off
+		magic, data, err := log.next()
+		if err == io.EOF {
+			break
+		}
+		if err == nil && magic != magicClustered {
+			err = &RecordError{Offset: off, Err: fmt.Errorf("%w: unexpected magic %q", ErrCorrupted, magic)}
+		}
+		if err != nil {
+			readErr = err
+			break
+		}
+		rec, err := parseClusterRecord(data)
+		if err != nil {
+			readErr = &RecordError{Offset: off, Err: err}
+			break
+		}
+		rec.Offset = off
+		cl.Records = append(cl.Records, rec)
+	}
+
+	d, err := cl.replay()
+	if readErr != nil {
+		return d, cl, readErr
+	}
+	return d, cl, err
+}
+
+// LoadCluster reads the clustered database file at path, see ReadCluster.
+func LoadCluster(path string) (db.DB, *ClusterLog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	return ReadCluster(f)
+}
+
+func parseClusterRecord(data []byte) (ClusterRecord, error) {
+	var rec ClusterRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return rec, fmt.Errorf("raft record: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return rec, fmt.Errorf("raft record: %w", err)
+	}
+	has := func(name string) bool {
+		_, ok := fields[name]
+		return ok
+	}
+	switch {
+	case has("note"):
+		rec.Type = RecordNote
+	case has("commit_index"):
+		rec.Type = RecordCommitIndex
+	case has("vote"):
+		rec.Type = RecordVote
+	case has("leader"):
+		rec.Type = RecordLeader
+	case has("index"):
+		rec.Type = RecordEntry
+	case has("term"):
+		rec.Type = RecordTerm
+	default:
+		return rec, fmt.Errorf("%w: unknown raft record %s", ErrCorrupted, data)
+	}
+	return rec, nil
+}
+
+// entries returns the log entries following the snapshot by index, the entry rewritten
+// by a later leader replaces the old one and the entries after it.
+func (cl *ClusterLog) entries() []ClusterRecord {
+	var entries []ClusterRecord
+	for _, rec := range cl.Records {
+		if rec.Type != RecordEntry || rec.Index <= cl.Header.PrevIndex {
+			continue
+		}
+		pos := int(rec.Index - cl.Header.PrevIndex - 1)
+		if pos > len(entries) {
+			// the gap in the log, nothing to apply after it
+			break
+		}
+		entries = append(entries[:pos], rec)
+	}
+	return entries
+}
+
+// replay builds the content from the snapshot and the data of the entries.
+func (cl *ClusterLog) replay() (db.DB, error) {
+	var d db.DB
+	var err error
+	if len(cl.Header.PrevData) > 0 {
+		if d, err = applyEntryData(nil, cl.Header.PrevData); err != nil {
+			return nil, fmt.Errorf("storage: snapshot: %w", err)
+		}
+	}
+	for _, e := range cl.entries() {
+		if len(e.Data) == 0 {
+			continue
+		}
+		if d, err = applyEntryData(d, e.Data); err != nil {
+			return d, &RecordError{Offset: e.Offset, Err: fmt.Errorf("entry %d: %w", e.Index, err)}
+		}
+	}
+	return d, nil
+}
+
+// applyEntryData applies the data of the entry [schema or null, transaction or null] to d.
+// The schema starts the database anew, the transaction following it holds the whole content.
+func applyEntryData(d db.DB, data json.RawMessage) (db.DB, error) {
+	var parts []json.RawMessage
+	if err := json.Unmarshal(data, &parts); err != nil || len(parts) != 2 {
+		return d, fmt.Errorf("%w: invalid data %.64s", ErrCorrupted, data)
+	}
+	if !isNull(parts[0]) {
+		var sch schema.DbSchema
+		if err := json.Unmarshal(parts[0], &sch); err != nil {
+			return d, fmt.Errorf("schema: %w", err)
+		}
+		d = db.NewDB(&sch)
+	}
+	if isNull(parts[1]) {
+		return d, nil
+	}
+	if d == nil {
+		return nil, errors.New("transaction before the schema")
+	}
+	return d, applyRecord(d, parts[1])
+}
+
+func isNull(raw json.RawMessage) bool {
+	return len(raw) == 0 || string(bytes.TrimSpace(raw)) == "null"
+}
+
+// Show writes the log in the manner of "ovsdb-tool show-log": the header and the records
+// with their metadata, the data of the entries is summarized by the tables changed
+// and the comment of the transaction.
+func (cl *ClusterLog) Show(w io.Writer) error {
+	var b strings.Builder
+	h := cl.Header
+	b.WriteString("record 0:\n")
+	fmt.Fprintf(&b, " name: %q\n", h.Name)
+	fmt.Fprintf(&b, " local address: %q\n", h.LocalAddress)
+	fmt.Fprintf(&b, " server_id: %s\n", sid(h.ServerID))
+	if h.ClusterID != "" {
+		fmt.Fprintf(&b, " cluster_id: %s\n", sid(h.ClusterID))
+	}
+	if len(h.RemoteAddresses) > 0 {
+		fmt.Fprintf(&b, " remote_addresses: %s\n", strings.Join(h.RemoteAddresses, " "))
+	}
+	if h.PrevIndex > 0 {
+		fmt.Fprintf(&b, " prev_term: %d\n", h.PrevTerm)
+		fmt.Fprintf(&b, " prev_index: %d\n", h.PrevIndex)
+		if h.PrevEID != "" {
+			fmt.Fprintf(&b, " prev_eid: %s\n", sid(h.PrevEID))
+		}
+		if len(h.PrevServers) > 0 {
+			fmt.Fprintf(&b, " prev_servers: %s\n", servers(h.PrevServers))
+		}
+		if h.PrevElectionTimer > 0 {
+			fmt.Fprintf(&b, " prev_election_timer: %d\n", h.PrevElectionTimer)
+		}
+		fmt.Fprintf(&b, " prev_data: %s\n", summary(h.PrevData))
+	}
+
+	for i, rec := range cl.Records {
+		fmt.Fprintf(&b, "\nrecord %d:\n", i+1)
+		switch rec.Type {
+		case RecordEntry:
+			fmt.Fprintf(&b, " term: %d\n index: %d\n", rec.Term, rec.Index)
+			if rec.EID != "" {
+				fmt.Fprintf(&b, " eid: %s\n", sid(rec.EID))
+			}
+			if len(rec.Servers) > 0 {
+				fmt.Fprintf(&b, " servers: %s\n", servers(rec.Servers))
+			}
+			if rec.ElectionTimer > 0 {
+				fmt.Fprintf(&b, " election_timer: %d\n", rec.ElectionTimer)
+			}
+			if len(rec.Data) > 0 {
+				fmt.Fprintf(&b, " data: %s\n", summary(rec.Data))
+			}
+		case RecordTerm:
+			fmt.Fprintf(&b, " term: %d\n", rec.Term)
+		case RecordVote:
+			fmt.Fprintf(&b, " term: %d\n vote: %s\n", rec.Term, sid(rec.Vote))
+		case RecordLeader:
+			fmt.Fprintf(&b, " term: %d\n leader: %s\n", rec.Term, sid(rec.Leader))
+		case RecordCommitIndex:
+			fmt.Fprintf(&b, " commit_index: %d\n", rec.CommitIndex)
+		case RecordNote:
+			fmt.Fprintf(&b, " note: %q\n", rec.Note)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// sid abbreviates the UUID to 4 hex digits like ovsdb-tool.
+func sid(u string) string {
+	if len(u) < 4 {
+		return u
+	}
+	return u[:4]
+}
+
+func servers(s map[string]string) string {
+	ids := make([]string, 0, len(s))
+	for id := range s {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, fmt.Sprintf("%s(%q)", sid(id), s[id]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// summary describes the data of the entry: the schema and the tables changed by the transaction.
+func summary(data json.RawMessage) string {
+	var parts []json.RawMessage
+	if err := json.Unmarshal(data, &parts); err != nil || len(parts) != 2 {
+		return "<invalid>"
+	}
+	var desc []string
+	if !isNull(parts[0]) {
+		var sch struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		}
+		_ = json.Unmarshal(parts[0], &sch)
+		desc = append(desc, fmt.Sprintf("schema %s %s", sch.Name, sch.Version))
+	}
+	if !isNull(parts[1]) {
+		var txn map[string]json.RawMessage
+		_ = json.Unmarshal(parts[1], &txn)
+		var tables []string
+		for name, raw := range txn {
+			if strings.HasPrefix(name, "_") {
+				continue
+			}
+			var rows map[string]json.RawMessage
+			_ = json.Unmarshal(raw, &rows)
+			tables = append(tables, fmt.Sprintf("%s(%d)", name, len(rows)))
+		}
+		slices.Sort(tables)
+		desc = append(desc, "tables "+strings.Join(tables, " "))
+		if raw, ok := txn["_comment"]; ok {
+			var comment string
+			_ = json.Unmarshal(raw, &comment)
+			desc = append(desc, fmt.Sprintf("comment %q", comment))
+		}
+	}
+	if len(desc) == 0 {
+		return "null"
+	}
+	return strings.Join(desc, ", ")
+}
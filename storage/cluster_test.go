@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	sid1 = "5a3f0c2e-7b1d-4e8a-9c6f-0d2e4b6a8c01"
+	sid2 = "6b4a1d3f-8c2e-4f9b-ad7a-1e3f5c7b9d02"
+	cid  = "7c5b2e4a-9d3f-4a0c-be8b-2f4a6d8cae03"
+)
+
+func clusterRecord(t *testing.T, v string) []byte {
+	data, err := encodeRecord(magicClustered, json.RawMessage(v))
+	require.NoError(t, err)
+	return data
+}
+
+// testCluster returns the clustered file compacted at index 2, with the entry 4 rewritten
+// by the leader of the next term.
+func testCluster(t *testing.T) []byte {
+	var buf bytes.Buffer
+	buf.Write(clusterRecord(t, `{"server_id": "`+sid1+`", "cluster_id": "`+cid+`", "name": "Test",
+		"local_address": "tcp:10.0.0.1:6643", "prev_term": 1, "prev_index": 2,
+		"prev_servers": {"`+sid1+`": "tcp:10.0.0.1:6643"}, "prev_election_timer": 1000,
+		"prev_data": [`+testSchema+`, {"T": {"`+uuid1+`": {"name": "a", "tags": ["set", ["x"]]}}}]}`))
+	buf.Write(clusterRecord(t, `{"term": 1, "vote": "`+sid1+`"}`))
+	buf.Write(clusterRecord(t, `{"term": 1, "index": 3, "servers": {"`+sid1+`": "tcp:10.0.0.1:6643", "`+sid2+`": "tcp:10.0.0.2:6643"}}`))
+	buf.Write(clusterRecord(t, `{"term": 1, "index": 4, "eid": "`+uuid2+`",
+		"data": [null, {"_comment": "lost", "T": {"`+uuid1+`": null}}]}`))
+	buf.Write(clusterRecord(t, `{"term": 2}`))
+	buf.Write(clusterRecord(t, `{"term": 2, "leader": "`+sid2+`"}`))
+	buf.Write(clusterRecord(t, `{"term": 2, "index": 4, "eid": "`+uuid2+`",
+		"data": [null, {"_comment": "add b", "T": {"`+uuid2+`": {"name": "b"}}}]}`))
+	buf.Write(clusterRecord(t, `{"term": 2, "index": 5, "election_timer": 2000}`))
+	buf.Write(clusterRecord(t, `{"term": 2, "index": 6,
+		"data": [null, {"_is_diff": true, "T": {"`+uuid1+`": {"tags": ["set", ["y"]]}}}]}`))
+	buf.Write(clusterRecord(t, `{"commit_index": 6}`))
+	buf.Write(clusterRecord(t, `{"note": "transfer leadership"}`))
+	return buf.Bytes()
+}
+
+func TestReadCluster(t *testing.T) {
+	d, cl, err := ReadCluster(bytes.NewReader(testCluster(t)))
+	require.NoError(t, err)
+	require.NotNil(t, d)
+	assert.Equal(t, "Test", d.Schema().Name)
+	assert.Equal(t, 2, d.TableLen("T"))
+	assert.ElementsMatch(t, types.Set[string]{"x", "y"}, d.GetS("T", uuid1, "tags"))
+	assert.Equal(t, "b", d.GetS("T", uuid2, "name"))
+
+	assert.Equal(t, "Test", cl.Header.Name)
+	assert.Equal(t, uint64(2), cl.Header.PrevIndex)
+	require.Len(t, cl.Records, 10)
+	var kinds []string
+	for _, rec := range cl.Records {
+		kinds = append(kinds, rec.Type)
+	}
+	assert.Equal(t, []string{RecordVote, RecordEntry, RecordEntry, RecordTerm, RecordLeader,
+		RecordEntry, RecordEntry, RecordEntry, RecordCommitIndex, RecordNote}, kinds)
+	assert.Len(t, cl.Records[1].Servers, 2)
+	assert.Equal(t, uint64(2000), cl.Records[6].ElectionTimer)
+
+	var out strings.Builder
+	require.NoError(t, cl.Show(&out))
+	for _, s := range []string{
+		"record 0:\n name: \"Test\"\n",
+		"server_id: 5a3f\n",
+		"prev_servers: 5a3f(\"tcp:10.0.0.1:6643\")\n",
+		"prev_data: schema Test 1.0.0, tables T(1)\n",
+		"record 5:\n term: 2\n leader: 6b4a\n",
+		"data: tables T(1), comment \"add b\"\n",
+		"election_timer: 2000\n",
+		"commit_index: 6\n",
+		"note: \"transfer leadership\"\n",
+	} {
+		assert.Contains(t, out.String(), s)
+	}
+}
+
+func TestReadCluster_Broken(t *testing.T) {
+	data := testCluster(t)
+
+	// the log is cut in the last entry
+	last := bytes.LastIndex(data, []byte(`{"term":2,"index":6`))
+	d, cl, err := ReadCluster(bytes.NewReader(data[:last+10]))
+	assert.ErrorIs(t, err, ErrCorrupted)
+	var recErr *RecordError
+	require.True(t, errors.As(err, &recErr))
+	require.NotNil(t, d)
+	assert.Len(t, cl.Records, 7)
+	assert.Equal(t, 2, d.TableLen("T"))
+	assert.Equal(t, types.Set[string]{"x"}, d.GetS("T", uuid1, "tags"), "the entry cut is not applied")
+
+	_, err = Read(bytes.NewReader(data))
+	assert.Error(t, err, "clustered file is read as standalone")
+	_, _, err = ReadCluster(bytes.NewReader(testFile(t)))
+	assert.Error(t, err, "standalone file is read as clustered")
+}
+
+func TestLoadCluster_Joining(t *testing.T) {
+	// the server joining the cluster has no snapshot yet
+	path := filepath.Join(t.TempDir(), "test.db")
+	rec := clusterRecord(t, `{"server_id": "`+sid2+`", "name": "Test", "local_address": "tcp:10.0.0.2:6643",
+		"remote_addresses": ["tcp:10.0.0.1:6643"]}`)
+	require.NoError(t, os.WriteFile(path, rec, 0o600))
+
+	d, cl, err := LoadCluster(path)
+	require.NoError(t, err)
+	assert.Nil(t, d)
+	assert.Equal(t, []string{"tcp:10.0.0.1:6643"}, cl.Header.RemoteAddresses)
+	assert.Empty(t, cl.Records)
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// magics of the records of the database files
+const (
+	magicStandalone = "OVSDB JSON"
+	magicClustered  = "CLUSTER"
+)
+
+// ErrCorrupted is the cause of RecordError for the records failing the format or checksum checks.
+var ErrCorrupted = errors.New("corrupted record")
+
+// RecordError is the error of reading the record starting at Offset of the file.
+type RecordError struct {
+	Offset int64
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("storage: record at offset %d: %s", e.Offset, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// logReader reads the records of the database file: the header line "<magic> <length> <sha1>"
+// followed by length bytes of JSON text ending with new line, sha1 is the hash of the text.
+type logReader struct {
+	r   *bufio.Reader
+	off int64 // offset of the next record
+}
+
+func newLogReader(r io.Reader) *logReader {
+	return &logReader{r: bufio.NewReader(r)}
+}
+
+// next returns the magic and the text of the next record, io.EOF at the end of the file.
+func (l *logReader) next() (string, []byte, error) {
+	start := l.off
+	fail := func(format string, args ...any) (string, []byte, error) {
+		return "", nil, &RecordError{Offset: start, Err: fmt.Errorf("%w: %s", ErrCorrupted, fmt.Sprintf(format, args...))}
+	}
+
+	header, err := l.r.ReadString('\n')
+	if err == io.EOF && header == "" {
+		return "", nil, io.EOF
+	}
+	if err != nil {
+		return fail("truncated header")
+	}
+	fields := strings.Fields(header)
+	if len(fields) < 3 {
+		return fail("invalid header %q", strings.TrimSpace(header))
+	}
+	magic := strings.Join(fields[:len(fields)-2], " ")
+	length, err := strconv.ParseInt(fields[len(fields)-2], 10, 64)
+	if err != nil || length < 0 {
+		return fail("invalid length in header %q", strings.TrimSpace(header))
+	}
+	sum, err := hex.DecodeString(fields[len(fields)-1])
+	if err != nil || len(sum) != sha1.Size {
+		return fail("invalid checksum in header %q", strings.TrimSpace(header))
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(l.r, data); err != nil {
+		return fail("truncated data")
+	}
+	if actual := sha1.Sum(data); !bytes.Equal(actual[:], sum) {
+		return fail("checksum mismatch")
+	}
+	l.off += int64(len(header)) + length
+	return magic, data, nil
+}
+
+// encodeRecord returns the record of magic holding v as JSON text.
+func encodeRecord(magic string, v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, '\n')
+	sum := sha1.Sum(data)
+	header := fmt.Sprintf("%s %d %x\n", magic, len(data), sum)
+	return append([]byte(header), data...), nil
+}
@@ -0,0 +1,420 @@
+// Package storage reads and writes the standalone database files of ovsdb-server (e.g. conf.db),
+// so the database may be inspected or repaired without a running server. The file is a log
+// of records: the schema of the database followed by the transactions, each one holding
+// the new content of the rows changed (null for the rows deleted). The content is loaded
+// into db.DB by applying the transactions in order.
+//
+// The files of the clustered databases (e.g. OVN NB and SB) hold the RAFT log instead,
+// ReadCluster replays it and returns the metadata of the log along with the content.
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/db"
+	"github.com/kazmanavt/ovsdb/v2/engine"
+	iengine "github.com/kazmanavt/ovsdb/v2/internal/engine"
+	"github.com/kazmanavt/ovsdb/v2/monitor"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/transact"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Read parses the standalone database file from r. If a record after the schema is broken,
+// e.g. the file is truncated, the content of the records before it is returned along with
+// *RecordError, so the data may be recovered.
+func Read(r io.Reader) (db.DB, error) {
+	log := newLogReader(r)
+	magic, data, err := log.next()
+	if err == io.EOF {
+		return nil, errors.New("storage: empty file")
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch magic {
+	case magicStandalone:
+	case magicClustered:
+		return nil, errors.New("storage: clustered database, use ReadCluster")
+	default:
+		return nil, &RecordError{Offset: 0, Err: fmt.Errorf("%w: unknown magic %q", ErrCorrupted, magic)}
+	}
+	var sch schema.DbSchema
+	if err := json.Unmarshal(data, &sch); err != nil {
+		return nil, &RecordError{Offset: 0, Err: fmt.Errorf("schema: %w", err)}
+	}
+
+	d := db.NewDB(&sch)
+	for {
+		off := log.off
+		magic, data, err := log.next()
+		if err == io.EOF {
+			return d, nil
+		}
+		if err != nil {
+			return d, err
+		}
+		if magic != magicStandalone {
+			return d, &RecordError{Offset: off, Err: fmt.Errorf("%w: unexpected magic %q", ErrCorrupted, magic)}
+		}
+		if err := applyRecord(d, data); err != nil {
+			return d, &RecordError{Offset: off, Err: err}
+		}
+	}
+}
+
+// Load reads the standalone database file at path, see Read.
+func Load(path string) (db.DB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// applyRecord applies the transaction record to d. The rows are given by the new values
+// of the columns changed, or by the difference to the old values for sets and maps if the record
+// is marked by "_is_diff" (like the modify of update2).
+func applyRecord(d db.DB, data []byte) error {
+	var rec map[string]json.RawMessage
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return fmt.Errorf("transaction: %w", err)
+	}
+	var isDiff bool
+	if raw, ok := rec["_is_diff"]; ok {
+		if err := json.Unmarshal(raw, &isDiff); err != nil {
+			return fmt.Errorf("transaction: _is_diff: %w", err)
+		}
+	}
+
+	upd := make(monitor.TableSetUpdate2)
+	for tName, raw := range rec {
+		if strings.HasPrefix(tName, "_") {
+			continue
+		}
+		tSch, ok := d.Schema().Tables[tName]
+		if !ok {
+			return fmt.Errorf("transaction: unknown table %q", tName)
+		}
+		var rows map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &rows); err != nil {
+			return fmt.Errorf("transaction: table %q: %w", tName, err)
+		}
+		tUpd := make(monitor.TableUpdate2, len(rows))
+		for u, rowRaw := range rows {
+			old := d.TableRowS(tName, u)
+			if string(rowRaw) == "null" {
+				if old != nil {
+					tUpd[u] = monitor.RowUpdate2{Delete: tSch.NewRow()}
+				}
+				continue
+			}
+			row := tSch.NewRow()
+			if old != nil && !isDiff {
+				// the new values replace the old ones
+				var err error
+				if row, err = copyRow(old); err != nil {
+					return fmt.Errorf("transaction: table %q: row %s: %w", tName, u, err)
+				}
+			}
+			if err := row.UnmarshalJSON(rowRaw); err != nil {
+				return fmt.Errorf("transaction: table %q: row %s: %w", tName, u, err)
+			}
+			switch {
+			case old == nil:
+				tUpd[u] = monitor.RowUpdate2{Insert: row}
+			case isDiff:
+				tUpd[u] = monitor.RowUpdate2{Modify: row}
+			default:
+				diff, err := iengine.RowDiff(old, row)
+				if err != nil {
+					return fmt.Errorf("transaction: table %q: row %s: %w", tName, u, err)
+				}
+				if diff != nil {
+					tUpd[u] = monitor.RowUpdate2{Modify: diff}
+				}
+			}
+		}
+		if len(tUpd) > 0 {
+			upd[tName] = tUpd
+		}
+	}
+	return d.ApplyUpdate2(upd)
+}
+
+func copyRow(row schema.Row) (schema.Row, error) {
+	data, err := row.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	c := row.TableSchema().NewRow()
+	if err := c.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// File is the standalone database file open for appending the transactions.
+type File struct {
+	path string
+	mu   sync.Mutex
+	f    *os.File
+	d    db.DB
+}
+
+// OpenFile loads the standalone database file at path and opens it for appending.
+// The broken file is rejected, use Load to recover its content and Create to write it anew.
+func OpenFile(path string) (*File, error) {
+	d, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &File{path: path, f: f, d: d}, nil
+}
+
+// Create writes the new standalone database file at path holding the schema and the content of d.
+// It fails if the file exists. The returned File owns d.
+func Create(path string, d db.DB) (*File, error) {
+	data, err := snapshot(d)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL|os.O_APPEND, 0o640)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &File{path: path, f: f, d: d}, nil
+}
+
+// DB returns the content of the file. It must be changed by Append or Transact only.
+func (f *File) DB() db.DB {
+	return f.d
+}
+
+// Schema returns the schema of the database.
+func (f *File) Schema() *schema.DbSchema {
+	return f.d.Schema()
+}
+
+// Append appends the transaction record of the changes upd (e.g. returned by engine.Run)
+// to the file and applies them to the content once the record is synced. If the record
+// is not written completely, the file is closed, so nothing is appended after the torn record.
+func (f *File) Append(upd monitor.TableSetUpdate2, comment string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.f == nil {
+		return os.ErrClosed
+	}
+	rec := map[string]any{"_date": time.Now().UnixMilli()}
+	if comment != "" {
+		rec["_comment"] = comment
+	}
+	for tName, tUpd := range upd {
+		tSch, ok := f.d.Schema().Tables[tName]
+		if !ok {
+			continue
+		}
+		rows := make(map[string]any, len(tUpd))
+		for u, rowUpd := range tUpd {
+			row, err := f.newRow(tName, u, rowUpd)
+			if err != nil {
+				return fmt.Errorf("storage: apply changes: %w", err)
+			}
+			if row == nil {
+				rows[u] = nil
+				continue
+			}
+			cNames := columnNames(tSch, row)
+			if rowUpd.Modify != nil {
+				cNames = columnNames(tSch, rowUpd.Modify)
+			}
+			rows[u] = columnValues(tSch, row, cNames)
+		}
+		rec[tName] = rows
+	}
+	data, err := encodeRecord(magicStandalone, rec)
+	if err != nil {
+		return fmt.Errorf("storage: encode transaction: %w", err)
+	}
+	if _, err := f.f.Write(data); err != nil {
+		_ = f.f.Close()
+		f.f = nil
+		return err
+	}
+	if err := f.f.Sync(); err != nil {
+		_ = f.f.Close()
+		f.f = nil
+		return err
+	}
+	if err := f.d.ApplyUpdate2(upd); err != nil {
+		return fmt.Errorf("storage: apply changes: %w", err)
+	}
+	return nil
+}
+
+// newRow returns the row u of the table tName as changed by rowUpd, nil if it is deleted.
+// The content is not changed.
+func (f *File) newRow(tName, u string, rowUpd monitor.RowUpdate2) (schema.Row, error) {
+	switch {
+	case rowUpd.Initial != nil:
+		return rowUpd.Initial, nil
+	case rowUpd.Insert != nil:
+		return rowUpd.Insert, nil
+	case rowUpd.Modify != nil:
+		old := f.d.TableRowS(tName, u)
+		if old == nil {
+			return nil, fmt.Errorf("modify of unknown row %s in table %q", u, tName)
+		}
+		row, err := copyRow(old)
+		if err != nil {
+			return nil, err
+		}
+		if err := row.Update2(rowUpd.Modify); err != nil {
+			return nil, err
+		}
+		return row, nil
+	}
+	return nil, nil
+}
+
+// Transact executes the transaction tr on the content of the file by engine.Run
+// and appends the changes, see Append.
+func (f *File) Transact(tr transact.Transaction, comment string) error {
+	upd, err := engine.Run(f.d, tr)
+	if err != nil {
+		return err
+	}
+	return f.Append(upd, comment)
+}
+
+// Compact replaces the file by the one holding the schema and the current content only.
+// The new file is written aside and renamed over the old one.
+func (f *File) Compact() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.f == nil {
+		return os.ErrClosed
+	}
+	data, err := snapshot(f.d)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if fi, err := f.f.Stat(); err == nil {
+		_ = tmp.Chmod(fi.Mode().Perm())
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return err
+	}
+	nf, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	_ = f.f.Close()
+	f.f = nf
+	return nil
+}
+
+// Close closes the file.
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.f == nil {
+		return nil
+	}
+	err := f.f.Close()
+	f.f = nil
+	return err
+}
+
+// snapshot returns the records of the schema and of the transaction inserting the content of d.
+func snapshot(d db.DB) ([]byte, error) {
+	sch := d.Schema()
+	data, err := encodeRecord(magicStandalone, sch)
+	if err != nil {
+		return nil, fmt.Errorf("storage: encode schema: %w", err)
+	}
+	rec := map[string]any{"_date": time.Now().UnixMilli()}
+	for tName, tSch := range sch.Tables {
+		uuids := d.FindRecord(tName, nil)
+		if len(uuids) == 0 {
+			continue
+		}
+		rows := make(map[string]any, len(uuids))
+		for _, u := range uuids {
+			if row := d.TableRowS(tName, u); row != nil {
+				rows[u] = columnValues(tSch, row, columnNames(tSch, row))
+			}
+		}
+		rec[tName] = rows
+	}
+	if len(rec) == 1 {
+		return data, nil
+	}
+	txn, err := encodeRecord(magicStandalone, rec)
+	if err != nil {
+		return nil, fmt.Errorf("storage: encode content: %w", err)
+	}
+	return append(data, txn...), nil
+}
+
+// columnNames returns the names of the real columns set in the row.
+func columnNames(tSch *schema.TableSchema, row schema.Row) []string {
+	var cNames []string
+	for cName := range tSch.Columns {
+		if cName == "_uuid" || cName == "_version" {
+			continue
+		}
+		if _, ok := row.GetE(cName); ok {
+			cNames = append(cNames, cName)
+		}
+	}
+	return cNames
+}
+
+// columnValues returns the values of the columns of the row, the default ones for the columns not set.
+func columnValues(tSch *schema.TableSchema, row schema.Row, cNames []string) map[string]any {
+	vals := make(map[string]any, len(cNames))
+	for _, cName := range cNames {
+		if v, ok := row.GetE(cName); ok && v != nil {
+			vals[cName] = v
+		} else {
+			vals[cName] = tSch.Columns[cName].GetDefaultValue()
+		}
+	}
+	return vals
+}
@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/kazmanavt/ovsdb/v2/db"
+	"github.com/kazmanavt/ovsdb/v2/monitor"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/transact"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSchema = `{
+  "name": "Test",
+  "version": "1.0.0",
+  "tables": {
+    "T": {
+      "columns": {
+        "name": {"type": "string"},
+        "tags": {"type": {"key": "string", "min": 0, "max": "unlimited"}},
+        "opts": {"type": {"key": "string", "value": "string", "min": 0, "max": "unlimited"}}
+      }
+    }
+  }
+}`
+
+const (
+	uuid1 = "1b5e2f5e-0c4b-4f62-9d8e-2f0f4c7f8a01"
+	uuid2 = "2c6f3a6f-1d5c-4a73-8e9f-3a1a5d8a9b02"
+)
+
+func record(t *testing.T, v string) []byte {
+	data, err := encodeRecord(magicStandalone, json.RawMessage(v))
+	require.NoError(t, err)
+	return data
+}
+
+// testFile returns the file written by ovsdb-server: the full new values of the columns
+// changed and the diff of the sets and maps in "_is_diff" records.
+func testFile(t *testing.T) []byte {
+	var buf bytes.Buffer
+	buf.Write(record(t, testSchema))
+	buf.Write(record(t, `{"_date": 1, "_comment": "init", "T": {
+		"`+uuid1+`": {"name": "a", "tags": ["set", ["x", "y"]], "opts": ["map", [["k", "v"]]]},
+		"`+uuid2+`": {"name": "b"}}}`))
+	buf.Write(record(t, `{"_date": 2, "T": {"`+uuid1+`": {"tags": ["set", ["y", "z"]]}}}`))
+	buf.Write(record(t, `{"_date": 3, "_is_diff": true, "T": {"`+uuid1+`": {"tags": ["set", ["w", "y"]], "opts": ["map", [["k", "v2"]]]}}}`))
+	buf.Write(record(t, `{"_date": 4, "T": {"`+uuid2+`": null}}`))
+	return buf.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	d, err := Read(bytes.NewReader(testFile(t)))
+	require.NoError(t, err)
+	assert.Equal(t, "Test", d.Schema().Name)
+	assert.Equal(t, 1, d.TableLen("T"))
+	assert.Nil(t, d.TableRowS("T", uuid2))
+	row := d.TableRowS("T", uuid1)
+	require.NotNil(t, row)
+	assert.Equal(t, "a", row.Get("name"))
+	assert.ElementsMatch(t, types.Set[string]{"z", "w"}, row.Get("tags"))
+	assert.Equal(t, types.Map[string, string]{"k": "v2"}, row.Get("opts"))
+}
+
+func TestRead_Broken(t *testing.T) {
+	data := testFile(t)
+
+	// the last record is truncated
+	d, err := Read(bytes.NewReader(data[:len(data)-5]))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrCorrupted)
+	var recErr *RecordError
+	require.True(t, errors.As(err, &recErr))
+	assert.Equal(t, int64(bytes.LastIndex(data, []byte(magicStandalone))), recErr.Offset)
+	require.NotNil(t, d)
+	assert.NotNil(t, d.TableRowS("T", uuid2))
+
+	// the checksum of the record does not match
+	broken := bytes.Replace(data, []byte(`"name":"b"`), []byte(`"name":"c"`), 1)
+	require.NotEqual(t, data, broken)
+	_, err = Read(bytes.NewReader(broken))
+	assert.ErrorIs(t, err, ErrCorrupted)
+
+	_, err = Read(strings.NewReader("garbage\n"))
+	assert.ErrorIs(t, err, ErrCorrupted)
+}
+
+func TestFile(t *testing.T) {
+	var sch schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &sch))
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	f, err := Create(path, db.NewDB(&sch))
+	require.NoError(t, err)
+	_, err = Create(path, db.NewDB(&sch))
+	require.Error(t, err, "existing file is overwritten")
+
+	tr := transact.NewTransaction(&sch)
+	tr.Insert(sch.Tables["T"].NewRow("name", "a", "tags", types.Set[string]{"x"}))
+	tr.Insert(sch.Tables["T"].NewRow("name", "b"))
+	require.NoError(t, f.Transact(tr, "insert"))
+	tr = transact.NewTransaction(&sch)
+	tr.Mutate("T", []types.Condition{types.Equal("name", "a")},
+		[]types.Mutation{types.Insert("tags", types.Set[string]{"y"})})
+	tr.Delete("T", []types.Condition{types.Equal("name", "b")})
+	require.NoError(t, f.Transact(tr, ""))
+	require.NoError(t, f.Close())
+
+	check := func(t *testing.T) {
+		d, err := Load(path)
+		require.NoError(t, err)
+		require.Equal(t, 1, d.TableLen("T"))
+		u := d.FindRecord("T", []types.Condition{types.Equal("name", "a")})
+		require.Len(t, u, 1)
+		assert.ElementsMatch(t, types.Set[string]{"x", "y"}, d.GetS("T", u[0], "tags"))
+	}
+	check(t)
+
+	f, err = OpenFile(path)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, f.Compact())
+	check(t)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, bytes.Count(data, []byte(magicStandalone)), "schema and content records")
+
+	// the file is appended after the compaction
+	tr = transact.NewTransaction(&sch)
+	tr.Update([]types.Condition{types.Equal("name", "a")}, sch.Tables["T"].NewRow("opts", types.Map[string, string]{"k": "v"}))
+	require.NoError(t, f.Transact(tr, ""))
+	d, err := Load(path)
+	require.NoError(t, err)
+	u := d.FindRecord("T", nil)
+	require.Len(t, u, 1)
+	assert.Equal(t, types.Map[string, string]{"k": "v"}, d.GetS("T", u[0], "opts"))
+	assert.ElementsMatch(t, types.Set[string]{"x", "y"}, d.GetS("T", u[0], "tags"))
+}
+
+func TestFile_AppendFailed(t *testing.T) {
+	var sch schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &sch))
+	path := filepath.Join(t.TempDir(), "test.db")
+	f, err := Create(path, db.NewDB(&sch))
+	require.NoError(t, err)
+	defer f.Close()
+	size := func() int64 {
+		fi, err := os.Stat(path)
+		require.NoError(t, err)
+		return fi.Size()
+	}
+	written := size()
+
+	// the modify of unknown row is rejected before writing
+	err = f.Append(monitor.TableSetUpdate2{"T": {uuid1: {Modify: sch.Tables["T"].NewRow("name", "a")}}}, "")
+	require.Error(t, err)
+	assert.Equal(t, written, size())
+
+	// the content is not changed if the record is not written, the file is closed
+	require.NoError(t, f.f.Close())
+	tr := transact.NewTransaction(&sch).Insert(sch.Tables["T"].NewRow("name", "a"))
+	require.Error(t, f.Transact(tr, ""))
+	assert.Equal(t, 0, f.DB().TableLen("T"))
+	tr = transact.NewTransaction(&sch).Insert(sch.Tables["T"].NewRow("name", "a"))
+	assert.ErrorIs(t, f.Transact(tr, ""), os.ErrClosed)
+}
@@ -1,6 +1,47 @@
 package transact
 
-func (t *transaction) Assert() Transaction {
-	panic("implement me")
+import (
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"regexp"
+)
+
+var lockIdRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+type assertOp struct {
+	Op   string `json:"op"`
+	Lock string `json:"lock"`
+}
+
+func (a *assertOp) Name() string {
+	return a.Op
+}
+
+func (a *assertOp) Validate(_ *schema.DbSchema) error {
+	if !lockIdRe.MatchString(a.Lock) {
+		return fmt.Errorf("invalid lock name %q in assert operation", a.Lock)
+	}
 	return nil
 }
+
+// Assert adds the operation failing the transaction with "not owner" error
+// if the client does not own the lock (RFC 7047 5.2.11).
+func (t *transaction) Assert(lock string) Transaction {
+	t.txnSet = append(t.txnSet, &assertOp{
+		Op:   "assert",
+		Lock: lock,
+	})
+	t.resp = append(t.resp, &Result{})
+	return t
+}
+
+// AssertedLocks returns the names of the locks asserted by the transaction.
+func (t *transaction) AssertedLocks() []string {
+	var locks []string
+	for _, op := range t.txnSet {
+		if a, ok := op.(*assertOp); ok {
+			locks = append(locks, a.Lock)
+		}
+	}
+	return locks
+}
@@ -1,6 +1,7 @@
 package transact
 
 import (
+	"fmt"
 	"github.com/kazmanavt/ovsdb/v2/schema"
 	"github.com/kazmanavt/ovsdb/v2/types"
 )
@@ -17,3 +18,24 @@ type Result struct {
 	Rows    schema.Rows `json:"rows,omitempty"`
 	Count   int         `json:"count,omitempty"`
 }
+
+// toRow returns the row given to Insert or Update: schema.Row as is, or the one created
+// from schema.Model by the schema of the transaction.
+func (t *transaction) toRow(row any) (schema.Row, error) {
+	switch r := row.(type) {
+	case nil:
+		return nil, nil
+	case schema.Row:
+		return r, nil
+	case schema.Model:
+		return t.sch.RowFromModel(r)
+	}
+	return nil, fmt.Errorf("%T is neither schema.Row nor schema.Model", row)
+}
+
+func tableName(row any) string {
+	if m, ok := row.(schema.Model); ok {
+		return m.TableName()
+	}
+	return ""
+}
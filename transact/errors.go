@@ -0,0 +1,66 @@
+package transact
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors of the transaction operations defined by RFC 7047 and ovsdb-server.
+// OpError matches them by errors.Is according to its Tag.
+var (
+	ErrReferentialIntegrity = errors.New("referential integrity violation")
+	ErrConstraintViolation  = errors.New("constraint violation")
+	ErrResourcesExhausted   = errors.New("resources exhausted")
+	ErrIO                   = errors.New("I/O error")
+	ErrDuplicateUUIDName    = errors.New("duplicate uuid-name")
+	ErrDomain               = errors.New("domain error")
+	ErrRange                = errors.New("range error")
+	ErrTimedOut             = errors.New("timed out")
+	ErrNotSupported         = errors.New("not supported")
+	ErrAborted              = errors.New("aborted")
+	ErrNotOwner             = errors.New("not owner")
+	ErrSyntax               = errors.New("syntax error")
+	ErrUnknownDatabase      = errors.New("unknown database")
+	ErrPermissionError      = errors.New("permission error")
+)
+
+var tagErrors = map[string]error{}
+
+func init() {
+	for _, err := range []error{
+		ErrReferentialIntegrity, ErrConstraintViolation, ErrResourcesExhausted, ErrIO,
+		ErrDuplicateUUIDName, ErrDomain, ErrRange, ErrTimedOut, ErrNotSupported,
+		ErrAborted, ErrNotOwner, ErrSyntax, ErrUnknownDatabase, ErrPermissionError,
+	} {
+		tagErrors[err.Error()] = err
+	}
+}
+
+// OpError is the error of the transaction reported by the server.
+type OpError struct {
+	// Index of the failed operation, or -1 for the error detected on commit
+	// (e.g. referential integrity violation), which is not tied to an operation.
+	Index   int
+	Op      string // name of the failed operation, empty if Index is -1
+	Tag     string // error tag, e.g. "constraint violation"
+	Details string // details of the error, if provided by the server
+}
+
+func (e *OpError) Error() string {
+	var msg string
+	if e.Index < 0 {
+		msg = fmt.Sprintf("general transaction error: %s", e.Tag)
+	} else {
+		msg = fmt.Sprintf("operation #%d(%s): %s", e.Index, e.Op, e.Tag)
+	}
+	if e.Details != "" {
+		msg += fmt.Sprintf(" (%s)", e.Details)
+	}
+	return msg
+}
+
+// Is reports whether target is the sentinel error of e.Tag, e.g. ErrConstraintViolation.
+func (e *OpError) Is(target error) bool {
+	err, ok := tagErrors[e.Tag]
+	return ok && err == target
+}
@@ -0,0 +1,49 @@
+package transact
+
+import (
+	"encoding/json"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestOpError(t *testing.T) {
+	var sch schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &sch))
+
+	tr := NewTransaction(&sch).Delete("Child", nil)
+	require.NoError(t, tr.DecodeResult(json.RawMessage(`[
+		{"count": 1},
+		{"error": "referential integrity violation", "details": "cannot delete Child row"}
+	]`)))
+	err := tr.Error()
+	require.ErrorIs(t, err, ErrReferentialIntegrity)
+	assert.NotErrorIs(t, err, ErrConstraintViolation)
+	var opErr *OpError
+	require.ErrorAs(t, err, &opErr)
+	assert.Equal(t, -1, opErr.Index)
+	assert.Empty(t, opErr.Op)
+	assert.Equal(t, "general transaction error: referential integrity violation (cannot delete Child row)", err.Error())
+
+	tr = NewTransaction(&sch).Select("Child", nil, nil).Mutate("Child", nil, nil)
+	require.NoError(t, tr.DecodeResult(json.RawMessage(`[{"error": "timed out"}, null]`)))
+	err = tr.Error()
+	require.ErrorIs(t, err, ErrTimedOut)
+	assert.Equal(t, "operation #0(select): timed out", err.Error())
+}
+
+func TestAssert(t *testing.T) {
+	var sch schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &sch))
+
+	tr := NewTransaction(&sch).Assert("my_lock").Delete("Child", nil).Assert("other")
+	require.NoError(t, tr.Validate())
+	assert.Equal(t, []string{"my_lock", "other"}, tr.AssertedLocks())
+	ops, err := json.Marshal(tr.Operations())
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"op":"assert","lock":"my_lock"},{"op":"delete","table":"Child","where":null},{"op":"assert","lock":"other"}]`, string(ops))
+
+	require.Error(t, NewTransaction(&sch).Assert("").Validate())
+	require.Error(t, NewTransaction(&sch).Assert("bad lock").Validate())
+}
@@ -3,6 +3,7 @@ package transact
 import (
 	"fmt"
 	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/types"
 )
 
 type insertOp struct {
@@ -10,6 +11,7 @@ type insertOp struct {
 	Table string     `json:"table"`
 	Row   schema.Row `json:"row"`
 	Uuid  *string    `json:"uuid-name,omitempty"`
+	err   error      // of the conversion of the model to the row
 }
 
 func (i *insertOp) Name() string {
@@ -17,24 +19,39 @@ func (i *insertOp) Name() string {
 }
 
 func (i *insertOp) Validate(_ *schema.DbSchema) error {
+	if i.err != nil {
+		return fmt.Errorf("insert: %w", i.err)
+	}
 	if i.Row == nil {
 		return fmt.Errorf("nil row to insert operation")
 	}
 	return nil
 }
 
-func (t *transaction) Insert(row schema.Row, uuid ...string) Transaction {
-	uu := new(string)
-	if len(uuid) > 0 {
+func (t *transaction) Insert(row any, uuid ...string) Transaction {
+	var uu *string
+	if len(uuid) > 0 && uuid[0] != "" {
 		uu = &uuid[0]
 	}
 
+	r, err := t.toRow(row)
 	t.txnSet = append(t.txnSet, &insertOp{
 		Op:    "insert",
-		Table: row.TableName(),
-		Row:   row,
+		Table: tableName(row),
+		Row:   r,
 		Uuid:  uu,
+		err:   err,
 	})
 	t.resp = append(t.resp, &Result{})
 	return t
 }
+
+// InsertRef adds insert operation with generated uuid-name and returns the named UUID
+// of the row to be inserted. The returned UUID can be used in the row columns and
+// conditions of other operations of the transaction to refer to the new row,
+// and to get the real UUID from Results after the transaction is committed.
+func (t *transaction) InsertRef(row any) types.UUID {
+	name := types.NewNamedUUID()
+	t.Insert(row, name)
+	return types.UUID(name)
+}
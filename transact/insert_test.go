@@ -0,0 +1,49 @@
+package transact
+
+import (
+	"encoding/json"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type parent struct {
+	Name     string       `ovsdb:"name"`
+	Kind     string       `ovsdb:"kind"`
+	Children []types.UUID `ovsdb:"children,omitempty"`
+}
+
+func (parent) TableName() string {
+	return "Parent"
+}
+
+func TestInsertUpdate_Model(t *testing.T) {
+	var sch schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &sch))
+
+	tr := NewTransaction(&sch)
+	ref := tr.InsertRef(parent{Name: "p1", Kind: "k"})
+	tr.Update([]types.Condition{types.Equal("name", "p1")}, &parent{Name: "p2", Kind: "k", Children: []types.UUID{ref}})
+	require.NoError(t, tr.Validate())
+	data, err := json.Marshal(tr.Operations())
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"op": "insert", "table": "Parent", "row": {"name": "p1", "kind": "k"}, "uuid-name": "`+string(ref)+`"},
+		{"op": "update", "table": "Parent", "where": [["name", "==", "p1"]],
+		 "row": {"name": "p2", "children": ["named-uuid", "`+string(ref)+`"]}}
+	]`, string(data), "immutable kind is not updated")
+
+	// the model not fitting the schema fails the transaction
+	var bad struct {
+		parent
+		Name int `ovsdb:"name"`
+	}
+	tr = NewTransaction(&sch)
+	tr.Insert(bad)
+	assert.ErrorContains(t, tr.Validate(), "insert")
+	tr = NewTransaction(&sch)
+	tr.Update(nil, "not a row")
+	assert.ErrorContains(t, tr.Validate(), "update")
+}
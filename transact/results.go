@@ -0,0 +1,86 @@
+package transact
+
+import (
+	"fmt"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/types"
+)
+
+// Results gives typed access to the results of the committed transaction.
+type Results struct {
+	t *transaction
+}
+
+func (t *transaction) Results() Results {
+	return Results{t: t}
+}
+
+func (r Results) result(idx int, ops ...string) (*Result, error) {
+	if idx < 0 || idx >= len(r.t.txnSet) {
+		return nil, fmt.Errorf("operation #%d out of range", idx)
+	}
+	name := r.t.txnSet[idx].Name()
+	for _, op := range ops {
+		if op == name {
+			if idx >= len(r.t.resp) || r.t.resp[idx] == nil {
+				return nil, fmt.Errorf("operation #%d(%s): no result", idx, name)
+			}
+			return r.t.resp[idx], nil
+		}
+	}
+	return nil, fmt.Errorf("operation #%d is %s, not %v", idx, name, ops)
+}
+
+// UUID returns the real UUID of the row inserted with the named UUID
+// (returned by InsertRef or given to Insert).
+func (r Results) UUID(named types.UUID) (types.UUID, bool) {
+	for i, op := range r.t.txnSet {
+		ins, ok := op.(*insertOp)
+		if !ok || ins.Uuid == nil || *ins.Uuid != string(named) || i >= len(r.t.resp) || r.t.resp[i] == nil {
+			continue
+		}
+		u := r.t.resp[i].Uuid
+		return u, u != ""
+	}
+	return "", false
+}
+
+// NamedUUIDs returns the map of named UUIDs of the inserted rows to the real ones.
+func (r Results) NamedUUIDs() map[types.UUID]types.UUID {
+	res := make(map[types.UUID]types.UUID)
+	for i, op := range r.t.txnSet {
+		ins, ok := op.(*insertOp)
+		if !ok || ins.Uuid == nil || i >= len(r.t.resp) || r.t.resp[i] == nil || r.t.resp[i].Uuid == "" {
+			continue
+		}
+		res[types.UUID(*ins.Uuid)] = r.t.resp[i].Uuid
+	}
+	return res
+}
+
+// Inserted returns the UUID of the row inserted by insert operation #idx.
+func (r Results) Inserted(idx int) (types.UUID, error) {
+	res, err := r.result(idx, "insert")
+	if err != nil {
+		return "", err
+	}
+	return res.Uuid, nil
+}
+
+// Count returns the number of rows affected by update, mutate or delete operation #idx.
+func (r Results) Count(idx int) (int, error) {
+	res, err := r.result(idx, "update", "mutate", "delete")
+	if err != nil {
+		return 0, err
+	}
+	return res.Count, nil
+}
+
+// Rows returns the rows selected by select operation #idx.
+func (r Results) Rows(idx int) ([]schema.Row, error) {
+	res, err := r.result(idx, "select")
+	if err != nil {
+		return nil, err
+	}
+	return res.Rows.Rows, nil
+}
@@ -0,0 +1,122 @@
+package transact
+
+import (
+	"encoding/json"
+	"github.com/kazmanavt/ovsdb/v2/schema"
+	"github.com/kazmanavt/ovsdb/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const testSchema = `{
+  "name": "Test",
+  "version": "1.0.0",
+  "tables": {
+    "Parent": {
+      "columns": {
+        "name": {"type": "string"},
+        "kind": {"type": "string", "mutable": false},
+        "children": {"type": {"key": {"type": "uuid", "refTable": "Child"}, "min": 0, "max": "unlimited"}}
+      }
+    },
+    "Child": {
+      "columns": {
+        "name": {"type": "string"}
+      }
+    }
+  }
+}`
+
+func TestResults(t *testing.T) {
+	var sch schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &sch))
+
+	tr := NewTransaction(&sch)
+	child := sch.Tables["Child"].NewRow()
+	child.Set("name", "c1")
+	childRef := tr.InsertRef(child)
+
+	parent := sch.Tables["Parent"].NewRow()
+	parent.Set("name", "p1")
+	parent.Set("children", types.Set[types.UUID]{childRef})
+	tr.Insert(parent)
+	tr.Select("Child", []types.Condition{types.Equal("_uuid", childRef)}, []string{"name"})
+	tr.Delete("Child", []types.Condition{types.Equal("name", "c0")})
+
+	ops, err := json.Marshal(tr.Operations())
+	require.NoError(t, err)
+	var raw []map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(ops, &raw))
+	assert.JSONEq(t, `"`+string(childRef)+`"`, string(raw[0]["uuid-name"]))
+	assert.NotContains(t, raw[1], "uuid-name")
+	assert.JSONEq(t, `{"name":"p1","children":["named-uuid","`+string(childRef)+`"]}`, string(raw[1]["row"]))
+
+	require.NoError(t, tr.DecodeResult(json.RawMessage(`[
+		{"uuid": ["uuid", "8cc2eb5c-8e66-4554-af1d-8fa5b9321f99"]},
+		{"uuid": ["uuid", "1b2d5f1e-41d3-4a4c-9b7e-4f1c7e5a9d3c"]},
+		{"rows": [{"name": "c1"}]},
+		{"count": 2}
+	]`)))
+	require.NoError(t, tr.Error())
+
+	res := tr.Results()
+	u, ok := res.UUID(childRef)
+	require.True(t, ok)
+	assert.Equal(t, types.UUID("8cc2eb5c-8e66-4554-af1d-8fa5b9321f99"), u)
+	assert.Equal(t, map[types.UUID]types.UUID{childRef: u}, res.NamedUUIDs())
+
+	u, err = res.Inserted(1)
+	require.NoError(t, err)
+	assert.Equal(t, types.UUID("1b2d5f1e-41d3-4a4c-9b7e-4f1c7e5a9d3c"), u)
+
+	rows, err := res.Rows(2)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "c1", rows[0].Get("name"))
+
+	n, err := res.Count(3)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	_, err = res.Count(2)
+	assert.Error(t, err)
+	_, err = res.Rows(5)
+	assert.Error(t, err)
+}
+
+func TestTransaction_Append(t *testing.T) {
+	var sch schema.DbSchema
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &sch))
+
+	ops := NewTransaction(&sch)
+	child := sch.Tables["Child"].NewRow()
+	child.Set("name", "c1")
+	childRef := ops.InsertRef(child)
+	ops.Delete("Child", []types.Condition{types.Equal("name", "c0")})
+
+	tr := NewTransaction(&sch).
+		WaitNow("Child", []types.Condition{}, []string{"name"}, "==", []schema.Row{}).
+		Wait("Child", []types.Condition{}, []string{"name"}, "!=", []schema.Row{}, 0).
+		Append(ops)
+	require.Equal(t, 4, tr.Len())
+
+	data, err := json.Marshal(tr.Operations())
+	require.NoError(t, err)
+	var raw []map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &raw))
+	assert.JSONEq(t, `0`, string(raw[0]["timeout"]))
+	assert.NotContains(t, raw[1], "timeout")
+
+	require.NoError(t, tr.DecodeResult(json.RawMessage(`[
+		{}, {},
+		{"uuid": ["uuid", "8cc2eb5c-8e66-4554-af1d-8fa5b9321f99"]},
+		{"count": 2}
+	]`)))
+	u, ok := ops.Results().UUID(childRef)
+	require.True(t, ok)
+	assert.Equal(t, types.UUID("8cc2eb5c-8e66-4554-af1d-8fa5b9321f99"), u)
+	n, err := ops.Results().Count(1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
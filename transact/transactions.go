@@ -2,7 +2,6 @@ package transact
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/kazmanavt/ovsdb/v2/schema"
 	"github.com/kazmanavt/ovsdb/v2/types"
@@ -17,21 +16,34 @@ func NewTransaction(sch *schema.DbSchema) Transaction {
 }
 
 type Transaction interface {
-	Insert(row schema.Row, uuid ...string) Transaction
+	// Insert adds the insert operation of the row, either schema.Row or the struct
+	// implementing schema.Model (see schema.TableSchema.RowFromStruct).
+	Insert(row any, uuid ...string) Transaction
+	InsertRef(row any) types.UUID
 	Select(tName string, where []types.Condition, columns []string) Transaction
-	Update(where []types.Condition, row schema.Row) Transaction
+	// Update adds the update operation of the row, either schema.Row or schema.Model like for Insert.
+	// The immutable columns of schema.Model are not updated.
+	Update(where []types.Condition, row any) Transaction
 	Mutate(tName string, where []types.Condition, mutt []types.Mutation) Transaction
 	Delete(tName string, where []types.Condition) Transaction
 	Wait(tName string, where []types.Condition, columns []string, until string, rows []schema.Row, timeout int) Transaction
+	// WaitNow adds the wait operation with zero timeout, the transaction fails
+	// with "timed out" error at once if the condition is not met.
+	WaitNow(tName string, where []types.Condition, columns []string, until string, rows []schema.Row) Transaction
 	Commit(durable bool) Transaction
 	Abort() Transaction
 	Comment(comment string) Transaction
-	Assert() Transaction
+	Assert(lock string) Transaction
+	// Append appends the operations of other to the transaction,
+	// the results of them are decoded into other as well.
+	Append(other Transaction) Transaction
+	AssertedLocks() []string
 	Validate() error
 	Operations() []operation
 	Len() int
 	DecodeResult(result json.RawMessage) error
 	Result(idx int) *Result
+	Results() Results
 	Error() error
 	Clone() Transaction
 }
@@ -39,6 +51,8 @@ type transaction struct {
 	sch    *schema.DbSchema
 	txnSet []operation
 	resp   []*Result
+	// appended transactions sharing the results, by the index of their first operation
+	appended map[int]*transaction
 }
 
 func (t *transaction) Clone() Transaction {
@@ -46,6 +60,20 @@ func (t *transaction) Clone() Transaction {
 	return &newT
 }
 
+func (t *transaction) Append(other Transaction) Transaction {
+	if o, ok := other.(*transaction); ok && o.Len() > 0 {
+		if t.appended == nil {
+			t.appended = make(map[int]*transaction)
+		}
+		t.appended[len(t.txnSet)] = o
+	}
+	for _, op := range other.Operations() {
+		t.txnSet = append(t.txnSet, op)
+		t.resp = append(t.resp, &Result{})
+	}
+	return t
+}
+
 func (t *transaction) Validate() error {
 	if t == nil {
 		return fmt.Errorf("nil transaction")
@@ -67,27 +95,35 @@ func (t *transaction) Len() int {
 }
 
 func (t *transaction) DecodeResult(result json.RawMessage) error {
-	return json.Unmarshal(result, &t.resp)
+	if err := json.Unmarshal(result, &t.resp); err != nil {
+		return err
+	}
+	for off, o := range t.appended {
+		o.resp = t.resp[min(off, len(t.resp)):min(off+o.Len(), len(t.resp))]
+	}
+	return nil
 }
 
 func (t *transaction) Result(idx int) *Result {
 	return t.resp[idx]
 }
 
+// Error returns *OpError of the first failed operation, or nil if the transaction succeeded.
 func (t *transaction) Error() error {
 	for i, r := range t.resp {
-		if r.Error != nil {
-			var errStr string
-			if i >= len(t.txnSet) {
-				errStr = fmt.Sprintf("general transaction error: %v", r.Error)
-			} else {
-				errStr = fmt.Sprintf("operation #%d(%s): %v", i, t.txnSet[i].Name(), r.Error)
-			}
-			if r.Details != nil {
-				errStr += fmt.Sprintf(" (%v)", r.Details)
-			}
-			return errors.New(errStr)
+		if r == nil || r.Error == nil {
+			continue
+		}
+		opErr := OpError{Index: i, Tag: fmt.Sprint(r.Error)}
+		if i >= len(t.txnSet) {
+			opErr.Index = -1
+		} else {
+			opErr.Op = t.txnSet[i].Name()
+		}
+		if r.Details != nil {
+			opErr.Details = fmt.Sprint(r.Details)
 		}
+		return &opErr
 	}
 	return nil
 }
@@ -11,6 +11,7 @@ type updateOp struct {
 	Table string            `json:"table"`
 	Where []types.Condition `json:"where"`
 	Row   schema.Row        `json:"row"`
+	err   error             // of the conversion of the model to the row
 }
 
 func (u *updateOp) Name() string {
@@ -18,6 +19,9 @@ func (u *updateOp) Name() string {
 }
 
 func (u *updateOp) Validate(_ *schema.DbSchema) error {
+	if u.err != nil {
+		return fmt.Errorf("update: %w", u.err)
+	}
 	if u.Row == nil {
 		return fmt.Errorf("nil row to insert operation")
 	}
@@ -35,13 +39,34 @@ func (u *updateOp) Validate(_ *schema.DbSchema) error {
 	return nil
 }
 
-func (t *transaction) Update(where []types.Condition, row schema.Row) Transaction {
+func (t *transaction) Update(where []types.Condition, row any) Transaction {
+	r, err := t.toRow(row)
+	if _, ok := row.(schema.Row); !ok && r != nil {
+		r = mutableOnly(r)
+	}
 	t.txnSet = append(t.txnSet, &updateOp{
 		Op:    "update",
-		Table: row.TableName(),
+		Table: tableName(row),
 		Where: where,
-		Row:   row,
+		Row:   r,
+		err:   err,
 	})
 	t.resp = append(t.resp, &Result{})
 	return t
 }
+
+// mutableOnly returns the row without the immutable columns of r, the server rejects the update
+// of them, even to the same value.
+func mutableOnly(r schema.Row) schema.Row {
+	tSch := r.TableSchema()
+	out := tSch.NewRow()
+	for cName, cSch := range tSch.Columns {
+		if !cSch.Mutable {
+			continue
+		}
+		if value, ok := r.GetE(cName); ok {
+			out.Set(cName, value)
+		}
+	}
+	return out
+}
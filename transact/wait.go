@@ -9,7 +9,7 @@ import (
 
 type waitOp struct {
 	Op      string            `json:"op"`
-	Timeout int               `json:"timeout,omitempty"`
+	Timeout *int              `json:"timeout,omitempty"`
 	Table   string            `json:"table"`
 	Where   []types.Condition `json:"where"`
 	Columns []string          `json:"columns"`
@@ -50,15 +50,29 @@ func (w *waitOp) Validate(dSch *schema.DbSchema) error {
 	return nil
 }
 
+// Wait adds the wait operation (RFC 7047 5.2.6). Zero timeout is omitted, so the server
+// waits with no timeout; use WaitNow for the wait failing at once.
 func (t *transaction) Wait(tName string, where []types.Condition, columns []string, until string, rows []schema.Row, timeout int) Transaction {
+	var to *int
+	if timeout != 0 {
+		to = &timeout
+	}
+	return t.wait(tName, where, columns, until, rows, to)
+}
+
+func (t *transaction) WaitNow(tName string, where []types.Condition, columns []string, until string, rows []schema.Row) Transaction {
+	return t.wait(tName, where, columns, until, rows, new(int))
+}
+
+func (t *transaction) wait(tName string, where []types.Condition, columns []string, until string, rows []schema.Row, timeout *int) Transaction {
 	t.txnSet = append(t.txnSet, &waitOp{
 		Op:      "wait",
+		Timeout: timeout,
 		Table:   tName,
 		Where:   where,
 		Columns: columns,
 		Until:   until,
 		Rows:    rows,
-		Timeout: timeout,
 	})
 
 	t.resp = append(t.resp, &Result{})